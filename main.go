@@ -2,16 +2,83 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"flag"
 	"fmt"
 	"md-manual-tool/pkg/config"
 	"md-manual-tool/pkg/constants"
 	"md-manual-tool/pkg/document"
+	"md-manual-tool/pkg/i18n"
 	"md-manual-tool/pkg/input"
+	"md-manual-tool/pkg/processor"
+	"md-manual-tool/pkg/serve"
+	"md-manual-tool/pkg/template"
 	"md-manual-tool/pkg/ui"
+	"md-manual-tool/pkg/utils"
 	"md-manual-tool/pkg/validator"
+	"md-manual-tool/pkg/watch"
+	"net"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
 )
 
+// flagConfigKeys 命令行参数与配置项的对应关系，命令行参数优先于配置文件
+var flagConfigKeys = map[string]string{
+	"embed-images":    constants.ConfigKeyEmbedImages,
+	"embed-max-bytes": constants.ConfigKeyEmbedMaxBytes,
+	"keep-stale":      constants.ConfigKeyKeepStaleAssets,
+	"force":           constants.ConfigKeyForce,
+	"format":          constants.ConfigKeyOutputFormats,
+	"html-theme":      constants.ConfigKeyHTMLTheme,
+	"self-contained":  constants.ConfigKeyHTMLSelfContained,
+	"docx-reference":  constants.ConfigKeyDOCXReference,
+	"pdf-font":        constants.ConfigKeyPDFFont,
+	"toc-depth":       constants.ConfigKeyTOCDepth,
+	"numbering":       constants.ConfigKeyNumbering,
+	"timeout":         constants.ConfigKeyJobTimeout,
+	"languages":       constants.ConfigKeyLanguages,
+	"profile":         constants.ConfigKeyProfile,
+}
+
+// 监视模式参数
+var (
+	watchInterval time.Duration
+	watchDebounce time.Duration
+	watchPolling  bool
+)
+
+// 预览服务参数
+var serveAddr string
+
+// 界面语言参数，为空时根据 LC_ALL、LC_MESSAGES、LANG 环境变量检测
+var uiLang string
+
+// defineFlags 定义命令行参数
+func defineFlags() {
+	flag.DurationVar(&watchInterval, "watch-interval", watch.DefaultInterval, constants.FlagWatchInterval)
+	flag.DurationVar(&watchDebounce, "watch-debounce", watch.DefaultDebounce, constants.FlagWatchDebounce)
+	flag.BoolVar(&watchPolling, "watch-polling", false, constants.FlagWatchPolling)
+	flag.StringVar(&serveAddr, "addr", serve.DefaultAddr, constants.FlagAddr)
+	flag.StringVar(&uiLang, "lang", "", constants.FlagLang)
+	flag.Bool("embed-images", false, constants.FlagEmbedImages)
+	flag.Int("embed-max-bytes", 0, constants.FlagEmbedMaxBytes)
+	flag.Bool("keep-stale", false, constants.FlagKeepStale)
+	flag.Bool("force", false, constants.FlagForce)
+	flag.String("format", "", constants.FlagFormat)
+	flag.String("html-theme", "", constants.FlagHTMLTheme)
+	flag.Bool("self-contained", false, constants.FlagSelfContained)
+	flag.String("docx-reference", "", constants.FlagDOCXReference)
+	flag.String("pdf-font", "", constants.FlagPDFFont)
+	flag.Int("toc-depth", 0, constants.FlagTOCDepth)
+	flag.String("numbering", "", constants.FlagNumbering)
+	flag.String("timeout", "", constants.FlagTimeout)
+	flag.String("languages", "", constants.FlagLanguages)
+	flag.String("profile", "", constants.FlagProfile)
+}
+
 // Application 应用程序结构体
 type Application struct {
 	collector    *input.Collector
@@ -34,35 +101,227 @@ func NewApplication() *Application {
 	}
 }
 
-// Run 运行应用程序
-func (app *Application) Run() error {
+// ApplyFlags 将显式指定的命令行参数作为配置覆盖项
+func (app *Application) ApplyFlags() {
+	flag.Visit(func(f *flag.Flag) {
+		if key, ok := flagConfigKeys[f.Name]; ok {
+			app.configMgr.SetOverride(key, f.Value.String())
+		}
+	})
+}
+
+// Run 运行应用程序，ctx取消时中止生成
+func (app *Application) Run(ctx context.Context) error {
 	// 1. 收集用户输入
 	inputData, err := app.collectInputs()
 	if err != nil {
-		return fmt.Errorf(constants.ErrCollectInputs, err)
+		return i18n.Errorf(constants.ErrCollectInputs, err)
 	}
 
 	// 2. 验证输入
 	if err := app.validateInputs(inputData); err != nil {
-		return fmt.Errorf(constants.ErrValidateInputs, err)
+		return i18n.Errorf(constants.ErrValidateInputs, err)
 	}
 
 	// 3. 加载和处理配置
 	configData, err := app.loadConfig(inputData)
 	if err != nil {
-		return fmt.Errorf(constants.ErrLoadConfig, err)
+		return i18n.Errorf(constants.ErrLoadConfig, err)
 	}
 
 	// 4. 处理文档
-	if err := app.processDocument(configData); err != nil {
-		return fmt.Errorf(constants.ErrProcessDocument, err)
+	if err := app.processDocument(ctx, configData); err != nil {
+		return i18n.Errorf(constants.ErrProcessDocument, err)
 	}
 
 	// 5. 显示成功信息
-	app.ui.ShowSuccess(configData.OutputPath)
+	for _, job := range configData.Jobs() {
+		app.ui.ShowSuccess(job.OutputPath)
+	}
 	return nil
 }
 
+// Watch 监视模式：首次生成后，模板、配置文件或引用的图片变化时自动重新生成
+func (app *Application) Watch(ctx context.Context) error {
+	// 1. 收集并验证用户输入
+	inputData, err := app.collectInputs()
+	if err != nil {
+		return i18n.Errorf(constants.ErrCollectInputs, err)
+	}
+	if err := app.validateInputs(inputData); err != nil {
+		return i18n.Errorf(constants.ErrValidateInputs, err)
+	}
+
+	// 2. 首次生成
+	app.render(ctx, inputData)
+
+	// 3. 监视源文件变化
+	app.ui.ShowInfo(i18n.Msg(constants.MsgWatching))
+	return newWatcher().Run(ctx, app.sourceFiles(inputData), func(changed []string) {
+		app.ui.ShowChangedFiles(changed)
+		app.render(ctx, inputData)
+	})
+}
+
+// Serve 预览模式：在内存中渲染为HTML并在本地提供预览，源文件变化时自动刷新浏览器
+func (app *Application) Serve(ctx context.Context) error {
+	// 1. 收集并验证用户输入
+	inputData, err := app.collectInputs()
+	if err != nil {
+		return i18n.Errorf(constants.ErrCollectInputs, err)
+	}
+	if err := app.validateInputs(inputData); err != nil {
+		return i18n.Errorf(constants.ErrValidateInputs, err)
+	}
+
+	// 2. 首次渲染
+	server := serve.NewServer(filepath.Base(inputData.TemplatePath), func() (*processor.Preview, error) {
+		configData, err := app.loadConfig(inputData)
+		if err != nil {
+			return nil, i18n.Errorf(constants.ErrLoadConfig, err)
+		}
+		return app.docProcessor.Preview(configData, serve.AssetPrefix)
+	})
+	reload := func() {
+		start := time.Now()
+		err := server.Reload()
+		app.ui.ShowRenderResult("http://"+serveAddr, time.Since(start), i18n.Msg(constants.MsgMemoryRender), err)
+	}
+	reload()
+
+	// 3. 启动预览服务
+	listener, err := net.Listen("tcp", serveAddr)
+	if err != nil {
+		return i18n.Errorf(constants.ErrStartServer, err)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(ctx, listener)
+		cancel()
+	}()
+	app.ui.ShowInfoWithFormat(i18n.Msg(constants.MsgServing), listener.Addr())
+
+	// 4. 监视源文件变化
+	err = newWatcher().Run(ctx, app.sourceFiles(inputData), func(changed []string) {
+		app.ui.ShowChangedFiles(changed)
+		reload()
+	})
+	cancel()
+	if serveErrValue := <-serveErr; serveErrValue != nil {
+		return serveErrValue
+	}
+	return err
+}
+
+// Migrate 迁移模板：将文件名版本号对应的文本逐处确认后改写为 {{.version}} 占位符
+func (app *Application) Migrate() error {
+	// 1. 收集并验证模板和配置文件路径
+	inputData, err := app.collector.CollectPaths()
+	if err != nil {
+		return i18n.Errorf(constants.ErrCollectInputs, err)
+	}
+	if err := app.validateInputs(inputData); err != nil {
+		return i18n.Errorf(constants.ErrValidateInputs, err)
+	}
+	oldVersion := utils.NewVersionUtils().ExtractVersionFromFilename(inputData.TemplatePath)
+	if oldVersion == "" {
+		return i18n.Errorf(constants.ErrMigrateNoVersion)
+	}
+
+	// 2. 按配置的替换规则查找版本号并逐处确认
+	configData, err := app.loadConfig(inputData)
+	if err != nil {
+		return i18n.Errorf(constants.ErrLoadConfig, err)
+	}
+	rules, err := template.ParseVersionRules(configData.Config.Variables)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(inputData.TemplatePath)
+	if err != nil {
+		return i18n.Errorf(constants.ErrReadTemplate, err)
+	}
+
+	decision := ""
+	migrated, count, err := template.MigrateVersions(string(content), oldVersion, rules, func(occ template.VersionOccurrence, line string) (bool, error) {
+		app.ui.ShowInfoWithFormat(i18n.Msg(constants.MsgMigrateOccurrence), occ.Line, occ.Text, line)
+		if decision != "" {
+			return decision == "a", nil
+		}
+		for {
+			answer, err := app.collector.Ask(i18n.Msg(constants.PromptMigrate))
+			if err != nil {
+				return false, err
+			}
+			switch answer {
+			case "y", "yes":
+				return true, nil
+			case "n", "no", "":
+				return false, nil
+			case "a", "q":
+				decision = answer
+				return answer == "a", nil
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		app.ui.ShowInfo(i18n.Msg(constants.MsgMigrateNone))
+		return nil
+	}
+
+	// 3. 备份原模板后写入
+	backup := inputData.TemplatePath + ".bak"
+	if err := os.WriteFile(backup, content, 0644); err != nil {
+		return i18n.Errorf(constants.ErrBackupTemplate, err)
+	}
+	if err := os.WriteFile(inputData.TemplatePath, []byte(migrated), 0644); err != nil {
+		return i18n.Errorf(constants.ErrWriteTemplate, err)
+	}
+	app.ui.ShowInfoWithFormat(i18n.Msg(constants.MsgMigrateDone), count, inputData.TemplatePath, backup)
+	return nil
+}
+
+// sourceFiles 返回获取监视文件列表的函数，每次重新读取配置以获取最新的图片列表
+func (app *Application) sourceFiles(inputData *input.InputData) func() []string {
+	return func() []string {
+		configData, err := app.loadConfig(inputData)
+		if err != nil {
+			return []string{inputData.TemplatePath, inputData.ConfigPath}
+		}
+		return app.docProcessor.SourceFiles(configData, inputData.ConfigPath)
+	}
+}
+
+// newWatcher 根据命令行参数创建文件监视器
+func newWatcher() *watch.Watcher {
+	w := watch.NewWatcher()
+	w.Interval = watchInterval
+	w.Debounce = watchDebounce
+	w.Polling = watchPolling
+	return w
+}
+
+// render 重新加载配置并生成文档，输出简要结果（监视模式使用）
+func (app *Application) render(ctx context.Context, inputData *input.InputData) {
+	start := time.Now()
+	configData, err := app.loadConfig(inputData)
+	if err != nil {
+		app.ui.ShowRenderResult(inputData.TemplatePath, time.Since(start), "", i18n.Errorf(constants.ErrLoadConfig, err))
+		return
+	}
+	err = app.processDocument(ctx, configData)
+	var outputs []string
+	for _, job := range configData.Jobs() {
+		outputs = append(outputs, job.OutputPath)
+	}
+	app.ui.ShowRenderResult(strings.Join(outputs, "、"), time.Since(start), app.docProcessor.CacheStats().String(), err)
+}
+
 // collectInputs 收集用户输入
 func (app *Application) collectInputs() (*input.InputData, error) {
 	return app.collector.CollectAll()
@@ -74,7 +333,7 @@ func (app *Application) validateInputs(inputData *input.InputData) error {
 	result := app.validator.ValidateInputs(inputData.TemplatePath, inputData.ConfigPath)
 	if !result.IsValid {
 		app.ui.ShowValidationErrors(result.Errors)
-		return fmt.Errorf("输入验证失败")
+		return i18n.Errorf(constants.ErrInvalidInputs)
 	}
 
 	// 验证版本号格式
@@ -95,14 +354,79 @@ func (app *Application) loadConfig(inputData *input.InputData) (*config.ConfigDa
 }
 
 // processDocument 处理文档
-func (app *Application) processDocument(configData *config.ConfigData) error {
-	return app.docProcessor.ProcessDocument(configData)
+func (app *Application) processDocument(ctx context.Context, configData *config.ConfigData) error {
+	return app.docProcessor.ProcessDocument(ctx, configData)
+}
+
+// interruptContext 返回按下Ctrl+C时取消的ctx，正在进行的生成中止并清理未完成的输出；
+// 取消后恢复默认的信号处理，再次按下Ctrl+C立即退出
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return ctx, stop
+}
+
+// usage 按界面语言输出命令行帮助，参数说明保存的是消息键，输出前翻译
+func usage() {
+	if uiLang != "" {
+		i18n.SetLocale(uiLang)
+	}
+	out := flag.CommandLine.Output()
+	fmt.Fprint(out, i18n.Sprintf(constants.MsgUsage, filepath.Base(os.Args[0])))
+	flag.VisitAll(func(f *flag.Flag) {
+		f.Usage = i18n.Msg(f.Usage)
+	})
+	flag.PrintDefaults()
 }
 
 func main() {
+	defineFlags()
+	// 解析参数前先按环境变量设置界面语言，-h 和参数错误时的帮助也随之翻译
+	i18n.SetLocale(i18n.DetectLocale(os.Getenv))
+	flag.Usage = usage
+	flag.Parse()
+
+	// 子命令之后也允许出现参数，如 md-manual-tool watch --force
+	command := flag.Arg(0)
+	if command != "" {
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+
+	// 界面语言：--lang 优先于环境变量
+	if uiLang == "" {
+		uiLang = i18n.DetectLocale(os.Getenv)
+	}
+	if err := i18n.SetLocale(uiLang); err != nil {
+		fmt.Print(i18n.Sprintf(constants.MsgError, err))
+		os.Exit(1)
+	}
+
 	app := NewApplication()
-	if err := app.Run(); err != nil {
-		fmt.Printf("错误：%v\n", err)
+	app.ApplyFlags()
+
+	var err error
+	switch command {
+	case "", "watch", "serve":
+		ctx, stop := interruptContext()
+		switch command {
+		case "":
+			err = app.Run(ctx)
+		case "watch":
+			err = app.Watch(ctx)
+		default:
+			err = app.Serve(ctx)
+		}
+		stop()
+	case "migrate":
+		err = app.Migrate()
+	default:
+		err = i18n.Errorf(constants.ErrUnknownCommand, command)
+	}
+	if err != nil {
+		fmt.Print(i18n.Sprintf(constants.MsgError, err))
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,200 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"md-manual-tool/pkg/constants"
+	"md-manual-tool/pkg/i18n"
+	"md-manual-tool/pkg/vfs"
+	"path/filepath"
+)
+
+// FileName 缓存文件名，保存在输出目录中
+const FileName = ".md-manual-tool.cache.json"
+
+// Stats 缓存命中统计
+type Stats struct {
+	JobHits     int
+	JobMisses   int
+	ImageHits   int
+	ImageMisses int
+}
+
+// String 格式化统计信息
+func (s Stats) String() string {
+	return i18n.Sprintf(constants.MsgCacheStats, s.JobHits, s.JobMisses, s.ImageHits, s.ImageMisses)
+}
+
+// Add 返回两次统计之和（多语言生成时汇总各语言版本）
+func (s Stats) Add(other Stats) Stats {
+	return Stats{
+		JobHits:     s.JobHits + other.JobHits,
+		JobMisses:   s.JobMisses + other.JobMisses,
+		ImageHits:   s.ImageHits + other.ImageHits,
+		ImageMisses: s.ImageMisses + other.ImageMisses,
+	}
+}
+
+// jobEntry 文档生成记录
+type jobEntry struct {
+	Key       string            `json:"key"`
+	OutputSum string            `json:"outputSum"`
+	Extras    map[string]string `json:"extras,omitempty"` // 同一任务生成的其他文件 -> 摘要
+}
+
+// imageEntry 图片处理记录
+type imageEntry struct {
+	Filename string `json:"filename"`
+	Sum      string `json:"sum"`
+}
+
+// cacheData 缓存文件内容
+type cacheData struct {
+	Jobs   map[string]jobEntry   `json:"jobs"`
+	Images map[string]imageEntry `json:"images"`
+}
+
+// Cache 增量渲染缓存
+type Cache struct {
+	fs    vfs.WriteFS
+	path  string
+	data  cacheData
+	Force bool // 忽略已有记录，强制重新生成（仍会记录本次结果）
+	Stats Stats
+}
+
+// Open 打开本地缓存文件，文件不存在或已损坏时返回空缓存
+func Open(path string) *Cache {
+	return OpenFS(vfs.OSFS{}, path)
+}
+
+// OpenFS 打开文件系统中的缓存文件，输出文件和图片也在该文件系统中检查
+func OpenFS(fsys vfs.WriteFS, path string) *Cache {
+	c := &Cache{
+		fs:   fsys,
+		path: path,
+		data: cacheData{Jobs: make(map[string]jobEntry), Images: make(map[string]imageEntry)},
+	}
+
+	content, err := vfs.ReadFile(fsys, path)
+	if err != nil {
+		return c
+	}
+	var data cacheData
+	if err := json.Unmarshal(content, &data); err != nil {
+		fmt.Print(i18n.Sprintf(constants.MsgCacheCorrupt, err))
+		return c
+	}
+	if data.Jobs != nil {
+		c.data.Jobs = data.Jobs
+	}
+	if data.Images != nil {
+		c.data.Images = data.Images
+	}
+	return c
+}
+
+// Save 保存缓存文件
+func (c *Cache) Save() error {
+	content, err := json.MarshalIndent(c.data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("生成缓存失败: %v", err)
+	}
+	if err := vfs.MkdirAll(c.fs, filepath.Dir(c.path)); err != nil {
+		return fmt.Errorf("创建缓存目录失败: %v", err)
+	}
+	return c.fs.WriteFile(vfs.Name(c.fs, c.path), content)
+}
+
+// JobUpToDate 判断输出文件是否已由相同输入生成且未被修改
+func (c *Cache) JobUpToDate(outputPath, key string) bool {
+	entry, ok := c.data.Jobs[c.jobID(outputPath)]
+	if !c.Force && ok && entry.Key == key && c.fileSum(outputPath) == entry.OutputSum && c.extrasIntact(entry.Extras) {
+		c.Stats.JobHits++
+		return true
+	}
+	c.Stats.JobMisses++
+	return false
+}
+
+// extrasIntact 检查任务生成的其他文件是否未被修改
+func (c *Cache) extrasIntact(extras map[string]string) bool {
+	for path, sum := range extras {
+		if c.fileSum(path) != sum {
+			return false
+		}
+	}
+	return true
+}
+
+// RecordJob 记录输出文件的生成结果
+func (c *Cache) RecordJob(outputPath, key string, output []byte) {
+	c.data.Jobs[c.jobID(outputPath)] = jobEntry{Key: key, OutputSum: Checksum(output)}
+}
+
+// RecordExtra 记录同一任务生成的其他文件（如导出的HTML），需在RecordJob之后调用
+func (c *Cache) RecordExtra(outputPath, extraPath string, data []byte) {
+	entry, ok := c.data.Jobs[c.jobID(outputPath)]
+	if !ok {
+		return
+	}
+	if entry.Extras == nil {
+		entry.Extras = make(map[string]string)
+	}
+	entry.Extras[c.jobID(extraPath)] = Checksum(data)
+	c.data.Jobs[c.jobID(outputPath)] = entry
+}
+
+// Invalidate 删除输出文件的生成记录（如其依赖的图片已丢失）
+func (c *Cache) Invalidate(outputPath string) {
+	delete(c.data.Jobs, c.jobID(outputPath))
+}
+
+// LookupImage 查找相同输入处理过的图片，目标文件仍存在且未被修改时返回其文件名
+func (c *Cache) LookupImage(key, dir string) (string, bool) {
+	entry, ok := c.data.Images[key]
+	if !c.Force && ok && c.fileSum(filepath.Join(dir, entry.Filename)) == entry.Sum {
+		c.Stats.ImageHits++
+		return entry.Filename, true
+	}
+	c.Stats.ImageMisses++
+	return "", false
+}
+
+// StoreImage 记录图片处理结果
+func (c *Cache) StoreImage(key, filename string, data []byte) {
+	c.data.Images[key] = imageEntry{Filename: filename, Sum: Checksum(data)}
+}
+
+// Checksum 计算若干数据片段的SHA-256摘要
+func Checksum(parts ...[]byte) string {
+	h := sha256.New()
+	for _, part := range parts {
+		// 写入长度前缀，避免不同切分方式产生相同摘要
+		fmt.Fprintf(h, "%d:", len(part))
+		h.Write(part)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// jobID 以输出文件的绝对路径标识任务，非本地文件系统中使用文件系统内的路径
+func (c *Cache) jobID(outputPath string) string {
+	if !vfs.Native(c.fs) {
+		return vfs.Clean(outputPath)
+	}
+	if abs, err := filepath.Abs(outputPath); err == nil {
+		return abs
+	}
+	return outputPath
+}
+
+// fileSum 计算文件摘要，文件不存在时返回空字符串
+func (c *Cache) fileSum(path string) string {
+	data, err := vfs.ReadFile(c.fs, path)
+	if err != nil {
+		return ""
+	}
+	return Checksum(data)
+}
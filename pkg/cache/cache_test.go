@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"md-manual-tool/pkg/vfs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCacheJobs(t *testing.T) {
+	tempDir := t.TempDir()
+	cachePath := filepath.Join(tempDir, FileName)
+	outputPath := filepath.Join(tempDir, "manual.md")
+
+	c := Open(cachePath)
+	if c.JobUpToDate(outputPath, "k1") {
+		t.Error("空缓存不应命中")
+	}
+	os.WriteFile(outputPath, []byte("output"), 0644)
+	c.RecordJob(outputPath, "k1", []byte("output"))
+	if err := c.Save(); err != nil {
+		t.Fatalf("保存缓存失败: %v", err)
+	}
+
+	c = Open(cachePath)
+	if !c.JobUpToDate(outputPath, "k1") {
+		t.Error("相同输入应命中缓存")
+	}
+	if c.JobUpToDate(outputPath, "k2") {
+		t.Error("输入变化时不应命中缓存")
+	}
+
+	// 输出文件被手动修改后不再命中
+	os.WriteFile(outputPath, []byte("edited"), 0644)
+	if c.JobUpToDate(outputPath, "k1") {
+		t.Error("输出文件被修改时不应命中缓存")
+	}
+
+	if c.Stats.JobHits != 1 || c.Stats.JobMisses != 2 {
+		t.Errorf("统计错误: %+v", c.Stats)
+	}
+}
+
+func TestCacheJobExtras(t *testing.T) {
+	fsys := vfs.NewMemFS(map[string]string{"out/manual.md": "output", "out/manual.html": "<p>output</p>"})
+
+	c := OpenFS(fsys, "out/"+FileName)
+	c.RecordJob("out/manual.md", "k1", []byte("output"))
+	c.RecordExtra("out/manual.md", "out/manual.html", []byte("<p>output</p>"))
+	if err := c.Save(); err != nil {
+		t.Fatalf("保存缓存失败: %v", err)
+	}
+	c = OpenFS(fsys, "out/"+FileName)
+	if !c.JobUpToDate("./out/manual.md", "k1") {
+		t.Error("输出文件完好时应命中缓存")
+	}
+
+	// 导出的文件被删除后不再命中
+	fsys.Remove("out/manual.html")
+	if c.JobUpToDate("out/manual.md", "k1") {
+		t.Error("导出的文件缺失时不应命中缓存")
+	}
+}
+
+func TestCacheImagesAndForce(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "a.png"), []byte("png"), 0644)
+
+	c := Open(filepath.Join(tempDir, FileName))
+	c.StoreImage("key", "a.png", []byte("png"))
+	if filename, ok := c.LookupImage("key", tempDir); !ok || filename != "a.png" {
+		t.Errorf("应命中图片缓存: %s %v", filename, ok)
+	}
+	if _, ok := c.LookupImage("key", filepath.Join(tempDir, "other")); ok {
+		t.Error("目标文件不存在时不应命中")
+	}
+
+	c.Force = true
+	if _, ok := c.LookupImage("key", tempDir); ok {
+		t.Error("强制模式下不应命中")
+	}
+	if c.Stats.ImageHits != 1 || c.Stats.ImageMisses != 2 {
+		t.Errorf("统计错误: %+v", c.Stats)
+	}
+}
+
+func TestOpenCorruptCache(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), FileName)
+	os.WriteFile(cachePath, []byte("{not json"), 0644)
+	if c := Open(cachePath); c.JobUpToDate("x.md", "k") {
+		t.Error("损坏的缓存不应命中")
+	}
+}
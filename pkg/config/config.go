@@ -1,8 +1,11 @@
 package config
 
 import (
+	"fmt"
 	"io/ioutil"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // Config 配置结构体
@@ -36,3 +39,73 @@ func ReadConfig(configPath string) (*Config, error) {
 
 	return config, nil
 }
+
+// Clone 返回配置的副本
+func (c *Config) Clone() *Config {
+	clone := &Config{Variables: make(map[string]string, len(c.Variables))}
+	for key, value := range c.Variables {
+		clone.Variables[key] = value
+	}
+	return clone
+}
+
+// GetString 获取字符串配置项，不存在或为空时返回默认值
+func (c *Config) GetString(key, defaultValue string) string {
+	if value, exists := c.Variables[key]; exists && value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// GetInt 获取整数配置项，不存在时返回默认值
+func (c *Config) GetInt(key string, defaultValue int) (int, error) {
+	value, exists := c.Variables[key]
+	if !exists || value == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return defaultValue, fmt.Errorf("配置项 %s 不是有效的整数: %s", key, value)
+	}
+	return n, nil
+}
+
+// GetBool 获取布尔配置项，不存在时返回默认值
+func (c *Config) GetBool(key string, defaultValue bool) (bool, error) {
+	value, exists := c.Variables[key]
+	if !exists || value == "" {
+		return defaultValue, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return defaultValue, fmt.Errorf("配置项 %s 不是有效的布尔值: %s", key, value)
+	}
+	return b, nil
+}
+
+// GetDuration 获取时长配置项，支持 90s、5m 等写法，纯数字按秒计算；不存在时返回默认值
+func (c *Config) GetDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	value, exists := c.Variables[key]
+	if !exists || value == "" {
+		return defaultValue, nil
+	}
+	d, err := time.ParseDuration(value)
+	if seconds, atoiErr := strconv.Atoi(value); atoiErr == nil {
+		d, err = time.Duration(seconds)*time.Second, nil
+	}
+	if err != nil || d < 0 {
+		return defaultValue, fmt.Errorf("配置项 %s 不是有效的时长: %s（如 90s、5m）", key, value)
+	}
+	return d, nil
+}
+
+// GetList 获取以逗号分隔的列表配置项
+func (c *Config) GetList(key string) []string {
+	var items []string
+	for _, item := range strings.Split(c.Variables[key], ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -2,7 +2,9 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestReadConfig(t *testing.T) {
@@ -45,3 +47,82 @@ email: test@example.com`
 		}
 	}
 }
+
+func TestGetDuration(t *testing.T) {
+	config := &Config{Variables: map[string]string{
+		"seconds":  "90",
+		"minutes":  "5m",
+		"empty":    "",
+		"invalid":  "五分钟",
+		"negative": "-1",
+	}}
+
+	tests := []struct {
+		key     string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"seconds", 90 * time.Second, false},
+		{"minutes", 5 * time.Minute, false},
+		{"empty", time.Minute, false},
+		{"missing", time.Minute, false},
+		{"invalid", time.Minute, true},
+		{"negative", time.Minute, true},
+	}
+	for _, tt := range tests {
+		got, err := config.GetDuration(tt.key, time.Minute)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("GetDuration(%s) = %v, %v；期望 %v，出错 %v", tt.key, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestLanguageVariants(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	os.WriteFile(configPath, []byte("productName: 产品数据管理\nsupport: 400-123-4567\nlanguages: zh-CN, en-US\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "config.en-US.yaml"), []byte("productName: Product Data Management\nsupport: overlay\n"), 0644)
+
+	m := NewManager()
+	m.SetOverride("support", "flag")
+	data, err := m.LoadAndProcessConfig(configPath, filepath.Join(dir, "手册_1.0.0.md"), "1.1.0")
+	if err != nil {
+		t.Fatalf("加载配置失败: %v", err)
+	}
+	jobs := data.Jobs()
+	if len(jobs) != 2 {
+		t.Fatalf("应生成2个语言版本，实际 %d", len(jobs))
+	}
+
+	tests := []struct {
+		lang, productName, output string
+	}{
+		{"zh-CN", "产品数据管理", "手册_1.1.0.zh-CN.md"},
+		{"en-US", "Product Data Management", "手册_1.1.0.en-US.md"},
+	}
+	for i, tt := range tests {
+		job := jobs[i]
+		if job.Language != tt.lang || filepath.Base(job.OutputPath) != tt.output {
+			t.Errorf("语言版本 %d: %s %s，期望 %s %s", i, job.Language, job.OutputPath, tt.lang, tt.output)
+		}
+		vars := job.Config.Variables
+		if vars["productName"] != tt.productName || vars["lang"] != tt.lang || vars["version"] != "1.1.0" {
+			t.Errorf("%s 的配置错误: %v", tt.lang, vars)
+		}
+		// 命令行参数优先于语言覆盖配置
+		if vars["support"] != "flag" {
+			t.Errorf("%s 的support应为命令行参数: %s", tt.lang, vars["support"])
+		}
+		if vars["i18nDir"] != filepath.Join(dir, "i18n") {
+			t.Errorf("消息目录应相对于配置文件: %s", vars["i18nDir"])
+		}
+	}
+	if data.Config.Variables["lang"] != "" {
+		t.Error("语言版本不应修改原配置")
+	}
+
+	os.WriteFile(configPath, []byte("languages: zh-CN, ../en\n"), 0644)
+	if _, err := m.LoadAndProcessConfig(configPath, filepath.Join(dir, "手册_1.0.0.md"), ""); err == nil {
+		t.Error("应拒绝无效的语言标签")
+	}
+}
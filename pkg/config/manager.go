@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"md-manual-tool/pkg/constants"
+	"md-manual-tool/pkg/i18n"
 	"md-manual-tool/pkg/utils"
 	"os"
 	"path/filepath"
@@ -11,21 +12,40 @@ import (
 // Manager 配置管理器
 type Manager struct {
 	versionUtils *utils.VersionUtils
+	overrides    map[string]string
 }
 
 // NewManager 创建新的配置管理器
 func NewManager() *Manager {
 	return &Manager{
 		versionUtils: utils.NewVersionUtils(),
+		overrides:    make(map[string]string),
 	}
 }
 
+// SetOverride 设置覆盖配置文件的配置项（如命令行参数）
+func (m *Manager) SetOverride(key, value string) {
+	m.overrides[key] = value
+}
+
 // ConfigData 配置数据结构
 type ConfigData struct {
 	Config       *Config
 	OutputPath   string
 	TemplatePath string
 	Version      string
+
+	Language string        // 语言版本的语言，未配置languages时为空
+	Sources  []string      // 语言版本额外依赖的文件：语言覆盖配置和消息目录
+	Variants []*ConfigData // 按languages生成的各语言版本，未配置时为空
+}
+
+// Jobs 返回需要生成的文档：配置了languages时为各语言版本，否则为自身
+func (d *ConfigData) Jobs() []*ConfigData {
+	if len(d.Variants) > 0 {
+		return d.Variants
+	}
+	return []*ConfigData{d}
 }
 
 // LoadAndProcessConfig 加载并处理配置
@@ -33,13 +53,21 @@ func (m *Manager) LoadAndProcessConfig(configPath, templatePath, version string)
 	// 读取配置文件
 	cfg, err := ReadConfig(configPath)
 	if err != nil {
-		return nil, fmt.Errorf(constants.ErrReadConfig, err)
+		return nil, i18n.Errorf(constants.ErrReadConfig, err)
+	}
+
+	// 应用覆盖配置项
+	for key, value := range m.overrides {
+		cfg.Variables[key] = value
 	}
 
 	// 将版本参数添加到配置变量中
 	if version != "" {
 		cfg.Variables["version"] = version
-		fmt.Printf(constants.MsgVersionAdded, version)
+		fmt.Print(i18n.Sprintf(constants.MsgVersionAdded, version))
+	}
+	if err := validateLanguages(cfg); err != nil {
+		return nil, err
 	}
 
 	// 生成输出文件名
@@ -52,12 +80,68 @@ func (m *Manager) LoadAndProcessConfig(configPath, templatePath, version string)
 	}
 	outputPath := filepath.Join(currentDir, "output", outputFilename)
 
-	return &ConfigData{
+	data := &ConfigData{
 		Config:       cfg,
 		OutputPath:   outputPath,
 		TemplatePath: templatePath,
 		Version:      version,
-	}, nil
+	}
+	if err := m.addLanguageVariants(data, configPath); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// validateLanguages 检查languages中的语言标签
+func validateLanguages(cfg *Config) error {
+	for _, lang := range cfg.GetList(constants.ConfigKeyLanguages) {
+		if err := i18n.ValidateLang(lang); err != nil {
+			return fmt.Errorf("配置项 %s: %v", constants.ConfigKeyLanguages, err)
+		}
+	}
+	return nil
+}
+
+// addLanguageVariants 按languages为每种语言生成一个版本：在配置文件之上依次应用语言覆盖配置
+// （如 config.en-US.yaml，不存在时跳过）、命令行参数和版本号，输出文件名加上语言后缀
+func (m *Manager) addLanguageVariants(data *ConfigData, configPath string) error {
+	dir := data.Config.GetString(constants.ConfigKeyI18nDir, i18n.DefaultDir)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(filepath.Dir(configPath), dir)
+	}
+
+	for _, lang := range data.Config.GetList(constants.ConfigKeyLanguages) {
+		cfg := data.Config.Clone()
+		overlayPath := i18n.LangPath(configPath, lang)
+		overlay, err := ReadConfig(overlayPath)
+		switch {
+		case err == nil:
+			for key, value := range overlay.Variables {
+				cfg.Variables[key] = value
+			}
+			fmt.Print(i18n.Sprintf(constants.MsgLanguageConfig, overlayPath))
+		case !os.IsNotExist(err):
+			return i18n.Errorf(constants.ErrReadConfig, err)
+		}
+		for key, value := range m.overrides {
+			cfg.Variables[key] = value
+		}
+		if data.Version != "" {
+			cfg.Variables["version"] = data.Version
+		}
+		cfg.Variables[constants.ConfigKeyLang] = lang
+		cfg.Variables[constants.ConfigKeyI18nDir] = dir
+
+		data.Variants = append(data.Variants, &ConfigData{
+			Config:       cfg,
+			OutputPath:   i18n.LangPath(data.OutputPath, lang),
+			TemplatePath: data.TemplatePath,
+			Version:      data.Version,
+			Language:     lang,
+			Sources:      []string{overlayPath, i18n.CatalogPath(dir, lang)},
+		})
+	}
+	return nil
 }
 
 // AddVersionToConfig 将版本号添加到配置中
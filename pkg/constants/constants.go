@@ -6,39 +6,237 @@ const (
 	DefaultOutputFile = "output.md"
 )
 
+// 配置项键名（与模板变量共用同一配置文件）
+const (
+	ConfigKeyRemoteImages   = "remoteImages"   // 远程图片策略：keep、download、reject
+	ConfigKeyRemoteTimeout  = "remoteTimeout"  // 远程图片下载超时（秒）
+	ConfigKeyRemoteMaxBytes = "remoteMaxBytes" // 远程图片大小上限（字节）
+	ConfigKeyRemoteCacheDir = "remoteCacheDir" // 远程图片下载缓存目录
+	ConfigKeyEmbedImages    = "embedImages"    // 是否将图片以data URI内嵌
+	ConfigKeyEmbedMaxBytes  = "embedMaxBytes"  // 内嵌图片大小上限（字节）
+	ConfigKeyImageMaxWidth  = "imageMaxWidth"  // 图片最大宽度（像素）
+	ConfigKeyImageMaxHeight = "imageMaxHeight" // 图片最大高度（像素）
+	ConfigKeyPNGCompression = "pngCompression" // 是否重新压缩PNG
+	ConfigKeyJPEGQuality    = "jpegQuality"    // JPEG重新编码质量（1-100）
+	ConfigKeyConvertToWebP  = "convertToWebp"  // 是否转换为WebP无损格式
+
+	ConfigKeyImageCheck          = "imageCheck"          // 图片检查级别：off、warn、error
+	ConfigKeyImageCheckMaxBytes  = "imageCheckMaxBytes"  // 图片文件大小上限（字节）
+	ConfigKeyImageCheckMaxWidth  = "imageCheckMaxWidth"  // 图片宽度上限（像素）
+	ConfigKeyImageCheckMaxHeight = "imageCheckMaxHeight" // 图片高度上限（像素）
+	ConfigKeyImageCheckExtension = "imageCheckExtension" // 图片内容必须与扩展名一致
+
+	ConfigKeyAssetDir       = "assetDir"       // 图片目录模式，如 assets/{product}/{version}
+	ConfigKeyAssetURLPrefix = "assetURLPrefix" // 图片引用前缀，如 /static/img/{version}
+
+	ConfigKeyImageSearchPaths = "imageSearchPaths" // 图片搜索目录，逗号分隔，按顺序查找
+	ConfigKeyImageStrictPaths = "imageStrictPaths" // 只接受相对于模板目录的字面路径
+	ConfigKeyKeepStaleAssets  = "keepStaleAssets"  // 保留图片目录中不再引用的旧图片
+	ConfigKeyForce            = "force"            // 忽略缓存，强制重新生成
+	ConfigKeyJobTimeout       = "jobTimeout"       // 单次生成的超时时间，如 90s、5m，纯数字按秒计算
+
+	ConfigKeyLanguages = "languages" // 生成的语言版本，逗号分隔，如 zh-CN, en-US
+	ConfigKeyI18nDir   = "i18nDir"   // 消息目录所在目录，相对于配置文件，默认 i18n
+	ConfigKeyLang      = "lang"      // 当前语言版本，由工具按languages设置，模板中可用 {{.lang}}
+
+	ConfigKeyProfile = "profile" // 部署画像，逗号分隔，如 edition=enterprise, os=linux，模板中可用 {{if has .profile "linux"}}
+
+	ConfigKeyOutputFormats     = "outputFormats"     // 额外输出格式，逗号分隔，如 html
+	ConfigKeyHTMLTheme         = "htmlTheme"         // HTML主题：内置主题名或CSS文件路径
+	ConfigKeyHTMLSelfContained = "htmlSelfContained" // 内嵌CSS和图片，生成单个HTML文件
+	ConfigKeyDOCXReference     = "docxReference"     // DOCX参考文档，使用其中的样式
+	ConfigKeyPDFFont           = "pdfFont"           // PDF正文字体文件（TrueType，需包含中文字形）
+	ConfigKeyPDFMonoFont       = "pdfMonoFont"       // PDF代码字体文件
+	ConfigKeyPDFHeader         = "pdfHeader"         // PDF页眉，如 {productName} {version}
+	ConfigKeyPDFFooter         = "pdfFooter"         // PDF页脚，{page}和{pages}为页码和总页数
+	ConfigKeyPDFTOC            = "pdfTOC"            // PDF是否在正文前生成目录页
+
+	ConfigKeyTOCDepth  = "tocDepth"  // [TOC]目录包含的最大标题级别
+	ConfigKeySlugStyle = "slugStyle" // 标题锚点风格：github、typora
+
+	ConfigKeyNumbering      = "numbering"      // 自动编号的内容：headings、figures、tables或all，逗号分隔
+	ConfigKeyNumberingDepth = "numberingDepth" // 编号的标题层数
+	ConfigKeyChapterLevel   = "chapterLevel"   // 作为"章"的标题级别，默认自动判断
+
+	ConfigKeyVersionPrefixes      = "versionPrefixes"      // 允许紧贴版本号的前缀，逗号分隔，如 V, 版本：
+	ConfigKeyVersionRequirePrefix = "versionRequirePrefix" // 只替换带前缀的版本号
+	ConfigKeyVersionExclude       = "versionExclude"       // 排除规则（正则），匹配范围内的版本号不替换
+	ConfigKeyVersionProtect       = "versionProtect"       // 保护区域（正则），匹配范围内的内容不替换
+	ConfigKeyVersionReplace       = "versionReplace"       // 是否按文本替换模板中的原版本号，使用占位符的模板可关闭
+	ConfigKeyVersionDate          = "versionDate"          // 版本发布日期，模板中的 {{.versionDate}}，默认为当天
+)
+
+// 界面消息的键，对应的文本位于 pkg/i18n/locales 中各语言的消息目录，通过 i18n.Msg、i18n.Sprintf 和 i18n.Errorf 使用
+
 // 用户提示消息
 const (
-	PromptTemplatePath = "请输入模板文件路径（如 templates/template.md）："
-	PromptConfigPath   = "请输入配置文件路径（如 D:/config.yaml，直接回车则使用工具的当前目录的config.yaml）："
-	PromptVersion      = "请输入文件名中的版本号（如 1.0.1）："
+	PromptTemplatePath = "prompt.templatePath" // 请输入模板文件路径
+	PromptConfigPath   = "prompt.configPath"   // 请输入配置文件路径
+	PromptVersion      = "prompt.version"      // 请输入版本号
+	PromptMigrate      = "prompt.migrate"      // 迁移时逐处确认
 )
 
 // 成功消息
 const (
-	MsgDefaultConfigUsed = "使用默认配置文件：%s"
-	MsgVersionDetected   = "检测到模板文件中的版本号：%s"
-	MsgVersionAdded      = "版本参数已添加：%s"
-	MsgFileGenerated     = "文件生成成功！输出路径：%s"
+	MsgDefaultConfigUsed = "msg.defaultConfigUsed" // 使用默认配置文件：%s
+	MsgVersionDetected   = "msg.versionDetected"   // 检测到模板文件中的版本号：%s
+	MsgVersionAdded      = "msg.versionAdded"      // 版本参数已添加：%s
+	MsgFileGenerated     = "msg.fileGenerated"     // 文件生成成功！输出路径：%s
+	MsgError             = "msg.error"             // 错误：%v
+	MsgValidationFailed  = "msg.validationFailed"  // 验证失败：
+	MsgProgress          = "msg.progress"          // 正在处理：%s
+	MsgFilesChanged      = "msg.filesChanged"      // [时间] 检测到变化：[文件]
+	MsgRenderFailed      = "msg.renderFailed"      // [时间] 生成失败（耗时）：错误
+	MsgRenderSucceeded   = "msg.renderSucceeded"   // [时间] 生成成功：输出（耗时；统计）
+	MsgWatching          = "msg.watching"          // 正在监视变化
+	MsgServing           = "msg.serving"           // 预览地址
+	MsgMemoryRender      = "msg.memoryRender"      // 内存渲染
+	MsgMigrateOccurrence = "msg.migrateOccurrence" // 第%d行的版本号
+	MsgMigrateNone       = "msg.migrateNone"       // 没有改写任何版本号
+	MsgMigrateDone       = "msg.migrateDone"       // 已改写为占位符
+)
+
+// 生成过程消息
+const (
+	MsgCacheCorrupt          = "msg.cacheCorrupt"          // 缓存文件已损坏
+	MsgCacheStats            = "msg.cacheStats"            // 缓存命中统计
+	MsgLanguageConfig        = "msg.languageConfig"        // 已应用语言覆盖配置
+	MsgLanguageJob           = "msg.languageJob"           // 生成语言版本
+	MsgEmbedImageFailed      = "msg.embedImageFailed"      // 导出时无法嵌入图片
+	MsgDOCXUnsupportedImage  = "msg.docxUnsupportedImage"  // Word不支持的图片格式
+	MsgInlineImageFailed     = "msg.inlineImageFailed"     // HTML无法内嵌图片
+	MsgFontMissingChars      = "msg.fontMissingChars"      // 字体缺少字符
+	MsgRollbackFailed        = "msg.rollbackFailed"        // 清理未完成的输出失败
+	MsgRolledBack            = "msg.rolledBack"            // 已清理未完成的输出
+	MsgCacheSummary          = "msg.cacheSummary"          // 缓存统计
+	MsgUpToDate              = "msg.upToDate"              // 输入未变化
+	MsgExported              = "msg.exported"              // 已导出
+	MsgSaveCacheFailed       = "msg.saveCacheFailed"       // 保存缓存失败
+	MsgWarning               = "msg.warning"               // 警告
+	MsgNumbered              = "msg.numbered"              // 编号统计
+	MsgTOCInserted           = "msg.tocInserted"           // 已生成目录
+	MsgProfileReport         = "msg.profileReport"         // 条件内容报告
+	MsgProfileSection        = "msg.profileSection"        // 条件内容的处理结果
+	MsgProfileCheck          = "msg.profileCheck"          // has的判断结果
+	MsgRenderStart           = "msg.renderStart"           // 开始渲染模板
+	MsgVersionFromName       = "msg.versionFromName"       // 从文件名提取的版本号
+	MsgVersionPlaceholders   = "msg.versionPlaceholders"   // 跳过版本号文本替换
+	MsgVersionReplacing      = "msg.versionReplacing"      // 进行版本号替换
+	MsgRenderDone            = "msg.renderDone"            // 模板渲染完成
+	MsgVersionReplaced       = "msg.versionReplaced"       // 一处版本号已替换
+	MsgVersionSkipped        = "msg.versionSkipped"        // 一处版本号被跳过
+	MsgVersionSummary        = "msg.versionSummary"        // 版本号替换统计
+	MsgOptimizeSkipped       = "msg.optimizeSkipped"       // 无法解码图片，跳过优化
+	MsgImageScaled           = "msg.imageScaled"           // 缩放图片
+	MsgImageOptimized        = "msg.imageOptimized"        // 优化图片
+	MsgRemoteCacheHit        = "msg.remoteCacheHit"        // 命中远程图片缓存
+	MsgRemoteDownload        = "msg.remoteDownload"        // 下载远程图片
+	MsgRemoteCacheFailed     = "msg.remoteCacheFailed"     // 写入远程图片缓存失败
+	MsgResolveImage          = "msg.resolveImage"          // 解析图片路径
+	MsgImageAbsolute         = "msg.imageAbsolute"         // 图片路径是绝对路径
+	MsgImageFound            = "msg.imageFound"            // 找到图片文件
+	MsgResolveReport         = "msg.resolveReport"         // 图片解析报告
+	MsgImageDir              = "msg.imageDir"              // 图片目录
+	MsgRemoteKept            = "msg.remoteKept"            // 保留远程图片地址
+	MsgRemoteFetchFailed     = "msg.remoteFetchFailed"     // 获取远程图片失败
+	MsgImageRead             = "msg.imageRead"             // 成功读取图片
+	MsgImageUnchanged        = "msg.imageUnchanged"        // 图片未变化
+	MsgImageEmbedded         = "msg.imageEmbedded"         // 图片已内嵌
+	MsgImageNewPath          = "msg.imageNewPath"          // 新图片路径
+	MsgImageWriteFailed      = "msg.imageWriteFailed"      // 写入图片文件失败
+	MsgImageWritten          = "msg.imageWritten"          // 成功写入图片
+	MsgStaleImageRemoved     = "msg.staleImageRemoved"     // 删除不再引用的图片
+	MsgImageIssues           = "msg.imageIssues"           // 图片检查发现的问题
+	MsgResolveImageFailed    = "msg.resolveImageFailed"    // 无法解析图片路径
+	MsgImageNotExist         = "msg.imageNotExist"         // 源图片文件不存在
+	MsgImageReadFailed       = "msg.imageReadFailed"       // 读取图片文件失败
+	MsgInotifyFallback       = "msg.inotifyFallback"       // inotify不可用
+	MsgProfileKept           = "msg.profileKept"           // 条件内容保留
+	MsgProfileDropped        = "msg.profileDropped"        // 条件内容删除
+	MsgProfileTrue           = "msg.profileTrue"           // has判断成立
+	MsgProfileFalse          = "msg.profileFalse"          // has判断不成立
+	MsgUnknownFormat         = "msg.unknownFormat"         // 无法识别的图片格式
+	MsgCopyImagesStart       = "msg.copyImagesStart"       // 开始处理图片复制
+	MsgTemplatePath          = "msg.templatePath"          // 模板文件路径
+	MsgOutputPath            = "msg.outputPath"            // 输出文件路径
+	MsgImageCount            = "msg.imageCount"            // 图片路径数量
+	MsgOutputName            = "msg.outputName"            // 输出文件名
+	MsgImageProgress         = "msg.imageProgress"         // 处理第几张图片
+	MsgRewriteImagePaths     = "msg.rewriteImagePaths"     // 更新图片路径
+	MsgImagePathsRewritten   = "msg.imagePathsRewritten"   // 图片路径更新完成
+	MsgImagesCopied          = "msg.imagesCopied"          // 成功复制图片
+	MsgRuleTemplateDir       = "msg.ruleTemplateDir"       // 图片解析规则：模板目录
+	MsgRuleSearchPath        = "msg.ruleSearchPath"        // 图片解析规则：搜索路径
+	MsgRuleWorkingDir        = "msg.ruleWorkingDir"        // 图片解析规则：当前工作目录
+	MsgRuleTemplateDirByName = "msg.ruleTemplateDirByName" // 图片解析规则：模板目录下的常见目录
+	MsgRuleWorkingDirByName  = "msg.ruleWorkingDirByName"  // 图片解析规则：当前目录下的常见目录
+	MsgVersionNotStandalone  = "msg.versionNotStandalone"  // 跳过原因：不是独立的版本号
+	MsgVersionNoPrefix       = "msg.versionNoPrefix"       // 跳过原因：缺少前缀
+	MsgVersionProtected      = "msg.versionProtected"      // 跳过原因：位于保护区域
+	MsgVersionExcluded       = "msg.versionExcluded"       // 跳过原因：匹配排除规则
+	MsgImageEmpty            = "msg.imageEmpty"            // 图片问题：文件为空
+	MsgImageTooLarge         = "msg.imageTooLarge"         // 图片问题：文件过大
+	MsgImageUnknown          = "msg.imageUnknown"          // 图片问题：无法识别
+	MsgImageBroken           = "msg.imageBroken"           // 图片问题：文件已损坏
+	MsgImageExtMismatch      = "msg.imageExtMismatch"      // 图片问题：扩展名与内容不符
+	MsgImageTooWide          = "msg.imageTooWide"          // 图片问题：过宽
+	MsgImageTooTall          = "msg.imageTooTall"          // 图片问题：过高
+	MsgTemplateSize          = "msg.templateSize"          // 模板文件大小
+)
+
+// 命令行参数说明
+const (
+	MsgUsage          = "msg.usage"          // 命令行用法
+	FlagWatchInterval = "flag.watchInterval" // --watch-interval
+	FlagWatchDebounce = "flag.watchDebounce" // --watch-debounce
+	FlagWatchPolling  = "flag.watchPolling"  // --watch-polling
+	FlagAddr          = "flag.addr"          // --addr
+	FlagLang          = "flag.lang"          // --lang
+	FlagEmbedImages   = "flag.embedImages"   // --embed-images
+	FlagEmbedMaxBytes = "flag.embedMaxBytes" // --embed-max-bytes
+	FlagKeepStale     = "flag.keepStale"     // --keep-stale
+	FlagForce         = "flag.force"         // --force
+	FlagFormat        = "flag.format"        // --format
+	FlagHTMLTheme     = "flag.htmlTheme"     // --html-theme
+	FlagSelfContained = "flag.selfContained" // --self-contained
+	FlagDOCXReference = "flag.docxReference" // --docx-reference
+	FlagPDFFont       = "flag.pdfFont"       // --pdf-font
+	FlagTOCDepth      = "flag.tocDepth"      // --toc-depth
+	FlagNumbering     = "flag.numbering"     // --numbering
+	FlagTimeout       = "flag.timeout"       // --timeout
+	FlagLanguages     = "flag.languages"     // --languages
+	FlagProfile       = "flag.profile"       // --profile
 )
 
 // 错误消息
 const (
-	ErrReadTemplatePath = "读取模板文件路径失败: %v"
-	ErrReadConfigPath   = "读取配置文件路径失败: %v"
-	ErrReadVersion      = "读取版本号失败: %v"
-	ErrFileNotExist     = "%s不存在: %s\n当前工作目录: %s"
-	ErrReadConfig       = "读取配置文件失败: %v"
-	ErrProcessFailed    = "处理失败: %v"
-	ErrCollectInputs    = "收集输入失败: %v"
-	ErrValidateInputs   = "验证输入失败: %v"
-	ErrLoadConfig       = "加载配置失败: %v"
-	ErrProcessDocument  = "处理文档失败: %v"
+	ErrReadTemplatePath     = "err.readTemplatePath"     // 读取模板文件路径失败: %v
+	ErrReadConfigPath       = "err.readConfigPath"       // 读取配置文件路径失败: %v
+	ErrReadVersion          = "err.readVersion"          // 读取版本号失败: %v
+	ErrFileNotExist         = "err.fileNotExist"         // %s不存在: %s，当前工作目录: %s
+	ErrReadConfig           = "err.readConfig"           // 读取配置文件失败: %v
+	ErrProcessFailed        = "err.processFailed"        // 处理失败: %v
+	ErrCollectInputs        = "err.collectInputs"        // 收集输入失败: %v
+	ErrValidateInputs       = "err.validateInputs"       // 验证输入失败: %v
+	ErrLoadConfig           = "err.loadConfig"           // 加载配置失败: %v
+	ErrProcessDocument      = "err.processDocument"      // 处理文档失败: %v
+	ErrReadInput            = "err.readInput"            // 读取输入失败: %v
+	ErrInvalidVersionFormat = "err.invalidVersionFormat" // 版本号格式无效
+	ErrInvalidInputs        = "err.invalidInputs"        // 输入验证失败
+	ErrCurrentDir           = "err.currentDir"           // 无法获取当前目录
+	ErrUnknownCommand       = "err.unknownCommand"       // 未知命令: %s
+	ErrUnknownLocale        = "err.unknownLocale"        // 不支持的界面语言: %s
+	ErrStartServer          = "err.startServer"          // 启动预览服务失败: %v
+	ErrMigrateNoVersion     = "err.migrateNoVersion"     // 模板文件名中没有版本号
+	ErrReadTemplate         = "err.readTemplate"         // 读取模板文件失败: %v
+	ErrBackupTemplate       = "err.backupTemplate"       // 备份模板失败: %v
+	ErrWriteTemplate        = "err.writeTemplate"        // 写入模板失败: %v
 )
 
 // 文件类型
 const (
-	FileTypeTemplate = "模板文件"
-	FileTypeConfig   = "配置文件"
+	FileTypeTemplate = "fileType.template" // 模板文件
+	FileTypeConfig   = "fileType.config"   // 配置文件
 )
 
 // 版本号正则表达式
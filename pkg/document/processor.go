@@ -1,41 +1,86 @@
 package document
 
 import (
+	"context"
 	"fmt"
+	"md-manual-tool/pkg/cache"
 	"md-manual-tool/pkg/config"
 	"md-manual-tool/pkg/constants"
+	"md-manual-tool/pkg/i18n"
 	"md-manual-tool/pkg/processor"
 )
 
 // Processor 文档处理器
-type Processor struct{}
+type Processor struct {
+	cacheStats cache.Stats
+}
 
 // NewProcessor 创建新的文档处理器
 func NewProcessor() *Processor {
 	return &Processor{}
 }
 
-// ProcessDocument 处理文档
-func (p *Processor) ProcessDocument(configData *config.ConfigData) error {
-	// 创建处理器
-	proc := processor.NewProcessor(configData.Config)
+// ProcessDocument 处理文档，配置了languages时依次生成各语言版本；ctx取消时中止生成并保留原有输出
+func (p *Processor) ProcessDocument(ctx context.Context, configData *config.ConfigData) error {
+	p.cacheStats = cache.Stats{}
+	for _, job := range configData.Jobs() {
+		if job.Language != "" {
+			fmt.Print(i18n.Sprintf(constants.MsgLanguageJob, job.Language, job.OutputPath))
+		}
 
-	// 处理整个流程
-	if err := proc.Process(configData.TemplatePath, configData.OutputPath); err != nil {
-		return fmt.Errorf(constants.ErrProcessFailed, err)
+		// 创建处理器
+		proc := processor.NewProcessor(job.Config)
+
+		// 处理整个流程
+		err := proc.Process(ctx, job.TemplatePath, job.OutputPath)
+		p.cacheStats = p.cacheStats.Add(proc.CacheStats())
+		if err != nil {
+			if job.Language != "" {
+				err = fmt.Errorf("%s: %v", job.Language, err)
+			}
+			return i18n.Errorf(constants.ErrProcessFailed, err)
+		}
 	}
 
 	return nil
 }
 
+// SourceFiles 返回文档依赖的源文件：模板、配置文件、引用的本地图片，以及各语言版本的覆盖配置和消息目录
+func (p *Processor) SourceFiles(configData *config.ConfigData, configPath string) []string {
+	files := []string{configData.TemplatePath, configPath}
+	for _, job := range configData.Jobs() {
+		files = append(files, job.Sources...)
+	}
+	images, err := processor.NewProcessor(configData.Jobs()[0].Config).SourceFiles(configData.TemplatePath)
+	if err == nil {
+		files = append(files, images...)
+	}
+	return files
+}
+
+// Preview 在内存中渲染文档用于预览，本地图片地址以urlPrefix开头；配置了languages时预览第一种语言
+func (p *Processor) Preview(configData *config.ConfigData, urlPrefix string) (*processor.Preview, error) {
+	job := configData.Jobs()[0]
+	preview, err := processor.NewProcessor(job.Config).Preview(job.TemplatePath, urlPrefix)
+	if err != nil {
+		return nil, i18n.Errorf(constants.ErrProcessFailed, err)
+	}
+	return preview, nil
+}
+
+// CacheStats 返回最近一次处理的缓存命中统计
+func (p *Processor) CacheStats() cache.Stats {
+	return p.cacheStats
+}
+
 // ProcessWithConfig 使用配置处理文档
-func (p *Processor) ProcessWithConfig(cfg *config.Config, templatePath, outputPath string) error {
+func (p *Processor) ProcessWithConfig(ctx context.Context, cfg *config.Config, templatePath, outputPath string) error {
 	// 创建处理器
 	proc := processor.NewProcessor(cfg)
 
 	// 处理整个流程
-	if err := proc.Process(templatePath, outputPath); err != nil {
-		return fmt.Errorf(constants.ErrProcessFailed, err)
+	if err := proc.Process(ctx, templatePath, outputPath); err != nil {
+		return i18n.Errorf(constants.ErrProcessFailed, err)
 	}
 
 	return nil
@@ -0,0 +1,755 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/fs"
+	"md-manual-tool/pkg/constants"
+	"md-manual-tool/pkg/i18n"
+	"md-manual-tool/pkg/markdown"
+	"md-manual-tool/pkg/utils"
+	"md-manual-tool/pkg/validator"
+	"md-manual-tool/pkg/vfs"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DOCX页面尺寸（A4，页边距2.54厘米），单位为twip（1/1440英寸）
+const (
+	docxPageWidth  = 11906
+	docxPageHeight = 16838
+	docxMargin     = 1440
+
+	emuPerTwip  = 635
+	emuPerPixel = 9525 // 96 DPI
+)
+
+// docxMaxImageWidth 图片最大宽度（EMU），超过版心宽度时等比缩小
+const docxMaxImageWidth = (docxPageWidth - 2*docxMargin) * emuPerTwip
+
+// docxImageFormats Word支持嵌入的图片格式及其MIME类型
+var docxImageFormats = map[string]string{
+	"png":  "image/png",
+	"jpeg": "image/jpeg",
+	"gif":  "image/gif",
+	"bmp":  "image/bmp",
+	"tiff": "image/tiff",
+	"webp": "image/webp",
+}
+
+// htmlImgRe 内嵌HTML中的图片
+var htmlImgRe = regexp.MustCompile(`(?i)<img\s[^>]*?src\s*=\s*["']([^"']+)["'][^>]*>`)
+
+// htmlAltRe 图片的alt属性
+var htmlAltRe = regexp.MustCompile(`(?i)\salt\s*=\s*["']([^"']*)["']`)
+
+// htmlTagRe HTML标签
+var htmlTagRe = regexp.MustCompile(`<[^>]+>`)
+
+// DOCXOptions DOCX导出选项
+type DOCXOptions struct {
+	Title        string             // 文档标题（写入文档属性），为空时使用第一个标题
+	Reference    string             // 参考文档路径，使用其中的样式
+	ReferenceDir string             // 参考文档相对路径的基准目录
+	SourceFS     fs.FS              // 读取参考文档的文件系统（模板所在），为nil时使用本地文件系统
+	SlugStyle    markdown.SlugStyle // 标题书签名风格，与目录链接的锚点一致
+	FS           fs.FS              // 读取输出目录中图片的文件系统，为nil时使用本地文件系统
+}
+
+// DOCXPath 返回Markdown输出文件对应的DOCX文件路径
+func DOCXPath(outputPath string) string {
+	return strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + ".docx"
+}
+
+// DOCX 将渲染后的Markdown导出为Word文档
+//
+// 标题使用Word内置的标题样式，图片从输出目录读取后嵌入文档；指定参考文档时沿用其样式定义，
+// 参考文档中缺少的样式使用内置定义补齐
+func DOCX(outputPath string, content []byte, opts *DOCXOptions) ([]File, error) {
+	styles := docxStyles
+	if opts.Reference != "" {
+		reference, err := loadReferenceStyles(readFS(opts.SourceFS), opts.Reference, opts.ReferenceDir)
+		if err != nil {
+			return nil, err
+		}
+		styles = mergeStyles(reference, docxStyles)
+	}
+
+	doc := markdown.Parse(string(content))
+	doc.AssignIDs(opts.SlugStyle)
+	title := opts.Title
+	if title == "" {
+		title = documentTitle(doc, outputPath)
+	}
+
+	w := &docxWriter{fs: readFS(opts.FS), baseDir: filepath.Dir(outputPath)}
+	w.writeBlocks(doc.Blocks, 0)
+
+	data, err := w.pack(title, styles)
+	if err != nil {
+		return nil, fmt.Errorf("生成DOCX失败: %v", err)
+	}
+	return []File{{Path: DOCXPath(outputPath), Data: data}}, nil
+}
+
+// docxMedia 嵌入的图片
+type docxMedia struct {
+	name string
+	data []byte
+}
+
+// docxRel 文档关系
+type docxRel struct {
+	id       string
+	relType  string
+	target   string
+	external bool
+}
+
+// docxWriter DOCX正文生成器
+type docxWriter struct {
+	fs      fs.FS
+	baseDir string
+	body    strings.Builder
+	media   []docxMedia
+	rels    []docxRel
+	starts  []int // 每个有序列表实例的起始序号，对应numId从2开始
+	shapeID int
+	marks   int // 已写入的书签数量
+}
+
+// 段落样式
+type paragraphStyle struct {
+	style  string // 样式ID
+	numID  int    // 列表编号，0表示无
+	level  int    // 列表层级
+	align  string // 对齐方式
+	indent int    // 左缩进（twip）
+}
+
+// writeBlocks 写入块级元素，depth为列表嵌套层级
+func (w *docxWriter) writeBlocks(blocks []markdown.Block, depth int) {
+	for _, block := range blocks {
+		switch b := block.(type) {
+		case *markdown.Heading:
+			w.heading(b)
+
+		case *markdown.Paragraph:
+			w.paragraph(paragraphStyle{indent: depth * 420}, b.Content)
+
+		case *markdown.CodeBlock:
+			w.codeBlock(b.Code, depth)
+
+		case *markdown.List:
+			w.list(b, depth)
+
+		case *markdown.Quote:
+			for _, inner := range b.Blocks {
+				if p, ok := inner.(*markdown.Paragraph); ok {
+					w.paragraph(paragraphStyle{style: "Quote"}, p.Content)
+				} else {
+					w.writeBlocks([]markdown.Block{inner}, depth)
+				}
+			}
+
+		case *markdown.Rule:
+			w.body.WriteString(`<w:p><w:pPr><w:pBdr><w:bottom w:val="single" w:sz="6" w:space="1" w:color="auto"/></w:pBdr></w:pPr></w:p>`)
+
+		case *markdown.HTMLBlock:
+			w.htmlBlock(b.Raw)
+
+		case *markdown.Table:
+			w.table(b)
+		}
+	}
+}
+
+// heading 写入标题段落，有锚点时在标题上添加书签，使目录中的#锚点链接可以跳转
+func (w *docxWriter) heading(h *markdown.Heading) {
+	w.body.WriteString("<w:p>")
+	w.paragraphProperties(paragraphStyle{style: fmt.Sprintf("Heading%d", h.Level)})
+	if h.ID != "" {
+		w.marks++
+		fmt.Fprintf(&w.body, `<w:bookmarkStart w:id="%d" w:name="%s"/><w:bookmarkEnd w:id="%d"/>`,
+			w.marks, xmlEscape(bookmarkName(h.ID)), w.marks)
+	}
+	w.inlines(h.Content, runProps{})
+	w.body.WriteString("</w:p>")
+}
+
+// paragraph 写入一个段落
+func (w *docxWriter) paragraph(style paragraphStyle, content []markdown.Inline) {
+	w.body.WriteString("<w:p>")
+	w.paragraphProperties(style)
+	w.inlines(content, runProps{})
+	w.body.WriteString("</w:p>")
+}
+
+// paragraphProperties 写入段落属性
+func (w *docxWriter) paragraphProperties(style paragraphStyle) {
+	var props strings.Builder
+	if style.style != "" {
+		fmt.Fprintf(&props, `<w:pStyle w:val="%s"/>`, style.style)
+	}
+	if style.numID > 0 {
+		fmt.Fprintf(&props, `<w:numPr><w:ilvl w:val="%d"/><w:numId w:val="%d"/></w:numPr>`, style.level, style.numID)
+	}
+	if style.indent > 0 {
+		fmt.Fprintf(&props, `<w:ind w:left="%d"/>`, style.indent)
+	}
+	if style.align != "" {
+		fmt.Fprintf(&props, `<w:jc w:val="%s"/>`, style.align)
+	}
+	if props.Len() > 0 {
+		w.body.WriteString("<w:pPr>" + props.String() + "</w:pPr>")
+	}
+}
+
+// list 写入列表，有序列表每次重新从起始序号编号
+func (w *docxWriter) list(list *markdown.List, depth int) {
+	numID := 1 // 无序列表共用编号定义
+	if list.Ordered {
+		w.starts = append(w.starts, list.Start)
+		numID = len(w.starts) + 1
+	}
+	level := depth
+	if level > 8 {
+		level = 8
+	}
+
+	for _, item := range list.Items {
+		first := true
+		for _, block := range item.Blocks {
+			p, ok := block.(*markdown.Paragraph)
+			switch {
+			case ok && first:
+				w.paragraph(paragraphStyle{style: "ListParagraph", numID: numID, level: level}, p.Content)
+			case ok:
+				w.paragraph(paragraphStyle{style: "ListParagraph", indent: (level + 1) * 420}, p.Content)
+			default:
+				w.writeBlocks([]markdown.Block{block}, depth+1)
+			}
+			first = false
+		}
+	}
+}
+
+// codeBlock 写入代码块，每行一个段落以保留换行和缩进
+func (w *docxWriter) codeBlock(code string, depth int) {
+	lines := strings.Split(strings.TrimSuffix(code, "\n"), "\n")
+	for _, line := range lines {
+		w.body.WriteString("<w:p>")
+		w.paragraphProperties(paragraphStyle{style: "SourceCode", indent: depth * 420})
+		if line != "" {
+			w.run(expandTabs(line), runProps{})
+		}
+		w.body.WriteString("</w:p>")
+	}
+}
+
+// htmlBlock 写入内嵌HTML：提取其中的图片和文本
+func (w *docxWriter) htmlBlock(raw string) {
+	var content []markdown.Inline
+	for _, m := range htmlImgRe.FindAllStringSubmatch(raw, -1) {
+		alt := ""
+		if altMatch := htmlAltRe.FindStringSubmatch(m[0]); altMatch != nil {
+			alt = altMatch[1]
+		}
+		content = append(content, &markdown.Image{Src: m[1], Alt: alt})
+	}
+	if text := strings.TrimSpace(htmlTagRe.ReplaceAllString(raw, "")); text != "" {
+		content = append(content, &markdown.Text{Value: text})
+	}
+	if len(content) > 0 {
+		w.paragraph(paragraphStyle{align: "center"}, content)
+	}
+}
+
+// table 写入表格，表头行在跨页时重复
+func (w *docxWriter) table(table *markdown.Table) {
+	columns := len(table.Align)
+	width := (docxPageWidth - 2*docxMargin) / columns
+
+	w.body.WriteString(`<w:tbl><w:tblPr><w:tblStyle w:val="TableGrid"/><w:tblW w:w="5000" w:type="pct"/>`)
+	w.body.WriteString(`<w:tblBorders><w:top w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:left w:val="single" w:sz="4" w:space="0" w:color="auto"/>` +
+		`<w:bottom w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:right w:val="single" w:sz="4" w:space="0" w:color="auto"/>` +
+		`<w:insideH w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:insideV w:val="single" w:sz="4" w:space="0" w:color="auto"/></w:tblBorders>`)
+	w.body.WriteString(`</w:tblPr><w:tblGrid>`)
+	for j := 0; j < columns; j++ {
+		fmt.Fprintf(&w.body, `<w:gridCol w:w="%d"/>`, width)
+	}
+	w.body.WriteString(`</w:tblGrid>`)
+
+	writeRow := func(cells [][]markdown.Inline, header bool) {
+		w.body.WriteString("<w:tr>")
+		if header {
+			w.body.WriteString(`<w:trPr><w:tblHeader/></w:trPr>`)
+		}
+		for j, cell := range cells {
+			fmt.Fprintf(&w.body, `<w:tc><w:tcPr><w:tcW w:w="%d" w:type="dxa"/>`, width)
+			if header {
+				w.body.WriteString(`<w:shd w:val="clear" w:color="auto" w:fill="EEF2F8"/>`)
+			}
+			w.body.WriteString("</w:tcPr><w:p>")
+			w.paragraphProperties(paragraphStyle{style: "TableText", align: docxAlign(table.Align[j])})
+			w.inlines(cell, runProps{bold: header})
+			w.body.WriteString("</w:p></w:tc>")
+		}
+		w.body.WriteString("</w:tr>")
+	}
+
+	writeRow(table.Header, true)
+	for _, row := range table.Rows {
+		writeRow(row, false)
+	}
+	w.body.WriteString("</w:tbl>")
+	// 表格后加空段落，避免相邻表格合并
+	w.body.WriteString("<w:p/>")
+}
+
+// docxAlign 返回表格列对齐方式对应的段落对齐值
+func docxAlign(align markdown.Alignment) string {
+	switch align {
+	case markdown.AlignCenter:
+		return "center"
+	case markdown.AlignRight:
+		return "right"
+	}
+	return ""
+}
+
+// runProps 字符格式
+type runProps struct {
+	style  string // 字符样式ID
+	bold   bool
+	italic bool
+	strike bool
+}
+
+// xml 按OOXML要求的元素顺序生成<w:rPr>内容
+func (p runProps) xml() string {
+	var b strings.Builder
+	if p.style != "" {
+		fmt.Fprintf(&b, `<w:rStyle w:val="%s"/>`, p.style)
+	}
+	if p.bold {
+		b.WriteString("<w:b/>")
+	}
+	if p.italic {
+		b.WriteString("<w:i/>")
+	}
+	if p.strike {
+		b.WriteString("<w:strike/>")
+	}
+	return b.String()
+}
+
+// inlines 写入行内元素，props为外层的字符格式
+func (w *docxWriter) inlines(nodes []markdown.Inline, props runProps) {
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case *markdown.Text:
+			w.text(n.Value, props)
+		case *markdown.Code:
+			code := props
+			code.style = "VerbatimChar"
+			w.run(n.Value, code)
+		case *markdown.Strong:
+			strong := props
+			strong.bold = true
+			w.inlines(n.Children, strong)
+		case *markdown.Emphasis:
+			emphasis := props
+			emphasis.italic = true
+			w.inlines(n.Children, emphasis)
+		case *markdown.Strike:
+			strike := props
+			strike.strike = true
+			w.inlines(n.Children, strike)
+		case *markdown.Link:
+			w.link(n, props)
+		case *markdown.Image:
+			w.image(n)
+		case *markdown.LineBreak:
+			w.body.WriteString("<w:r><w:br/></w:r>")
+		case *markdown.RawHTML:
+			if strings.HasPrefix(strings.ToLower(n.Value), "<br") {
+				w.body.WriteString("<w:r><w:br/></w:r>")
+			} else if m := htmlImgRe.FindStringSubmatch(n.Value); m != nil {
+				w.image(&markdown.Image{Src: m[1]})
+			}
+		}
+	}
+}
+
+// text 写入文本
+func (w *docxWriter) text(value string, props runProps) {
+	w.run(joinLines(value), props)
+}
+
+// expandTabs 将代码行中的制表符按4列对齐展开为空格（DOCX和PDF的代码块中没有制表位）
+func expandTabs(line string) string {
+	if !strings.Contains(line, "\t") {
+		return line
+	}
+	var b strings.Builder
+	column := 0
+	for _, r := range line {
+		if r == '\t' {
+			n := 4 - column%4
+			b.WriteString(strings.Repeat(" ", n))
+			column += n
+			continue
+		}
+		b.WriteRune(r)
+		column++
+	}
+	return b.String()
+}
+
+// joinLines 合并段落内的软换行：中文之间直接相连，其他情况替换为空格
+func joinLines(value string) string {
+	lines := strings.Split(value, "\n")
+	var b strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			prev, _ := utf8.DecodeLastRuneInString(lines[i-1])
+			next, _ := utf8.DecodeRuneInString(line)
+			if !unicode.Is(unicode.Han, prev) || !unicode.Is(unicode.Han, next) {
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+// run 写入一段文本
+func (w *docxWriter) run(text string, props runProps) {
+	w.body.WriteString("<w:r>")
+	if rPr := props.xml(); rPr != "" {
+		w.body.WriteString("<w:rPr>" + rPr + "</w:rPr>")
+	}
+	fmt.Fprintf(&w.body, `<w:t xml:space="preserve">%s</w:t></w:r>`, xmlEscape(text))
+}
+
+// link 写入超链接，文档内锚点使用书签，其他地址作为外部链接
+func (w *docxWriter) link(link *markdown.Link, props runProps) {
+	props.style = "Hyperlink"
+	if strings.HasPrefix(link.Href, "#") {
+		fmt.Fprintf(&w.body, `<w:hyperlink w:anchor="%s">`, xmlEscape(bookmarkName(link.Href[1:])))
+	} else {
+		id := w.addRel("http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink", link.Href, true)
+		fmt.Fprintf(&w.body, `<w:hyperlink r:id="%s">`, id)
+	}
+	w.inlines(link.Children, props)
+	w.body.WriteString("</w:hyperlink>")
+}
+
+// image 嵌入图片，无法嵌入时以文字说明代替
+func (w *docxWriter) image(img *markdown.Image) {
+	data, err := loadImage(w.fs, w.baseDir, img.Src)
+	if err != nil {
+		fmt.Print(i18n.Sprintf(constants.MsgEmbedImageFailed, img.Src, err))
+		w.run(fmt.Sprintf("[图片: %s]", firstNonEmpty(img.Alt, img.Src)), runProps{italic: true})
+		return
+	}
+	info := validator.InspectImage(data)
+	mimeType, ok := docxImageFormats[info.Format]
+	if !ok {
+		fmt.Print(i18n.Sprintf(constants.MsgDOCXUnsupportedImage, firstNonEmpty(info.Format, i18n.Msg(constants.MsgUnknownFormat)), img.Src))
+		w.run(fmt.Sprintf("[图片: %s]", firstNonEmpty(img.Alt, img.Src)), runProps{italic: true})
+		return
+	}
+
+	ext := strings.TrimPrefix(mimeType, "image/")
+	name := fmt.Sprintf("image%d.%s", len(w.media)+1, ext)
+	w.media = append(w.media, docxMedia{name: name, data: data})
+	id := w.addRel("http://schemas.openxmlformats.org/officeDocument/2006/relationships/image", "media/"+name, false)
+
+	// 无法获取尺寸时按版心宽度的一半显示
+	cx, cy := int64(info.Width)*emuPerPixel, int64(info.Height)*emuPerPixel
+	if cx <= 0 || cy <= 0 {
+		cx, cy = docxMaxImageWidth/2, docxMaxImageWidth/2
+	}
+	if cx > docxMaxImageWidth {
+		cy = cy * docxMaxImageWidth / cx
+		cx = docxMaxImageWidth
+	}
+
+	w.shapeID++
+	fmt.Fprintf(&w.body, `<w:r><w:drawing><wp:inline distT="0" distB="0" distL="0" distR="0"><wp:extent cx="%d" cy="%d"/>`+
+		`<wp:docPr id="%d" name="图片 %d" descr="%s"/><wp:cNvGraphicFramePr><a:graphicFrameLocks noChangeAspect="1"/></wp:cNvGraphicFramePr>`+
+		`<a:graphic><a:graphicData uri="http://schemas.openxmlformats.org/drawingml/2006/picture"><pic:pic>`+
+		`<pic:nvPicPr><pic:cNvPr id="%d" name="%s"/><pic:cNvPicPr/></pic:nvPicPr>`+
+		`<pic:blipFill><a:blip r:embed="%s"/><a:stretch><a:fillRect/></a:stretch></pic:blipFill>`+
+		`<pic:spPr><a:xfrm><a:off x="0" y="0"/><a:ext cx="%d" cy="%d"/></a:xfrm><a:prstGeom prst="rect"><a:avLst/></a:prstGeom></pic:spPr>`+
+		`</pic:pic></a:graphicData></a:graphic></wp:inline></w:drawing></w:r>`,
+		cx, cy, w.shapeID, w.shapeID, xmlEscape(img.Alt), w.shapeID, name, id, cx, cy)
+}
+
+// loadImage 读取图片：data URI直接解码，本地路径相对于baseDir（输出目录）
+func loadImage(fsys fs.FS, baseDir, src string) ([]byte, error) {
+	if strings.HasPrefix(src, "data:") {
+		comma := strings.Index(src, ",")
+		if comma < 0 || !strings.HasSuffix(src[:comma], ";base64") {
+			return nil, fmt.Errorf("不支持的data URI")
+		}
+		return base64.StdEncoding.DecodeString(src[comma+1:])
+	}
+	if utils.IsRemoteImage(src) {
+		return nil, fmt.Errorf("远程图片不会嵌入文档")
+	}
+	path := src
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(baseDir, filepath.FromSlash(src))
+	}
+	return vfs.ReadFile(fsys, path)
+}
+
+// ReferenceFile 解析参考文档路径：相对路径先相对于baseDir查找，找不到时相对于fsys的根目录
+func ReferenceFile(fsys fs.FS, reference, baseDir string) string {
+	if !filepath.IsAbs(reference) && baseDir != "" {
+		if _, err := vfs.Stat(readFS(fsys), filepath.Join(baseDir, reference)); err == nil {
+			return filepath.Join(baseDir, reference)
+		}
+	}
+	return reference
+}
+
+// readFS 返回读取图片的文件系统，未指定时使用本地文件系统
+func readFS(fsys fs.FS) fs.FS {
+	if fsys == nil {
+		return vfs.OSFS{}
+	}
+	return fsys
+}
+
+// addRel 添加文档关系，返回关系ID
+func (w *docxWriter) addRel(relType, target string, external bool) string {
+	id := fmt.Sprintf("rId%d", len(w.rels)+100)
+	w.rels = append(w.rels, docxRel{id: id, relType: relType, target: target, external: external})
+	return id
+}
+
+// pack 打包为DOCX文件
+func (w *docxWriter) pack(title, styles string) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	write := func(name string, data []byte) error {
+		// 不写入修改时间，相同内容生成相同的文件
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate})
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(data)
+		return err
+	}
+
+	parts := []struct {
+		name string
+		data string
+	}{
+		{"[Content_Types].xml", w.contentTypes()},
+		{"_rels/.rels", docxPackageRels},
+		{"docProps/core.xml", fmt.Sprintf(docxCoreProps, xmlEscape(title))},
+		{"word/document.xml", w.document()},
+		{"word/_rels/document.xml.rels", w.documentRels()},
+		{"word/styles.xml", styles},
+		{"word/numbering.xml", w.numbering()},
+		{"word/settings.xml", docxSettings},
+	}
+	for _, part := range parts {
+		if err := write(part.name, []byte(part.data)); err != nil {
+			return nil, err
+		}
+	}
+	for _, media := range w.media {
+		if err := write("word/media/"+media.name, media.data); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// contentTypes 生成[Content_Types].xml
+func (w *docxWriter) contentTypes() string {
+	var b strings.Builder
+	b.WriteString(xmlHeader + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	b.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	b.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	seen := make(map[string]bool)
+	for _, media := range w.media {
+		ext := strings.TrimPrefix(filepath.Ext(media.name), ".")
+		if !seen[ext] {
+			seen[ext] = true
+			fmt.Fprintf(&b, `<Default Extension="%s" ContentType="image/%s"/>`, ext, ext)
+		}
+	}
+	b.WriteString(`<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>`)
+	b.WriteString(`<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>`)
+	b.WriteString(`<Override PartName="/word/numbering.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.numbering+xml"/>`)
+	b.WriteString(`<Override PartName="/word/settings.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.settings+xml"/>`)
+	b.WriteString(`<Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>`)
+	b.WriteString(`</Types>`)
+	return b.String()
+}
+
+// document 生成word/document.xml
+func (w *docxWriter) document() string {
+	return xmlHeader + `<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" ` +
+		`xmlns:wp="http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing" ` +
+		`xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" ` +
+		`xmlns:pic="http://schemas.openxmlformats.org/drawingml/2006/picture"><w:body>` +
+		w.body.String() +
+		fmt.Sprintf(`<w:sectPr><w:pgSz w:w="%d" w:h="%d"/><w:pgMar w:top="%d" w:right="%d" w:bottom="%d" w:left="%d" w:header="851" w:footer="992" w:gutter="0"/></w:sectPr>`,
+			docxPageWidth, docxPageHeight, docxMargin, docxMargin, docxMargin, docxMargin) +
+		`</w:body></w:document>`
+}
+
+// documentRels 生成word/_rels/document.xml.rels
+func (w *docxWriter) documentRels() string {
+	var b strings.Builder
+	b.WriteString(xmlHeader + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	b.WriteString(`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`)
+	b.WriteString(`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/numbering" Target="numbering.xml"/>`)
+	b.WriteString(`<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/settings" Target="settings.xml"/>`)
+	for _, rel := range w.rels {
+		mode := ""
+		if rel.external {
+			mode = ` TargetMode="External"`
+		}
+		fmt.Fprintf(&b, `<Relationship Id="%s" Type="%s" Target="%s"%s/>`, rel.id, rel.relType, xmlEscape(rel.target), mode)
+	}
+	b.WriteString(`</Relationships>`)
+	return b.String()
+}
+
+// numbering 生成word/numbering.xml：numId 1为无序列表，其后每个有序列表一个实例
+func (w *docxWriter) numbering() string {
+	var b strings.Builder
+	b.WriteString(xmlHeader + `<w:numbering xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">`)
+
+	bullets := []string{"●", "○", "■"}
+	b.WriteString(`<w:abstractNum w:abstractNumId="0"><w:multiLevelType w:val="hybridMultilevel"/>`)
+	for level := 0; level < 9; level++ {
+		fmt.Fprintf(&b, `<w:lvl w:ilvl="%d"><w:start w:val="1"/><w:numFmt w:val="bullet"/><w:lvlText w:val="%s"/><w:lvlJc w:val="left"/>`+
+			`<w:pPr><w:ind w:left="%d" w:hanging="420"/></w:pPr></w:lvl>`, level, bullets[level%len(bullets)], (level+1)*420)
+	}
+	b.WriteString(`</w:abstractNum>`)
+
+	formats := []string{"decimal", "lowerLetter", "lowerRoman"}
+	b.WriteString(`<w:abstractNum w:abstractNumId="1"><w:multiLevelType w:val="hybridMultilevel"/>`)
+	for level := 0; level < 9; level++ {
+		fmt.Fprintf(&b, `<w:lvl w:ilvl="%d"><w:start w:val="1"/><w:numFmt w:val="%s"/><w:lvlText w:val="%%%d."/><w:lvlJc w:val="left"/>`+
+			`<w:pPr><w:ind w:left="%d" w:hanging="420"/></w:pPr></w:lvl>`, level, formats[level%len(formats)], level+1, (level+1)*420)
+	}
+	b.WriteString(`</w:abstractNum>`)
+
+	b.WriteString(`<w:num w:numId="1"><w:abstractNumId w:val="0"/></w:num>`)
+	for i, start := range w.starts {
+		fmt.Fprintf(&b, `<w:num w:numId="%d"><w:abstractNumId w:val="1"/><w:lvlOverride w:ilvl="0"><w:startOverride w:val="%d"/></w:lvlOverride></w:num>`, i+2, start)
+	}
+	b.WriteString(`</w:numbering>`)
+	return b.String()
+}
+
+// loadReferenceStyles 从fsys读取参考文档中的样式定义，相对路径先相对于baseDir查找
+func loadReferenceStyles(fsys fs.FS, reference, baseDir string) (string, error) {
+	data, err := vfs.ReadFile(fsys, ReferenceFile(fsys, reference, baseDir))
+	if err != nil {
+		return "", fmt.Errorf("打开参考文档失败: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("打开参考文档失败: %v", err)
+	}
+
+	for _, f := range zr.File {
+		if f.Name != "word/styles.xml" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return "", fmt.Errorf("读取参考文档样式失败: %v", err)
+		}
+		defer rc.Close()
+		data, err := io.ReadAll(rc)
+		if err != nil {
+			return "", fmt.Errorf("读取参考文档样式失败: %v", err)
+		}
+		return string(data), nil
+	}
+	return "", fmt.Errorf("参考文档中没有样式定义: %s", reference)
+}
+
+// styleRe 样式定义
+var styleRe = regexp.MustCompile(`(?s)<w:style\b[^>]*w:styleId="([^"]+)".*?</w:style>`)
+
+// mergeStyles 在参考样式中补充其缺少的内置样式
+func mergeStyles(reference, builtin string) string {
+	end := strings.LastIndex(reference, "</w:styles>")
+	if end < 0 {
+		return reference
+	}
+	var missing strings.Builder
+	for _, m := range styleRe.FindAllStringSubmatch(builtin, -1) {
+		if !strings.Contains(reference, fmt.Sprintf(`w:styleId="%s"`, m[1])) {
+			missing.WriteString(m[0])
+		}
+	}
+	return reference[:end] + missing.String() + reference[end:]
+}
+
+// bookmarkName 生成书签名：Word书签名不能以数字开头且不超过40个字符
+func bookmarkName(anchor string) string {
+	name := "_" + anchor
+	if utf8.RuneCountInString(name) > 40 {
+		name = string([]rune(name)[:40])
+	}
+	return name
+}
+
+// xmlEscape 转义XML特殊字符并去掉XML不允许的控制字符
+func xmlEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '&':
+			b.WriteString("&amp;")
+		case '<':
+			b.WriteString("&lt;")
+		case '>':
+			b.WriteString("&gt;")
+		case '"':
+			b.WriteString("&quot;")
+		case '\t', '\n', '\r':
+			b.WriteRune(r)
+		default:
+			if r >= 0x20 {
+				b.WriteRune(r)
+			}
+		}
+	}
+	return b.String()
+}
+
+// firstNonEmpty 返回第一个非空字符串
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,135 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"md-manual-tool/pkg/vfs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// readZip 读取zip中所有文件，并检查XML文件格式正确
+func readZip(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("无法读取DOCX: %v", err)
+	}
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(content)
+
+		if strings.HasSuffix(f.Name, ".xml") || strings.HasSuffix(f.Name, ".rels") {
+			decoder := xml.NewDecoder(bytes.NewReader(content))
+			for {
+				if _, err := decoder.Token(); err == io.EOF {
+					break
+				} else if err != nil {
+					t.Fatalf("%s 不是合法的XML: %v", f.Name, err)
+				}
+			}
+		}
+	}
+	return files
+}
+
+func TestDOCX(t *testing.T) {
+	tempDir := t.TempDir()
+	outputPath := filepath.Join(tempDir, "manual_1.0.1.md")
+	os.MkdirAll(filepath.Join(tempDir, "manual_1.0.1.assets"), 0755)
+	// 1x1 PNG
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00\x1f\x15\xc4\x89\x00\x00\x00\rIDATx\x9cc\xf8\xff\xff?\x00\x05\xfe\x02\xfe\xa7\x35\x81\x84\x00\x00\x00\x00IEND\xaeB`\x82")
+	os.WriteFile(filepath.Join(tempDir, "manual_1.0.1.assets", "a.png"), png, 0644)
+
+	content := []byte("# 部署手册\n\n## 安装 & 配置\n\n1. 下载\n2. 解压 `tar -xf`\n   - 子项\n\n3. 启动\n\n" +
+		"| 参数 | 说明 |\n|---|:---:|\n| a | **必填** |\n\n```\nline 1\n  line 2\na\tb\n```\n\n![架构](./manual_1.0.1.assets/a.png)\n\n" +
+		"[官网](https://example.com) ![缺失](./missing.png) [返回](#安装--配置)\n")
+	files, err := DOCX(outputPath, content, &DOCXOptions{})
+	if err != nil {
+		t.Fatalf("导出失败: %v", err)
+	}
+	if len(files) != 1 || files[0].Path != filepath.Join(tempDir, "manual_1.0.1.docx") {
+		t.Fatalf("导出文件错误: %v", files)
+	}
+
+	parts := readZip(t, files[0].Data)
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "word/document.xml", "word/styles.xml", "word/numbering.xml", "word/media/image1.png"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("缺少 %s", name)
+		}
+	}
+	document := parts["word/document.xml"]
+	for _, expected := range []string{
+		`<w:pStyle w:val="Heading1"/>`,
+		`安装 &amp; 配置`,
+		`<w:numPr><w:ilvl w:val="0"/><w:numId w:val="2"/></w:numPr>`,
+		`<w:numPr><w:ilvl w:val="1"/><w:numId w:val="1"/></w:numPr>`,
+		`<w:tblHeader/>`,
+		`<w:jc w:val="center"/>`,
+		`<w:t xml:space="preserve">  line 2</w:t>`,
+		`<w:t xml:space="preserve">a   b</w:t>`,
+		`<wp:extent cx="9525" cy="9525"/>`,
+		`[图片: 缺失]`,
+		`<w:bookmarkStart w:id="2" w:name="_安装--配置"/>`,
+		`<w:hyperlink w:anchor="_安装--配置">`,
+	} {
+		if !strings.Contains(document, expected) {
+			t.Errorf("document.xml缺少 %q", expected)
+		}
+	}
+	if !strings.Contains(parts["word/_rels/document.xml.rels"], `Target="https://example.com" TargetMode="External"`) {
+		t.Error("缺少外部链接关系")
+	}
+
+	// 相同输入生成相同文件，便于增量缓存
+	again, _ := DOCX(outputPath, content, &DOCXOptions{})
+	if !bytes.Equal(files[0].Data, again[0].Data) {
+		t.Error("相同输入应生成相同的DOCX")
+	}
+}
+
+func TestDOCXReferenceStyles(t *testing.T) {
+	tempDir := t.TempDir()
+	referencePath := filepath.Join(tempDir, "reference.docx")
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	f, _ := zw.Create("word/styles.xml")
+	f.Write([]byte(`<?xml version="1.0"?><w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">` +
+		`<w:style w:type="paragraph" w:styleId="Heading1"><w:name w:val="heading 1"/><w:rPr><w:color w:val="FF0000"/></w:rPr></w:style></w:styles>`))
+	zw.Close()
+	os.WriteFile(referencePath, buf.Bytes(), 0644)
+
+	files, err := DOCX(filepath.Join(tempDir, "manual.md"), []byte("# 标题\n"), &DOCXOptions{Reference: "reference.docx", ReferenceDir: tempDir})
+	if err != nil {
+		t.Fatalf("导出失败: %v", err)
+	}
+	styles := readZip(t, files[0].Data)["word/styles.xml"]
+	if !strings.Contains(styles, `<w:color w:val="FF0000"/>`) || strings.Count(styles, `w:styleId="Heading1"`) != 1 {
+		t.Errorf("应沿用参考文档的标题样式: %s", styles)
+	}
+	if !strings.Contains(styles, `w:styleId="SourceCode"`) {
+		t.Error("参考文档缺少的样式应使用内置定义补齐")
+	}
+
+	// 参考文档从模板所在的文件系统读取
+	source := vfs.NewMemFS(map[string]string{"docs/reference.docx": buf.String()})
+	files, err = DOCX("out/manual.md", []byte("# 标题\n"), &DOCXOptions{Reference: "reference.docx", ReferenceDir: "docs", SourceFS: source})
+	if err != nil {
+		t.Fatalf("从模板文件系统读取参考文档失败: %v", err)
+	}
+	if styles := readZip(t, files[0].Data)["word/styles.xml"]; !strings.Contains(styles, `<w:color w:val="FF0000"/>`) {
+		t.Error("应沿用模板文件系统中参考文档的样式")
+	}
+
+	if _, err := DOCX(filepath.Join(tempDir, "manual.md"), []byte("# 标题\n"), &DOCXOptions{Reference: "missing.docx"}); err == nil {
+		t.Error("参考文档不存在时应返回错误")
+	}
+}
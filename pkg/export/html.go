@@ -0,0 +1,128 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io/fs"
+	"md-manual-tool/pkg/constants"
+	"md-manual-tool/pkg/i18n"
+	"md-manual-tool/pkg/markdown"
+	"md-manual-tool/pkg/utils"
+	"md-manual-tool/pkg/vfs"
+	"path/filepath"
+	"strings"
+)
+
+// File 导出生成的文件
+type File struct {
+	Path string
+	Data []byte
+}
+
+// DefaultLang 未指定语言时HTML页面的lang属性
+const DefaultLang = "zh-CN"
+
+// HTMLOptions HTML导出选项
+type HTMLOptions struct {
+	Title         string             // 页面标题，为空时使用第一个标题
+	Lang          string             // 页面语言（lang属性），为空时为DefaultLang
+	Theme         string             // 内置主题名或CSS文件路径
+	ThemeDir      string             // 主题文件相对路径的基准目录
+	SourceFS      fs.FS              // 读取主题文件的文件系统（模板所在），为nil时使用本地文件系统
+	SelfContained bool               // 内嵌CSS和图片，生成单个HTML文件
+	SlugStyle     markdown.SlugStyle // 标题锚点风格，为空时使用github风格
+	FS            fs.FS              // 读取输出目录中图片的文件系统，为nil时使用本地文件系统
+}
+
+// HTMLPath 返回Markdown输出文件对应的HTML文件路径
+func HTMLPath(outputPath string) string {
+	return strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + ".html"
+}
+
+// HTML 将渲染后的Markdown导出为HTML
+//
+// 图片引用沿用Markdown中已改写的路径（相对于输出目录）；自包含模式下CSS写入<style>，
+// 本地图片读取后以data URI内嵌，否则主题样式写入同名的.css文件
+func HTML(outputPath string, content []byte, opts *HTMLOptions) ([]File, error) {
+	css, err := LoadTheme(opts.SourceFS, opts.Theme, opts.ThemeDir)
+	if err != nil {
+		return nil, err
+	}
+
+	source := string(content)
+	if opts.SelfContained {
+		source = inlineImages(opts.FS, source, filepath.Dir(outputPath))
+	}
+	doc := markdown.Parse(source)
+	doc.AssignIDs(opts.SlugStyle)
+
+	title := opts.Title
+	if title == "" {
+		title = documentTitle(doc, outputPath)
+	}
+
+	htmlPath := HTMLPath(outputPath)
+	if opts.SelfContained {
+		page := Page(opts.Lang, title, "<style>\n"+css+"</style>", doc.HTML(), "")
+		return []File{{Path: htmlPath, Data: page}}, nil
+	}
+
+	cssPath := strings.TrimSuffix(htmlPath, ".html") + ".css"
+	link := fmt.Sprintf("<link rel=\"stylesheet\" href=\"./%s\">", html.EscapeString(filepath.Base(cssPath)))
+	page := Page(opts.Lang, title, link, doc.HTML(), "")
+	return []File{{Path: htmlPath, Data: page}, {Path: cssPath, Data: []byte(css)}}, nil
+}
+
+// Page 生成完整的HTML页面，lang为页面语言（为空时为DefaultLang），head插入<head>末尾，script为页面脚本
+func Page(lang, title, head, body, script string) []byte {
+	if lang == "" {
+		lang = DefaultLang
+	}
+	var page bytes.Buffer
+	fmt.Fprintf(&page, "<!DOCTYPE html>\n<html lang=\"%s\">\n<head>\n<meta charset=\"utf-8\">\n", html.EscapeString(lang))
+	page.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n")
+	fmt.Fprintf(&page, "<title>%s</title>\n", html.EscapeString(title))
+	if head != "" {
+		page.WriteString(head + "\n")
+	}
+	page.WriteString("</head>\n<body>\n<main>\n")
+	page.WriteString(body)
+	page.WriteString("</main>\n")
+	if script != "" {
+		page.WriteString("<script>\n" + strings.TrimSpace(script) + "\n</script>\n")
+	}
+	page.WriteString("</body>\n</html>\n")
+	return page.Bytes()
+}
+
+// documentTitle 使用第一个标题作为页面标题，没有标题时使用文件名
+func documentTitle(doc *markdown.Document, outputPath string) string {
+	for _, block := range doc.Blocks {
+		if heading, ok := block.(*markdown.Heading); ok {
+			return markdown.PlainText(heading.Content)
+		}
+	}
+	return strings.TrimSuffix(filepath.Base(outputPath), filepath.Ext(outputPath))
+}
+
+// inlineImages 将引用的本地图片以data URI内嵌，找不到的图片保留原路径
+func inlineImages(fsys fs.FS, content, baseDir string) string {
+	mapping := make(map[string]string)
+	for _, imgPath := range utils.ExtractImages(content) {
+		if utils.IsRemoteImage(imgPath) {
+			continue
+		}
+		path := imgPath
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, filepath.FromSlash(imgPath))
+		}
+		data, err := vfs.ReadFile(readFS(fsys), path)
+		if err != nil {
+			fmt.Print(i18n.Sprintf(constants.MsgInlineImageFailed, imgPath, err))
+			continue
+		}
+		mapping[imgPath] = utils.EncodeDataURI(path, data)
+	}
+	return utils.RewriteImagePaths(content, mapping)
+}
@@ -0,0 +1,72 @@
+package export
+
+import (
+	"md-manual-tool/pkg/vfs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHTML(t *testing.T) {
+	tempDir := t.TempDir()
+	outputPath := filepath.Join(tempDir, "manual_1.0.1.md")
+	os.MkdirAll(filepath.Join(tempDir, "manual_1.0.1.assets"), 0755)
+	os.WriteFile(filepath.Join(tempDir, "manual_1.0.1.assets", "a.png"), []byte("png"), 0644)
+	content := []byte("# 部署手册\n\n| 项 | 值 |\n|---|---|\n| a | 1 |\n\n![图](./manual_1.0.1.assets/a.png)\n")
+
+	// 默认模式：样式写入单独的CSS文件，图片保留相对路径
+	files, err := HTML(outputPath, content, &HTMLOptions{})
+	if err != nil {
+		t.Fatalf("导出失败: %v", err)
+	}
+	if len(files) != 2 || files[0].Path != filepath.Join(tempDir, "manual_1.0.1.html") || files[1].Path != filepath.Join(tempDir, "manual_1.0.1.css") {
+		t.Fatalf("导出文件错误: %v", files)
+	}
+	page := string(files[0].Data)
+	for _, expected := range []string{
+		`<html lang="zh-CN">`,
+		"<title>部署手册</title>",
+		`<link rel="stylesheet" href="./manual_1.0.1.css">`,
+		"<td>a</td>",
+		`<img src="./manual_1.0.1.assets/a.png" alt="图">`,
+	} {
+		if !strings.Contains(page, expected) {
+			t.Errorf("页面缺少 %q:\n%s", expected, page)
+		}
+	}
+
+	// 自包含模式：内嵌样式和图片
+	files, err = HTML(outputPath, content, &HTMLOptions{Title: "手册", Lang: "en-US", Theme: "github", SelfContained: true})
+	if err != nil {
+		t.Fatalf("导出失败: %v", err)
+	}
+	page = string(files[0].Data)
+	if len(files) != 1 || !strings.Contains(page, `<html lang="en-US">`) || !strings.Contains(page, "<style>") || !strings.Contains(page, `<img src="data:image/png;base64,cG5n" alt="图">`) {
+		t.Errorf("自包含页面错误:\n%s", page)
+	}
+}
+
+func TestLoadTheme(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "corp.css"), []byte("body { color: red; }"), 0644)
+
+	if css, err := LoadTheme(nil, "", ""); err != nil || !strings.Contains(css, "body") {
+		t.Errorf("默认主题加载失败: %v", err)
+	}
+	if css, err := LoadTheme(nil, "corp.css", tempDir); err != nil || css != "body { color: red; }" {
+		t.Errorf("自定义主题加载失败: %q %v", css, err)
+	}
+	if _, err := LoadTheme(nil, "missing", tempDir); err == nil {
+		t.Error("不存在的主题应返回错误")
+	}
+
+	// 模板来自其他文件系统时，主题文件从同一文件系统中读取
+	source := vfs.NewMemFS(map[string]string{"docs/themes/corp.css": "h1 { color: blue; }"})
+	if css, err := LoadTheme(source, "themes/corp.css", "docs"); err != nil || css != "h1 { color: blue; }" {
+		t.Errorf("从模板文件系统加载主题失败: %q %v", css, err)
+	}
+	if _, err := LoadTheme(source, "corp.css", tempDir); err == nil {
+		t.Error("不应从本地文件系统读取主题")
+	}
+}
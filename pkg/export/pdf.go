@@ -0,0 +1,1115 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"md-manual-tool/pkg/constants"
+	"md-manual-tool/pkg/i18n"
+	"md-manual-tool/pkg/markdown"
+	"md-manual-tool/pkg/vfs"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// PDF页面尺寸（A4）和版式，单位为点（1/72英寸）
+const (
+	pdfPageWidth    = 595.28
+	pdfPageHeight   = 841.89
+	pdfMarginX      = 56.69 // 2厘米
+	pdfMarginTop    = 70.87 // 2.5厘米
+	pdfMarginBottom = 70.87
+
+	pdfBodySize   = 10.5
+	pdfCodeSize   = 9
+	pdfTableSize  = 9.5
+	pdfDecorSize  = 9   // 页眉页脚字号
+	pdfLeading    = 1.6 // 行高与字号之比
+	pdfListIndent = 18
+	pdfQuoteInset = 14
+	pdfPixel      = 0.75 // 图片按96 DPI换算为点
+	pdfTOCDepth   = 3    // 目录包含的标题级别
+)
+
+// pdfHeadingSizes 各级标题字号
+var pdfHeadingSizes = [7]float64{0, 20, 16, 14, 12, 11, 10.5}
+
+// 文字颜色
+const (
+	pdfTextColor  = "0.13 0.13 0.13"
+	pdfQuoteColor = "0.4 0.4 0.4"
+	pdfCodeColor  = "0.6 0.15 0.15"
+	pdfLinkColor  = "0.02 0.4 0.75"
+	pdfMutedColor = "0.45 0.45 0.45"
+)
+
+// 页眉页脚默认内容
+const (
+	DefaultPDFHeader = "{productName} {version}"
+	DefaultPDFFooter = "第 {page} 页 / 共 {pages} 页"
+)
+
+// pdfPlaceholder 页眉页脚中的占位符
+var pdfPlaceholder = regexp.MustCompile(`\{([A-Za-z0-9_.-]+)\}`)
+
+// htmlBreakRe 行内HTML换行标签
+var htmlBreakRe = regexp.MustCompile(`(?i)^<br\s*/?>$`)
+
+// PDFOptions PDF导出选项
+type PDFOptions struct {
+	Title     string             // 文档标题（写入文档属性），为空时使用第一个标题
+	Font      string             // 正文字体文件（TrueType），需要包含文档中的中文字符
+	MonoFont  string             // 代码字体文件，为空时代码中的英文使用内置Courier字体
+	FontDir   string             // 字体相对路径的基准目录
+	SourceFS  fs.FS              // 读取字体文件的文件系统（模板所在），为nil时使用本地文件系统
+	Header    string             // 页眉，{name}形式的占位符替换为Variables中的值，为空或none时不显示
+	Footer    string             // 页脚，{page}和{pages}替换为当前页码和总页数，为空或none时不显示
+	TOC       bool               // 在正文前生成目录页
+	Variables map[string]string  // 页眉页脚占位符的取值
+	SlugStyle markdown.SlugStyle // 标题锚点风格，用于解析文档内的#锚点链接
+	FS        fs.FS              // 读取输出目录中图片的文件系统，为nil时使用本地文件系统
+}
+
+// PDFPath 返回Markdown输出文件对应的PDF文件路径
+func PDFPath(outputPath string) string {
+	return strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + ".pdf"
+}
+
+// PDF 将渲染后的Markdown导出为PDF
+//
+// 字体以子集形式嵌入，中文可以正常显示、复制和搜索；标题生成书签（可选生成目录页），
+// 每页带页眉和页码，图片从输出目录读取后嵌入
+func PDF(outputPath string, content []byte, opts *PDFOptions) ([]File, error) {
+	if opts.Font == "" {
+		return nil, fmt.Errorf("导出PDF需要指定包含中文字形的TrueType字体文件（配置项 pdfFont）")
+	}
+	source := readFS(opts.SourceFS)
+	mainFont, err := loadTrueType(source, FontFile(source, opts.Font, opts.FontDir))
+	if err != nil {
+		return nil, err
+	}
+	res := &pdfResources{
+		main:     newPDFFont("F1", mainFont),
+		mono:     newPDFFont("F2", nil),
+		fs:       readFS(opts.FS),
+		baseDir:  filepath.Dir(outputPath),
+		imageSrc: make(map[string]*pdfImage),
+		imageErr: make(map[string]error),
+		missing:  make(map[rune]bool),
+	}
+	if opts.MonoFont != "" {
+		monoFont, err := loadTrueType(source, FontFile(source, opts.MonoFont, opts.FontDir))
+		if err != nil {
+			return nil, err
+		}
+		res.mono.tt = monoFont
+	}
+
+	doc := markdown.Parse(string(content))
+	doc.AssignIDs(opts.SlugStyle)
+	title := opts.Title
+	if title == "" {
+		title = documentTitle(doc, outputPath)
+	}
+
+	body := newPDFLayout(res)
+	body.blocks(doc.Blocks, false)
+
+	pages := body.pages
+	offset := 0
+	if opts.TOC && len(body.headings) > 0 {
+		// 先排版一次得到目录页数，正文页码随之后移
+		toc := tocLayout(res, body.headings, 0)
+		toc = tocLayout(res, body.headings, len(toc.pages))
+		offset = len(toc.pages)
+		pages = append(toc.pages, body.pages...)
+	}
+	resolveAnchors(body, offset)
+
+	vars := map[string]string{"title": title}
+	for key, value := range opts.Variables {
+		vars[key] = value
+	}
+	for i, page := range pages {
+		vars["page"] = strconv.Itoa(i + 1)
+		vars["pages"] = strconv.Itoa(len(pages))
+		decorate(res, page, expandPDFText(opts.Header, vars), expandPDFText(opts.Footer, vars))
+	}
+
+	if len(res.missing) > 0 {
+		var chars []string
+		for r := range res.missing {
+			chars = append(chars, string(r))
+		}
+		sort.Strings(chars)
+		if len(chars) > 10 {
+			chars = append(chars[:10], "…")
+		}
+		fmt.Print(i18n.Sprintf(constants.MsgFontMissingChars,
+			opts.Font, len(res.missing), strings.Join(chars, " ")))
+	}
+
+	outlines := buildOutlines(body.headings, offset)
+	return []File{{Path: PDFPath(outputPath), Data: writePDF(pages, res, outlines, title)}}, nil
+}
+
+// FontFile 解析字体路径：相对路径先相对于baseDir查找，找不到时相对于fsys的根目录（本地文件系统为当前目录）
+func FontFile(fsys fs.FS, path, baseDir string) string {
+	if filepath.IsAbs(path) || baseDir == "" {
+		return path
+	}
+	candidate := filepath.Join(baseDir, path)
+	if _, err := vfs.Stat(readFS(fsys), candidate); err == nil {
+		return candidate
+	}
+	return path
+}
+
+// expandPDFText 替换页眉页脚中的占位符，没有取值的占位符替换为空；none表示不显示
+func expandPDFText(pattern string, vars map[string]string) string {
+	if pattern == "none" {
+		return ""
+	}
+	result := pdfPlaceholder.ReplaceAllStringFunc(pattern, func(match string) string {
+		return vars[match[1:len(match)-1]]
+	})
+	return strings.TrimSpace(result)
+}
+
+// pdfResources 排版共用的字体和图片
+type pdfResources struct {
+	main     *pdfFont
+	mono     *pdfFont // 未指定代码字体时为内置Courier
+	fs       fs.FS
+	baseDir  string
+	images   []*pdfImage
+	imageSrc map[string]*pdfImage
+	imageErr map[string]error
+	missing  map[rune]bool // 正文字体中缺少的字符
+}
+
+// font 返回显示字符使用的字体：代码优先使用代码字体，其他字符使用正文字体
+func (r *pdfResources) font(ch rune, mono bool) *pdfFont {
+	if mono && r.mono.has(ch) {
+		return r.mono
+	}
+	if !r.main.has(ch) && !unicode.IsSpace(ch) {
+		r.missing[ch] = true
+	}
+	return r.main
+}
+
+// image 读取并缓存图片
+func (r *pdfResources) image(src string) (*pdfImage, error) {
+	if img, ok := r.imageSrc[src]; ok {
+		return img, nil
+	}
+	if err, ok := r.imageErr[src]; ok {
+		return nil, err
+	}
+	data, err := loadImage(r.fs, r.baseDir, src)
+	var img *pdfImage
+	if err == nil {
+		img, err = newPDFImage(fmt.Sprintf("Im%d", len(r.images)+1), data)
+	}
+	if err != nil {
+		r.imageErr[src] = err
+		return nil, err
+	}
+	r.images = append(r.images, img)
+	r.imageSrc[src] = img
+	return img, nil
+}
+
+// pdfStyle 文字样式
+type pdfStyle struct {
+	size   float64
+	bold   bool
+	italic bool
+	mono   bool
+	strike bool
+	color  string // 填充颜色（RGB）
+	link   string // 外部链接地址
+}
+
+// pdfWord 排版的最小单位：英文单词、单个中文字符或空白
+type pdfWord struct {
+	text  string
+	style pdfStyle
+	width float64
+	space bool // 空白，行首行尾的空白会被去掉
+	brk   bool // 强制换行
+}
+
+// pdfLink 页面上的链接区域
+type pdfLink struct {
+	rect   [4]float64
+	uri    string  // 外部链接地址，为空时链接到文档内的位置
+	anchor string  // 文档内的#锚点链接，排版完成后解析为目标标题的page和y
+	page   int     // 文档内链接的目标页
+	y      float64 // 文档内链接的目标位置
+}
+
+// pdfPage 一页的内容流和链接
+type pdfPage struct {
+	content bytes.Buffer
+	links   []pdfLink
+}
+
+// pdfHeading 标题的位置，用于书签和目录
+type pdfHeading struct {
+	level int
+	title string
+	id    string // 标题锚点
+	page  int
+	y     float64
+}
+
+// pdfLayout 将Markdown块排版到页面
+type pdfLayout struct {
+	res      *pdfResources
+	pages    []*pdfPage
+	y        float64   // 当前位置（距页面底部）
+	indent   float64   // 当前左缩进
+	quotes   []float64 // 引用竖线的横坐标
+	headings []pdfHeading
+}
+
+// newPDFLayout 创建排版器
+func newPDFLayout(res *pdfResources) *pdfLayout {
+	l := &pdfLayout{res: res}
+	l.newPage()
+	return l
+}
+
+// newPage 开始新的一页
+func (l *pdfLayout) newPage() {
+	l.pages = append(l.pages, &pdfPage{})
+	l.y = pdfPageHeight - pdfMarginTop
+}
+
+// page 返回当前页
+func (l *pdfLayout) page() *pdfPage {
+	return l.pages[len(l.pages)-1]
+}
+
+// atTop 判断是否位于页面顶部
+func (l *pdfLayout) atTop() bool {
+	return l.y >= pdfPageHeight-pdfMarginTop
+}
+
+// ensure 当前页剩余空间不足height时换页
+func (l *pdfLayout) ensure(height float64) {
+	if l.y-height < pdfMarginBottom && !l.atTop() {
+		l.newPage()
+	}
+}
+
+// space 增加垂直间距，页面顶部不留间距
+func (l *pdfLayout) space(height float64) {
+	if !l.atTop() {
+		l.y -= height
+	}
+}
+
+// left 返回当前行的起始横坐标
+func (l *pdfLayout) left() float64 {
+	return pdfMarginX + l.indent
+}
+
+// width 返回当前可用宽度
+func (l *pdfLayout) width() float64 {
+	return pdfPageWidth - 2*pdfMarginX - l.indent
+}
+
+// bodyStyle 返回正文样式，引用中的文字为灰色
+func (l *pdfLayout) bodyStyle() pdfStyle {
+	if len(l.quotes) > 0 {
+		return pdfStyle{size: pdfBodySize, color: pdfQuoteColor}
+	}
+	return pdfStyle{size: pdfBodySize, color: pdfTextColor}
+}
+
+// blocks 排版块级元素，tight为true时段落间距较小（紧凑列表项）
+func (l *pdfLayout) blocks(blocks []markdown.Block, tight bool) {
+	after := 6.0
+	if tight {
+		after = 2
+	}
+	for _, block := range blocks {
+		switch b := block.(type) {
+		case *markdown.Heading:
+			l.heading(b)
+		case *markdown.Paragraph:
+			l.paragraph(b.Content, l.bodyStyle(), markdown.AlignNone, "")
+			l.space(after)
+		case *markdown.CodeBlock:
+			l.codeBlock(b.Code)
+			l.space(after)
+		case *markdown.List:
+			l.list(b)
+			l.space(4)
+		case *markdown.Quote:
+			l.indent += pdfQuoteInset
+			l.quotes = append(l.quotes, l.left()-pdfQuoteInset+2)
+			l.blocks(b.Blocks, false)
+			l.quotes = l.quotes[:len(l.quotes)-1]
+			l.indent -= pdfQuoteInset
+		case *markdown.Rule:
+			l.space(6)
+			l.ensure(6)
+			fmt.Fprintf(&l.page().content, "0.75 G 0.5 w %s %s m %s %s l S\n",
+				pdfNum(l.left()), pdfNum(l.y), pdfNum(l.left()+l.width()), pdfNum(l.y))
+			l.y -= 6
+		case *markdown.HTMLBlock:
+			l.htmlBlock(b.Raw)
+		case *markdown.Table:
+			l.table(b)
+			l.space(after)
+		}
+	}
+}
+
+// heading 排版标题并记录位置，标题与下文至少两行在同一页
+func (l *pdfLayout) heading(h *markdown.Heading) {
+	size := pdfHeadingSizes[h.Level]
+	style := pdfStyle{size: size, bold: true, color: pdfTextColor}
+	lines := l.wrap(l.words(h.Content, style), l.width())
+
+	l.space(size * 0.8)
+	l.ensure(float64(len(lines))*size*pdfLeading + 2*pdfBodySize*pdfLeading)
+	l.headings = append(l.headings, pdfHeading{
+		level: h.Level,
+		title: markdown.PlainText(h.Content),
+		id:    h.ID,
+		page:  len(l.pages) - 1,
+		y:     l.y,
+	})
+	for _, line := range lines {
+		l.line(line, size*pdfLeading, markdown.AlignNone)
+	}
+	l.space(size * 0.4)
+}
+
+// paragraph 排版段落，图片单独成行；marker为列表项标记，显示在首行左侧
+func (l *pdfLayout) paragraph(content []markdown.Inline, style pdfStyle, align markdown.Alignment, marker string) {
+	drawMarker := func(baseline float64) {
+		if marker != "" {
+			l.text(marker, style, l.left()-l.measure(marker, style)-4, baseline)
+			marker = ""
+		}
+	}
+
+	var run []markdown.Inline
+	flush := func() {
+		for _, line := range l.wrap(l.words(run, style), l.width()) {
+			drawMarker(l.line(line, style.size*pdfLeading, align))
+		}
+		run = nil
+	}
+	for _, node := range content {
+		if img, ok := node.(*markdown.Image); ok {
+			flush()
+			if marker != "" {
+				l.ensure(style.size * pdfLeading)
+				drawMarker(l.y - style.size)
+			}
+			l.image(img)
+			continue
+		}
+		run = append(run, node)
+	}
+	flush()
+}
+
+// words 将行内元素拆分为排版单位
+func (l *pdfLayout) words(nodes []markdown.Inline, style pdfStyle) []pdfWord {
+	var words []pdfWord
+	for _, node := range nodes {
+		s := style
+		switch n := node.(type) {
+		case *markdown.Text:
+			words = l.splitWords(words, joinLines(n.Value), s)
+		case *markdown.Code:
+			s.mono = true
+			s.color = pdfCodeColor
+			words = l.splitWords(words, n.Value, s)
+		case *markdown.Strong:
+			s.bold = true
+			words = append(words, l.words(n.Children, s)...)
+		case *markdown.Emphasis:
+			s.italic = true
+			words = append(words, l.words(n.Children, s)...)
+		case *markdown.Strike:
+			s.strike = true
+			words = append(words, l.words(n.Children, s)...)
+		case *markdown.Link:
+			s.color = pdfLinkColor
+			s.link = n.Href
+			words = append(words, l.words(n.Children, s)...)
+		case *markdown.Image:
+			s.italic = true
+			words = l.splitWords(words, fmt.Sprintf("[图片: %s]", firstNonEmpty(n.Alt, n.Src)), s)
+		case *markdown.LineBreak:
+			words = append(words, pdfWord{style: s, brk: true})
+		case *markdown.RawHTML:
+			if htmlBreakRe.MatchString(n.Value) {
+				words = append(words, pdfWord{style: s, brk: true})
+			}
+		}
+	}
+	return words
+}
+
+// splitWords 拆分文本：英文按空白分词，中日韩字符逐字拆分，句末标点跟随前一个字符
+func (l *pdfLayout) splitWords(words []pdfWord, text string, style pdfStyle) []pdfWord {
+	var current strings.Builder
+	flush := func() {
+		if current.Len() > 0 {
+			words = append(words, l.word(current.String(), style))
+			current.Reset()
+		}
+	}
+	for _, r := range text {
+		switch {
+		case r == ' ' || r == '\t' || r == '\n':
+			flush()
+			if len(words) == 0 || !words[len(words)-1].space {
+				space := l.word(" ", style)
+				space.space = true
+				words = append(words, space)
+			}
+		case isWide(r):
+			flush()
+			if last := len(words) - 1; isClosingPunct(r) && last >= 0 && !words[last].space && !words[last].brk {
+				words[last].text += string(r)
+				words[last].width += l.measure(string(r), style)
+			} else {
+				words = append(words, l.word(string(r), style))
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+	return words
+}
+
+// isWide 判断是否为可以在任意位置断行的中日韩字符或全角符号
+func isWide(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) ||
+		(r >= 0x3000 && r <= 0x303F) || (r >= 0xFF00 && r <= 0xFFEF) || r == '“' || r == '”' || r == '‘' || r == '’' || r == '…'
+}
+
+// isClosingPunct 判断是否为不能出现在行首的标点
+func isClosingPunct(r rune) bool {
+	return strings.ContainsRune("，。、；：？！）》」』”’〉】…,.;:?!)", r)
+}
+
+// word 创建排版单位并计算宽度
+func (l *pdfLayout) word(text string, style pdfStyle) pdfWord {
+	return pdfWord{text: text, style: style, width: l.measure(text, style)}
+}
+
+// measure 计算文本宽度
+func (l *pdfLayout) measure(text string, style pdfStyle) float64 {
+	total := 0.0
+	for _, r := range text {
+		font := l.res.font(r, style.mono)
+		if r == ' ' && !font.has(r) {
+			total += style.size * 0.3
+			continue
+		}
+		total += font.width(r, style.size)
+	}
+	return total
+}
+
+// wrap 按宽度将排版单位分行，超长的单词按字符拆开
+func (l *pdfLayout) wrap(words []pdfWord, width float64) [][]pdfWord {
+	var lines [][]pdfWord
+	var line []pdfWord
+	lineWidth := 0.0
+	end := func() {
+		for len(line) > 0 && line[len(line)-1].space {
+			line = line[:len(line)-1]
+		}
+		if len(line) > 0 {
+			lines = append(lines, line)
+		}
+		line, lineWidth = nil, 0
+	}
+
+	for _, w := range words {
+		switch {
+		case w.brk:
+			end()
+		case w.space:
+			if len(line) > 0 {
+				line = append(line, w)
+				lineWidth += w.width
+			}
+		case w.width > width:
+			end()
+			for _, piece := range l.breakWord(w, width) {
+				end()
+				line, lineWidth = []pdfWord{piece}, piece.width
+			}
+		default:
+			if lineWidth+w.width > width && len(line) > 0 {
+				end()
+			}
+			line = append(line, w)
+			lineWidth += w.width
+		}
+	}
+	end()
+	return lines
+}
+
+// breakWord 将超过行宽的单词按字符拆分
+func (l *pdfLayout) breakWord(w pdfWord, width float64) []pdfWord {
+	var pieces []pdfWord
+	var current strings.Builder
+	currentWidth := 0.0
+	for _, r := range w.text {
+		rw := l.measure(string(r), w.style)
+		if currentWidth+rw > width && current.Len() > 0 {
+			pieces = append(pieces, pdfWord{text: current.String(), style: w.style, width: currentWidth})
+			current.Reset()
+			currentWidth = 0
+		}
+		current.WriteRune(r)
+		currentWidth += rw
+	}
+	if current.Len() > 0 {
+		pieces = append(pieces, pdfWord{text: current.String(), style: w.style, width: currentWidth})
+	}
+	return pieces
+}
+
+// line 在当前位置排版一行，空间不足时换页，返回基线位置
+func (l *pdfLayout) line(words []pdfWord, height float64, align markdown.Alignment) float64 {
+	l.ensure(height)
+	size := 0.0
+	for _, w := range words {
+		if w.style.size > size {
+			size = w.style.size
+		}
+	}
+	baseline := l.y - (height-size)/2 - size*0.8
+	l.quoteBars(height)
+	l.drawWords(words, l.left(), l.width(), baseline, align)
+	l.y -= height
+	return baseline
+}
+
+// drawWords 在基线上绘制一行文字
+func (l *pdfLayout) drawWords(words []pdfWord, x, width, baseline float64, align markdown.Alignment) {
+	total := 0.0
+	for _, w := range words {
+		total += w.width
+	}
+	switch align {
+	case markdown.AlignCenter:
+		x += (width - total) / 2
+	case markdown.AlignRight:
+		x += width - total
+	}
+
+	// 相同样式的相邻单词合并输出
+	var run strings.Builder
+	var runStyle pdfStyle
+	runX := x
+	flush := func() {
+		if text := strings.TrimRight(run.String(), " "); text != "" {
+			l.text(text, runStyle, runX, baseline)
+		}
+		run.Reset()
+	}
+	for _, w := range words {
+		if w.style != runStyle || run.Len() == 0 {
+			flush()
+			runStyle, runX = w.style, x
+		}
+		run.WriteString(w.text)
+		if w.style.link != "" {
+			link := pdfLink{rect: [4]float64{x, baseline - w.style.size*0.25, x + w.width, baseline + w.style.size*0.85}}
+			if strings.HasPrefix(w.style.link, "#") {
+				link.anchor = w.style.link
+			} else {
+				link.uri = w.style.link
+			}
+			l.page().links = append(l.page().links, link)
+		}
+		x += w.width
+	}
+	flush()
+}
+
+// text 在指定位置绘制文本，按字符所属字体分段输出
+func (l *pdfLayout) text(text string, style pdfStyle, x, baseline float64) {
+	out := &l.page().content
+	start := x
+	var font *pdfFont
+	var segment strings.Builder
+	flush := func() {
+		if segment.Len() == 0 {
+			return
+		}
+		s := segment.String()
+		mode := "0 Tr"
+		if style.bold {
+			// 没有粗体字体时用描边模拟加粗
+			mode = fmt.Sprintf("2 Tr %s RG %s w", style.color, pdfNum(style.size*0.03))
+		}
+		skew := "0"
+		if style.italic {
+			skew = "0.21"
+		}
+		fmt.Fprintf(out, "BT /%s %s Tf %s rg %s 1 0 %s 1 %s %s Tm %s Tj ET\n",
+			font.name, pdfNum(style.size), style.color, mode, skew, pdfNum(x), pdfNum(baseline), font.encode(s))
+		x += l.measure(s, style)
+		segment.Reset()
+	}
+
+	for _, r := range text {
+		f := l.res.font(r, style.mono)
+		if r == ' ' && !f.has(r) {
+			flush()
+			x += style.size * 0.3
+			continue
+		}
+		if f != font {
+			flush()
+			font = f
+		}
+		segment.WriteRune(r)
+	}
+	flush()
+
+	if style.strike {
+		y := baseline + style.size*0.3
+		fmt.Fprintf(out, "%s RG %s w %s %s m %s %s l S\n",
+			style.color, pdfNum(style.size*0.06), pdfNum(start), pdfNum(y), pdfNum(x), pdfNum(y))
+	}
+}
+
+// rect 填充矩形
+func (l *pdfLayout) rect(x, y, width, height float64, color string) {
+	fmt.Fprintf(&l.page().content, "%s rg %s %s %s %s re f\n",
+		color, pdfNum(x), pdfNum(y), pdfNum(width), pdfNum(height))
+}
+
+// quoteBars 在当前行左侧绘制引用竖线
+func (l *pdfLayout) quoteBars(height float64) {
+	for _, x := range l.quotes {
+		l.rect(x, l.y-height, 2.5, height, "0.82 0.82 0.82")
+	}
+}
+
+// codeBlock 排版代码块：等宽字体、灰色背景，超长的行按字符折行
+func (l *pdfLayout) codeBlock(code string) {
+	style := pdfStyle{size: pdfCodeSize, mono: true, color: pdfTextColor}
+	height := pdfCodeSize * 1.45
+	const padding = 6.0
+	const background = "0.96 0.96 0.96"
+
+	l.ensure(height + 2*padding)
+	l.rect(l.left(), l.y-padding, l.width(), padding, background)
+	l.y -= padding
+	for _, line := range strings.Split(strings.TrimSuffix(code, "\n"), "\n") {
+		for _, piece := range l.breakCode(expandTabs(line), style, l.width()-2*padding) {
+			l.ensure(height)
+			l.rect(l.left(), l.y-height, l.width(), height, background)
+			l.quoteBars(height)
+			if piece != "" {
+				l.text(piece, style, l.left()+padding, l.y-(height-pdfCodeSize)/2-pdfCodeSize*0.8)
+			}
+			l.y -= height
+		}
+	}
+	l.ensure(padding)
+	l.rect(l.left(), l.y-padding, l.width(), padding, background)
+	l.y -= padding
+}
+
+// breakCode 将代码行按宽度拆分
+func (l *pdfLayout) breakCode(line string, style pdfStyle, width float64) []string {
+	if line == "" {
+		return []string{""}
+	}
+	var pieces []string
+	for _, piece := range l.breakWord(pdfWord{text: line, style: style}, width) {
+		pieces = append(pieces, piece.text)
+	}
+	return pieces
+}
+
+// list 排版列表，嵌套列表逐级缩进
+func (l *pdfLayout) list(list *markdown.List) {
+	bullet := "•"
+	if !l.res.main.has('•') {
+		bullet = "-"
+	}
+	for i, item := range list.Items {
+		marker := bullet
+		if list.Ordered {
+			marker = fmt.Sprintf("%d.", list.Start+i)
+		}
+
+		l.indent += pdfListIndent
+		blocks := item.Blocks
+		if len(blocks) > 0 {
+			if p, ok := blocks[0].(*markdown.Paragraph); ok {
+				l.paragraph(p.Content, l.bodyStyle(), markdown.AlignNone, marker)
+				if item.Loose {
+					l.space(6)
+				} else {
+					l.space(2)
+				}
+				blocks, marker = blocks[1:], ""
+			}
+		}
+		if marker != "" {
+			style := l.bodyStyle()
+			l.ensure(style.size * pdfLeading)
+			l.text(marker, style, l.left()-l.measure(marker, style)-4, l.y-style.size)
+		}
+		l.blocks(blocks, !item.Loose)
+		l.indent -= pdfListIndent
+	}
+}
+
+// htmlBlock 排版内嵌HTML：提取其中的图片和文本，居中显示
+func (l *pdfLayout) htmlBlock(raw string) {
+	var content []markdown.Inline
+	for _, m := range htmlImgRe.FindAllStringSubmatch(raw, -1) {
+		alt := ""
+		if altMatch := htmlAltRe.FindStringSubmatch(m[0]); altMatch != nil {
+			alt = altMatch[1]
+		}
+		content = append(content, &markdown.Image{Src: m[1], Alt: alt})
+	}
+	if text := strings.TrimSpace(htmlTagRe.ReplaceAllString(raw, "")); text != "" {
+		content = append(content, &markdown.Text{Value: text})
+	}
+	if len(content) > 0 {
+		l.paragraph(content, l.bodyStyle(), markdown.AlignCenter, "")
+		l.space(6)
+	}
+}
+
+// image 排版图片：居中显示，超过版心时等比缩小，无法嵌入时以文字说明代替
+func (l *pdfLayout) image(img *markdown.Image) {
+	pi, err := l.res.image(img.Src)
+	if err != nil {
+		fmt.Print(i18n.Sprintf(constants.MsgEmbedImageFailed, img.Src, err))
+		style := l.bodyStyle()
+		style.italic = true
+		l.paragraph([]markdown.Inline{&markdown.Text{Value: fmt.Sprintf("[图片: %s]", firstNonEmpty(img.Alt, img.Src))}},
+			style, markdown.AlignNone, "")
+		return
+	}
+
+	width, height := float64(pi.width)*pdfPixel, float64(pi.height)*pdfPixel
+	if width > l.width() {
+		height = height * l.width() / width
+		width = l.width()
+	}
+	if maxHeight := pdfPageHeight - pdfMarginTop - pdfMarginBottom; height > maxHeight {
+		width = width * maxHeight / height
+		height = maxHeight
+	}
+
+	l.ensure(height)
+	x := l.left() + (l.width()-width)/2
+	fmt.Fprintf(&l.page().content, "q %s 0 0 %s %s %s cm /%s Do Q\n",
+		pdfNum(width), pdfNum(height), pdfNum(x), pdfNum(l.y-height), pi.name)
+	l.y -= height
+	l.space(6)
+}
+
+// table 排版表格：列宽平均分配，单元格内文字折行，跨页时重复表头
+func (l *pdfLayout) table(table *markdown.Table) {
+	columns := len(table.Align)
+	columnWidth := l.width() / float64(columns)
+	const padding = 4.0
+	lineHeight := pdfTableSize * 1.4
+
+	var row func(cells [][]markdown.Inline, header bool)
+	row = func(cells [][]markdown.Inline, header bool) {
+		style := pdfStyle{size: pdfTableSize, bold: header, color: pdfTextColor}
+		lines := make([][][]pdfWord, columns)
+		count := 1
+		for j := 0; j < columns && j < len(cells); j++ {
+			lines[j] = l.wrap(l.words(cells[j], style), columnWidth-2*padding)
+			if len(lines[j]) > count {
+				count = len(lines[j])
+			}
+		}
+		height := float64(count)*lineHeight + 2*padding
+		if l.y-height < pdfMarginBottom && !l.atTop() {
+			l.newPage()
+			if !header {
+				row(table.Header, true)
+			}
+		}
+
+		out := &l.page().content
+		top := l.y
+		x := l.left()
+		for j := 0; j < columns; j++ {
+			if header {
+				l.rect(x, top-height, columnWidth, height, "0.93 0.93 0.93")
+			}
+			fmt.Fprintf(out, "0.6 G 0.5 w %s %s %s %s re S\n",
+				pdfNum(x), pdfNum(top-height), pdfNum(columnWidth), pdfNum(height))
+			y := top - padding
+			for _, line := range lines[j] {
+				baseline := y - (lineHeight-pdfTableSize)/2 - pdfTableSize*0.8
+				l.drawWords(line, x+padding, columnWidth-2*padding, baseline, table.Align[j])
+				y -= lineHeight
+			}
+			x += columnWidth
+		}
+		l.y = top - height
+	}
+
+	// 表头不单独留在页尾
+	l.ensure(2 * (lineHeight + 2*padding))
+	row(table.Header, true)
+	for _, cells := range table.Rows {
+		row(cells, false)
+	}
+}
+
+// resolveAnchors 将正文中的#锚点链接解析为标题所在的页面位置，找不到目标标题的链接被移除
+func resolveAnchors(body *pdfLayout, offset int) {
+	targets := make(map[string]pdfHeading)
+	for _, h := range body.headings {
+		if h.id != "" {
+			targets[h.id] = h
+		}
+	}
+	for _, page := range body.pages {
+		links := page.links[:0]
+		for _, link := range page.links {
+			if link.anchor != "" {
+				anchor := link.anchor[1:]
+				if unescaped, err := url.PathUnescape(anchor); err == nil {
+					anchor = unescaped
+				}
+				h, ok := targets[anchor]
+				if !ok {
+					continue
+				}
+				link.page, link.y = offset+h.page, h.y
+			}
+			links = append(links, link)
+		}
+		page.links = links
+	}
+}
+
+// tocLayout 排版目录页，offset为目录页数（正文页码的偏移）
+func tocLayout(res *pdfResources, headings []pdfHeading, offset int) *pdfLayout {
+	l := newPDFLayout(res)
+	titleStyle := pdfStyle{size: pdfHeadingSizes[1], bold: true, color: pdfTextColor}
+	l.line([]pdfWord{l.word("目录", titleStyle)}, titleStyle.size*pdfLeading, markdown.AlignCenter)
+	l.space(12)
+
+	for _, h := range headings {
+		if h.level > pdfTOCDepth {
+			continue
+		}
+		style := pdfStyle{size: pdfBodySize, bold: h.level == 1, color: pdfTextColor}
+		height := style.size * 1.8
+		l.indent = float64(h.level-1) * 16
+		number := strconv.Itoa(offset + h.page + 1)
+		numberWidth := l.measure(number, style)
+		right := l.left() + l.width()
+
+		lines := l.wrap(l.splitWords(nil, h.title, style), l.width()-numberWidth-24)
+		for i, line := range lines {
+			baseline := l.line(line, height, markdown.AlignNone)
+			l.page().links = append(l.page().links, pdfLink{
+				rect: [4]float64{l.left(), l.y, right, l.y + height},
+				page: offset + h.page,
+				y:    h.y,
+			})
+			if i < len(lines)-1 {
+				continue
+			}
+			// 最后一行以点线连接页码
+			lineWidth := 0.0
+			for _, w := range line {
+				lineWidth += w.width
+			}
+			dotStyle := pdfStyle{size: style.size, color: pdfMutedColor}
+			dotWidth := l.measure(".", dotStyle)
+			if dots := int((right - numberWidth - l.left() - lineWidth - 8) / dotWidth); dots > 0 {
+				l.text(strings.Repeat(".", dots), dotStyle, right-numberWidth-4-float64(dots)*dotWidth, baseline)
+			}
+			l.text(number, style, right-numberWidth, baseline)
+		}
+	}
+	return l
+}
+
+// decorate 绘制页眉和页脚
+func decorate(res *pdfResources, page *pdfPage, header, footer string) {
+	l := &pdfLayout{res: res, pages: []*pdfPage{page}}
+	style := pdfStyle{size: pdfDecorSize, color: pdfMutedColor}
+	if header != "" {
+		l.text(header, style, pdfMarginX, pdfPageHeight-pdfMarginTop+22)
+		fmt.Fprintf(&page.content, "0.75 G 0.5 w %s %s m %s %s l S\n",
+			pdfNum(pdfMarginX), pdfNum(pdfPageHeight-pdfMarginTop+16),
+			pdfNum(pdfPageWidth-pdfMarginX), pdfNum(pdfPageHeight-pdfMarginTop+16))
+	}
+	if footer != "" {
+		l.text(footer, style, (pdfPageWidth-l.measure(footer, style))/2, pdfMarginBottom-30)
+	}
+}
+
+// pdfOutline 书签
+type pdfOutline struct {
+	title    string
+	page     int
+	y        float64
+	level    int
+	children []*pdfOutline
+	num      int
+}
+
+// buildOutlines 按标题级别生成书签树，offset为正文前的页数
+func buildOutlines(headings []pdfHeading, offset int) []*pdfOutline {
+	root := &pdfOutline{}
+	stack := []*pdfOutline{root}
+	for _, h := range headings {
+		item := &pdfOutline{title: h.title, page: offset + h.page, y: h.y, level: h.level}
+		for len(stack) > 1 && stack[len(stack)-1].level >= h.level {
+			stack = stack[:len(stack)-1]
+		}
+		parent := stack[len(stack)-1]
+		parent.children = append(parent.children, item)
+		stack = append(stack, item)
+	}
+	return root.children
+}
+
+// count 返回书签下所有子孙的数量
+func (o *pdfOutline) count() int {
+	n := len(o.children)
+	for _, child := range o.children {
+		n += child.count()
+	}
+	return n
+}
+
+// writePDF 组装PDF文件
+func writePDF(pages []*pdfPage, res *pdfResources, outlines []*pdfOutline, title string) []byte {
+	w := &pdfWriter{}
+	catalog := w.alloc()
+	pagesNum := w.alloc()
+	info := w.add("<< /Title %s /Producer (md-manual-tool) >>", pdfTextString(title))
+
+	pageNums := make([]int, len(pages))
+	for i := range pages {
+		pageNums[i] = w.alloc()
+	}
+	dest := func(page int, y float64) string {
+		return fmt.Sprintf("[%d 0 R /XYZ 0 %s null]", pageNums[page], pdfNum(y+12))
+	}
+
+	for i, page := range pages {
+		contents := w.alloc()
+		w.stream(contents, "", page.content.Bytes())
+
+		var annots []string
+		for _, link := range page.links {
+			rect := fmt.Sprintf("[%s %s %s %s]", pdfNum(link.rect[0]), pdfNum(link.rect[1]), pdfNum(link.rect[2]), pdfNum(link.rect[3]))
+			if link.uri != "" {
+				annots = append(annots, fmt.Sprintf("<< /Type /Annot /Subtype /Link /Rect %s /Border [0 0 0] /A << /S /URI /URI %s >> >>",
+					rect, pdfLiteral(link.uri)))
+			} else {
+				annots = append(annots, fmt.Sprintf("<< /Type /Annot /Subtype /Link /Rect %s /Border [0 0 0] /Dest %s >>",
+					rect, dest(link.page, link.y)))
+			}
+		}
+		annotEntry := ""
+		if len(annots) > 0 {
+			annotEntry = " /Annots [" + strings.Join(annots, " ") + "]"
+		}
+		w.set(pageNums[i], "<< /Type /Page /Parent %d 0 R /Contents %d 0 R%s >>", pagesNum, contents, annotEntry)
+	}
+
+	var fonts, images strings.Builder
+	for _, font := range []*pdfFont{res.main, res.mono} {
+		if font.active {
+			fmt.Fprintf(&fonts, "/%s %d 0 R ", font.name, font.write(w))
+		}
+	}
+	for _, img := range res.images {
+		fmt.Fprintf(&images, "/%s %d 0 R ", img.name, img.write(w))
+	}
+
+	kids := make([]string, len(pageNums))
+	for i, num := range pageNums {
+		kids[i] = fmt.Sprintf("%d 0 R", num)
+	}
+	w.set(pagesNum, "<< /Type /Pages /Kids [%s] /Count %d /MediaBox [0 0 %s %s] "+
+		"/Resources << /ProcSet [/PDF /Text /ImageB /ImageC] /Font << %s>> /XObject << %s>> >> >>",
+		strings.Join(kids, " "), len(pages), pdfNum(pdfPageWidth), pdfNum(pdfPageHeight), fonts.String(), images.String())
+
+	if len(outlines) == 0 {
+		w.set(catalog, "<< /Type /Catalog /Pages %d 0 R >>", pagesNum)
+		return w.bytes(catalog, info)
+	}
+
+	root := w.alloc()
+	var assign func(items []*pdfOutline)
+	assign = func(items []*pdfOutline) {
+		for _, item := range items {
+			item.num = w.alloc()
+			assign(item.children)
+		}
+	}
+	assign(outlines)
+
+	var write func(items []*pdfOutline, parent int)
+	write = func(items []*pdfOutline, parent int) {
+		for i, item := range items {
+			var b strings.Builder
+			fmt.Fprintf(&b, "<< /Title %s /Parent %d 0 R /Dest %s", pdfTextString(item.title), parent, dest(item.page, item.y))
+			if i > 0 {
+				fmt.Fprintf(&b, " /Prev %d 0 R", items[i-1].num)
+			}
+			if i < len(items)-1 {
+				fmt.Fprintf(&b, " /Next %d 0 R", items[i+1].num)
+			}
+			if len(item.children) > 0 {
+				fmt.Fprintf(&b, " /First %d 0 R /Last %d 0 R /Count %d",
+					item.children[0].num, item.children[len(item.children)-1].num, item.count())
+			}
+			b.WriteString(" >>")
+			w.set(item.num, "%s", b.String())
+			write(item.children, item.num)
+		}
+	}
+	write(outlines, root)
+
+	total := len(outlines)
+	for _, item := range outlines {
+		total += item.count()
+	}
+	w.set(root, "<< /Type /Outlines /First %d 0 R /Last %d 0 R /Count %d >>",
+		outlines[0].num, outlines[len(outlines)-1].num, total)
+	w.set(catalog, "<< /Type /Catalog /Pages %d 0 R /Outlines %d 0 R /PageMode /UseOutlines >>", pagesNum, root)
+	return w.bytes(catalog, info)
+}
@@ -0,0 +1,373 @@
+package export
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"md-manual-tool/pkg/vfs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+// testFontChars 测试字体包含的字符，'复'为引用'中'的组合字形
+const testFontChars = " .-/:[]()•0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz中文部署手册目录第页共图片安装参数说明"
+
+// buildTestFont 生成测试用的TrueType字体：每个字符一个方框字形
+func buildTestFont() []byte {
+	chars := []rune(testFontChars + "复")
+	numGlyphs := len(chars) + 1
+
+	box := func(width int16) []byte {
+		g := make([]byte, 10)
+		binary.BigEndian.PutUint16(g, 1) // 一个轮廓
+		binary.BigEndian.PutUint16(g[4:], 0)
+		binary.BigEndian.PutUint16(g[6:], uint16(width-50))
+		binary.BigEndian.PutUint16(g[8:], 700)
+		binary.BigEndian.PutUint16(g[2:], 50)
+		g = append(g, 0, 3, 0, 0) // endPtsOfContours, instructionLength
+		g = append(g, 1, 1, 1, 1) // 4个落在曲线上的点，坐标为16位增量
+		for _, d := range []int16{50, width - 100, 0, 100 - width, 0, 0, 700, 0} {
+			g = binary.BigEndian.AppendUint16(g, uint16(d))
+		}
+		return g
+	}
+
+	var glyf []byte
+	loca := make([]byte, 0, (numGlyphs+1)*4)
+	hmtx := make([]byte, 0, numGlyphs*4)
+	used := make(map[uint16]rune)
+	zhong := 0
+	for gid := 0; gid < numGlyphs; gid++ {
+		loca = binary.BigEndian.AppendUint32(loca, uint32(len(glyf)))
+		width := int16(500)
+		if gid > 0 && chars[gid-1] > 0x2E80 {
+			width = 1000
+		}
+		switch {
+		case gid > 0 && chars[gid-1] == '中':
+			zhong = gid
+			glyf = append(glyf, box(width)...)
+		case gid > 0 && chars[gid-1] == '复':
+			// 组合字形：平移引用'中'
+			g := make([]byte, 10)
+			binary.BigEndian.PutUint16(g, 0xFFFF)
+			binary.BigEndian.PutUint16(g[6:], 950)
+			binary.BigEndian.PutUint16(g[8:], 700)
+			g = binary.BigEndian.AppendUint16(g, 0x0003)
+			g = binary.BigEndian.AppendUint16(g, uint16(zhong))
+			g = binary.BigEndian.AppendUint16(g, 0)
+			g = binary.BigEndian.AppendUint16(g, 0)
+			glyf = append(glyf, g...)
+		case gid == 0 || chars[gid-1] != ' ':
+			glyf = append(glyf, box(width)...)
+		}
+		for len(glyf)%4 != 0 {
+			glyf = append(glyf, 0)
+		}
+		hmtx = binary.BigEndian.AppendUint16(hmtx, uint16(width))
+		hmtx = binary.BigEndian.AppendUint16(hmtx, 50)
+		if gid > 0 {
+			used[uint16(gid)] = chars[gid-1]
+		}
+	}
+	loca = binary.BigEndian.AppendUint32(loca, uint32(len(glyf)))
+
+	head := make([]byte, 54)
+	binary.BigEndian.PutUint32(head, 0x00010000)
+	binary.BigEndian.PutUint32(head[12:], 0x5F0F3CF5)
+	binary.BigEndian.PutUint16(head[18:], 1000)
+	binary.BigEndian.PutUint16(head[40:], 1000)
+	binary.BigEndian.PutUint16(head[42:], 800)
+	binary.BigEndian.PutUint16(head[50:], 1)
+
+	hhea := make([]byte, 36)
+	binary.BigEndian.PutUint32(hhea, 0x00010000)
+	binary.BigEndian.PutUint16(hhea[4:], 800)
+	binary.BigEndian.PutUint16(hhea[6:], uint16(0xFFFF-199)) // -200
+	binary.BigEndian.PutUint16(hhea[34:], uint16(numGlyphs))
+
+	maxp := make([]byte, 32)
+	binary.BigEndian.PutUint32(maxp, 0x00010000)
+	binary.BigEndian.PutUint16(maxp[4:], uint16(numGlyphs))
+
+	fontName := utf16.Encode([]rune("TestSans"))
+	name := make([]byte, 18)
+	binary.BigEndian.PutUint16(name[2:], 1)
+	binary.BigEndian.PutUint16(name[4:], 18)
+	binary.BigEndian.PutUint16(name[6:], 3)
+	binary.BigEndian.PutUint16(name[8:], 1)
+	binary.BigEndian.PutUint16(name[10:], 0x409)
+	binary.BigEndian.PutUint16(name[12:], 6)
+	binary.BigEndian.PutUint16(name[14:], uint16(len(fontName)*2))
+	for _, u := range fontName {
+		name = binary.BigEndian.AppendUint16(name, u)
+	}
+
+	return buildSfnt(map[string][]byte{
+		"head": head, "hhea": hhea, "maxp": maxp, "hmtx": hmtx,
+		"loca": loca, "glyf": glyf, "cmap": cmapFormat4(used), "name": name, "post": postFormat3(nil),
+	})
+}
+
+// writeTestFont 将测试字体写入临时目录，返回文件路径
+func writeTestFont(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.ttf")
+	if err := os.WriteFile(path, buildTestFont(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// pdfObjRe PDF对象的开头
+var pdfObjRe = regexp.MustCompile(`(?m)^(\d+) 0 obj\n`)
+
+// readPDF 检查交叉引用表中的偏移都指向对应的对象，返回所有对象和解压后的流
+func readPDF(t *testing.T, data []byte) (objects map[int]string, streams map[int]string) {
+	t.Helper()
+	if !bytes.HasPrefix(data, []byte("%PDF-1.7\n")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatal("PDF文件头或文件尾错误")
+	}
+	start := bytes.LastIndex(data, []byte("startxref\n"))
+	xref, err := strconv.Atoi(strings.Fields(string(data[start+10:]))[0])
+	if err != nil || !bytes.HasPrefix(data[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref没有指向交叉引用表: %d", xref)
+	}
+	lines := strings.Split(string(data[xref:]), "\n")
+	count, _ := strconv.Atoi(strings.Fields(lines[1])[1])
+
+	objects = make(map[int]string)
+	streams = make(map[int]string)
+	for num := 1; num < count; num++ {
+		offset, _ := strconv.Atoi(lines[2+num][:10])
+		header := fmt.Sprintf("%d 0 obj\n", num)
+		if !bytes.HasPrefix(data[offset:], []byte(header)) {
+			t.Fatalf("对象 %d 的偏移 %d 错误", num, offset)
+		}
+		body := data[offset+len(header):]
+		body = body[:bytes.Index(body, []byte("\nendobj\n"))]
+		objects[num] = string(body)
+
+		if m := regexp.MustCompile(`/Length (\d+) >>\nstream\n`).FindSubmatchIndex(body); m != nil {
+			length, _ := strconv.Atoi(string(body[m[2]:m[3]]))
+			raw := body[m[1] : m[1]+length]
+			if !bytes.HasPrefix(body[m[1]+length:], []byte("\nendstream")) {
+				t.Fatalf("对象 %d 的流长度错误", num)
+			}
+			if bytes.Contains(body[:m[0]], []byte("/FlateDecode")) {
+				r, err := zlib.NewReader(bytes.NewReader(raw))
+				if err != nil {
+					t.Fatalf("对象 %d 解压失败: %v", num, err)
+				}
+				raw, _ = io.ReadAll(r)
+			}
+			streams[num] = string(raw)
+		}
+	}
+	if len(pdfObjRe.FindAll(data, -1)) != count-1 {
+		t.Fatalf("交叉引用表的对象数量 %d 与文件不一致", count-1)
+	}
+	return objects, streams
+}
+
+func TestParseTrueType(t *testing.T) {
+	font, err := parseTrueType(buildTestFont())
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if font.postScriptName != "TestSans" || font.unitsPerEm != 1000 {
+		t.Errorf("字体信息错误: %s %d", font.postScriptName, font.unitsPerEm)
+	}
+	zhong, fu := font.glyph('中'), font.glyph('复')
+	if zhong == 0 || fu == 0 || font.glyph('A') == 0 || font.glyph('龙') != 0 {
+		t.Fatal("字符映射错误")
+	}
+	if font.advance(font.glyph('A')) != 500 || font.advance(zhong) != 1000 {
+		t.Errorf("字形宽度错误: %d %d", font.advance(font.glyph('A')), font.advance(zhong))
+	}
+
+	// 子集保留组合字形引用的字形，去掉未使用的字形
+	subset, err := parseTrueType(font.subset(map[uint16]rune{fu: '复'}))
+	if err != nil {
+		t.Fatalf("子集字体无法解析: %v", err)
+	}
+	if subset.glyphData(fu) == nil || subset.glyphData(zhong) == nil || subset.glyphData(0) == nil {
+		t.Error("子集缺少使用的字形")
+	}
+	if subset.glyphData(font.glyph('A')) != nil {
+		t.Error("子集包含未使用的字形")
+	}
+	if subset.glyph('复') != fu || subset.glyph('A') != 0 {
+		t.Error("子集的字符映射错误")
+	}
+	if tableChecksum(font.subset(map[uint16]rune{fu: '复'})) != 0xB1B0AFBA {
+		t.Error("字体校验和调整值错误")
+	}
+
+	if _, err := parseTrueType(append([]byte("OTTO"), make([]byte, 20)...)); err == nil || !strings.Contains(err.Error(), "CFF") {
+		t.Errorf("应拒绝CFF字体: %v", err)
+	}
+}
+
+func TestPDF(t *testing.T) {
+	tempDir := t.TempDir()
+	fontPath := writeTestFont(t)
+	outputPath := filepath.Join(tempDir, "部署手册_1.0.1.md")
+	assets := filepath.Join(tempDir, "部署手册_1.0.1.assets")
+	os.MkdirAll(assets, 0755)
+
+	// 带透明度的PNG和JPEG
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	img.Set(0, 0, color.NRGBA{R: 255, A: 128})
+	var pngData, jpegData bytes.Buffer
+	png.Encode(&pngData, img)
+	jpeg.Encode(&jpegData, image.NewRGBA(image.Rect(0, 0, 8, 8)), nil)
+	os.WriteFile(filepath.Join(assets, "a.png"), pngData.Bytes(), 0644)
+	os.WriteFile(filepath.Join(assets, "b.jpg"), jpegData.Bytes(), 0644)
+
+	var md strings.Builder
+	md.WriteString("# 部署手册\n\n## 安装\n\n中文 Text with **bold** and [link](https://example.com).\n\n")
+	md.WriteString("- A\n- B\n  1. C\n\n```\ncode line\n```\n\n> quote\n\n---\n\n")
+	md.WriteString("![图片](./部署手册_1.0.1.assets/a.png)\n\n![](./部署手册_1.0.1.assets/b.jpg)\n\n")
+	md.WriteString("### 参数\n\n| 参数 | 说明 |\n|---|:---:|\n")
+	for i := 0; i < 80; i++ {
+		md.WriteString("| a | b |\n")
+	}
+	md.WriteString("\n## 说明\n\n[参数](#参数) [missing](#missing)\n")
+
+	opts := &PDFOptions{
+		Font:      fontPath,
+		Header:    DefaultPDFHeader,
+		Footer:    DefaultPDFFooter,
+		TOC:       true,
+		Variables: map[string]string{"productName": "Manual", "version": "1.0.1"},
+	}
+	files, err := PDF(outputPath, []byte(md.String()), opts)
+	if err != nil {
+		t.Fatalf("导出失败: %v", err)
+	}
+	if len(files) != 1 || files[0].Path != filepath.Join(tempDir, "部署手册_1.0.1.pdf") {
+		t.Fatalf("导出文件错误: %v", files)
+	}
+	data := files[0].Data
+	objects, streams := readPDF(t, data)
+
+	var pages, outlines, images int
+	var all strings.Builder
+	for num, obj := range objects {
+		all.WriteString(obj)
+		switch {
+		case strings.HasPrefix(obj, "<< /Type /Page "):
+			pages++
+			if _, ok := streams[num]; ok {
+				t.Errorf("页面对象 %d 不应是流", num)
+			}
+		case strings.Contains(obj, "/Parent") && strings.Contains(obj, "/Title"):
+			outlines++
+		case strings.Contains(obj, "/Subtype /Image"):
+			images++
+		}
+	}
+	// 目录1页，正文因长表格至少2页
+	if pages < 3 || !strings.Contains(all.String(), fmt.Sprintf("/Count %d /MediaBox", pages)) {
+		t.Errorf("页数错误: %d", pages)
+	}
+	if outlines != 4 || !strings.Contains(all.String(), "/PageMode /UseOutlines") {
+		t.Errorf("书签数量错误: %d", outlines)
+	}
+	// PNG、透明度通道和JPEG
+	if images != 3 || !strings.Contains(all.String(), "/SMask") || !strings.Contains(all.String(), "/DCTDecode") {
+		t.Errorf("图片嵌入错误: %d", images)
+	}
+	for _, want := range []string{"/FontFile2", "/Identity-H", "+TestSans", "/ToUnicode", "/URI (https://example.com)", "/Dest ["} {
+		if !strings.Contains(all.String(), want) {
+			t.Errorf("PDF缺少 %s", want)
+		}
+	}
+	// #锚点链接解析为文档内跳转，目标不存在时不生成链接：书签4个、目录链接4个、正文链接每个汉字一个区域
+	if strings.Contains(all.String(), "/URI (#") || strings.Count(all.String(), "/Dest [") != 10 {
+		t.Errorf("锚点链接错误: %d", strings.Count(all.String(), "/Dest ["))
+	}
+
+	// 页眉和页码：按ToUnicode映射还原文字
+	font, _ := parseTrueType(buildTestFont())
+	encode := func(s string) string {
+		var b strings.Builder
+		for _, r := range s {
+			fmt.Fprintf(&b, "%04X", font.glyph(r))
+		}
+		return "<" + b.String() + ">"
+	}
+	var content strings.Builder
+	for _, stream := range streams {
+		content.WriteString(stream)
+	}
+	for _, want := range []string{"Manual 1.0.1", fmt.Sprintf("第 1 页 / 共 %d 页", pages), "目录"} {
+		if !strings.Contains(content.String(), encode(want)) {
+			t.Errorf("内容中缺少 %q", want)
+		}
+	}
+
+	// 相同输入生成相同的文件
+	again, _ := PDF(outputPath, []byte(md.String()), opts)
+	if !bytes.Equal(again[0].Data, data) {
+		t.Error("两次导出的PDF不一致")
+	}
+}
+
+func TestPDFOptions(t *testing.T) {
+	if _, err := PDF("manual.md", []byte("# 手册\n"), &PDFOptions{}); err == nil || !strings.Contains(err.Error(), "pdfFont") {
+		t.Errorf("未指定字体时应报错: %v", err)
+	}
+
+	// 字体相对路径基于FontDir查找
+	fontPath := writeTestFont(t)
+	files, err := PDF(filepath.Join(t.TempDir(), "manual.md"), []byte("text\n"), &PDFOptions{
+		Font:    filepath.Base(fontPath),
+		FontDir: filepath.Dir(fontPath),
+	})
+	if err != nil {
+		t.Fatalf("导出失败: %v", err)
+	}
+	objects, _ := readPDF(t, files[0].Data)
+	for _, obj := range objects {
+		if strings.Contains(obj, "/Outlines") {
+			t.Error("没有标题时不应生成书签")
+		}
+	}
+
+	// 字体从模板所在的文件系统读取
+	font, _ := os.ReadFile(fontPath)
+	source := vfs.NewMemFS(map[string]string{"docs/fonts/main.ttf": string(font)})
+	if _, err := PDF("out/manual.md", []byte("text\n"), &PDFOptions{Font: "fonts/main.ttf", FontDir: "docs", SourceFS: source}); err != nil {
+		t.Errorf("从模板文件系统读取字体失败: %v", err)
+	}
+
+	vars := map[string]string{"productName": "eRDCloud-PDM", "version": "3.2.0", "page": "2"}
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{DefaultPDFHeader, "eRDCloud-PDM 3.2.0"},
+		{"{productName} {missing}", "eRDCloud-PDM"},
+		{"- {page} -", "- 2 -"},
+		{"", ""},
+		{"none", ""},
+	}
+	for _, tt := range tests {
+		if got := expandPDFText(tt.pattern, vars); got != tt.want {
+			t.Errorf("expandPDFText(%q) = %q, 期望 %q", tt.pattern, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package export
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // 注册GIF解码器
+	"image/jpeg"
+	_ "image/png" // 注册PNG解码器
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// pdfWriter 按对象编号收集PDF对象并生成文件
+type pdfWriter struct {
+	objects [][]byte // 下标加1为对象编号
+}
+
+// alloc 预留一个对象编号
+func (w *pdfWriter) alloc() int {
+	w.objects = append(w.objects, nil)
+	return len(w.objects)
+}
+
+// set 设置对象内容
+func (w *pdfWriter) set(num int, format string, args ...interface{}) {
+	w.objects[num-1] = []byte(fmt.Sprintf(format, args...))
+}
+
+// add 添加对象，返回对象编号
+func (w *pdfWriter) add(format string, args ...interface{}) int {
+	num := w.alloc()
+	w.set(num, format, args...)
+	return num
+}
+
+// stream 设置流对象，数据使用Flate压缩；dict为除Length和Filter外的字典项
+func (w *pdfWriter) stream(num int, dict string, data []byte) {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(data)
+	zw.Close()
+	w.rawStream(num, dict+" /Filter /FlateDecode", buf.Bytes())
+}
+
+// rawStream 设置流对象，数据原样写入
+func (w *pdfWriter) rawStream(num int, dict string, data []byte) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "<< %s /Length %d >>\nstream\n", strings.TrimSpace(dict), len(data))
+	b.Write(data)
+	b.WriteString("\nendstream")
+	w.objects[num-1] = b.Bytes()
+}
+
+// bytes 生成完整的PDF文件
+func (w *pdfWriter) bytes(root, info int) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.7\n%\xE2\xE3\xCF\xD3\n")
+	offsets := make([]int, len(w.objects))
+	for i, obj := range w.objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n", i+1)
+		b.Write(obj)
+		b.WriteString("\nendobj\n")
+	}
+
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(w.objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(w.objects)+1, root, info, xref)
+	return b.Bytes()
+}
+
+// pdfNum 格式化数值，最多保留两位小数
+func pdfNum(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 2, 64)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" || s == "" {
+		return "0"
+	}
+	return s
+}
+
+// pdfTextString 将文本编码为UTF-16BE字符串，用于书签和文档属性
+func pdfTextString(s string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", u)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// pdfLiteral 转义PDF字面字符串
+func pdfLiteral(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`, "\r", `\r`, "\n", `\n`)
+	return "(" + r.Replace(s) + ")"
+}
+
+// pdfFont 文档使用的字体：嵌入的TrueType字体或内置的Courier
+type pdfFont struct {
+	name   string        // 资源名，如F1
+	tt     *trueTypeFont // 为nil时使用内置Courier
+	used   map[uint16]rune
+	active bool // 页面中使用过该字体
+}
+
+// newPDFFont 创建字体
+func newPDFFont(name string, tt *trueTypeFont) *pdfFont {
+	return &pdfFont{name: name, tt: tt, used: make(map[uint16]rune)}
+}
+
+// has 判断字体是否包含字符
+func (f *pdfFont) has(r rune) bool {
+	if f.tt == nil {
+		return r >= 32 && r < 127
+	}
+	return f.tt.glyph(r) != 0
+}
+
+// width 返回字符在指定字号下的宽度
+func (f *pdfFont) width(r rune, size float64) float64 {
+	if f.tt == nil {
+		return 600 * size / 1000
+	}
+	return float64(f.tt.advance(f.tt.glyph(r))) * size / 1000
+}
+
+// encode 将文本编码为PDF字符串操作数，并记录使用的字形
+func (f *pdfFont) encode(text string) string {
+	f.active = true
+	if f.tt == nil {
+		return pdfLiteral(text)
+	}
+	var b strings.Builder
+	b.WriteString("<")
+	for _, r := range text {
+		gid := f.tt.glyph(r)
+		if _, ok := f.used[gid]; !ok || gid == 0 {
+			f.used[gid] = r
+		}
+		fmt.Fprintf(&b, "%04X", gid)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// write 写入字体对象，返回字体字典的对象编号
+func (f *pdfFont) write(w *pdfWriter) int {
+	if f.tt == nil {
+		return w.add("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	}
+	font := f.tt
+
+	gids := make([]int, 0, len(f.used))
+	for gid := range f.used {
+		gids = append(gids, int(gid))
+	}
+	sort.Ints(gids)
+
+	// 子集字体名前缀由已用字形决定，相同内容生成相同的PDF
+	hash := sha1.New()
+	for _, gid := range gids {
+		fmt.Fprintf(hash, "%d,", gid)
+	}
+	var tag strings.Builder
+	for _, c := range hash.Sum(nil)[:6] {
+		tag.WriteByte('A' + c%26)
+	}
+	baseName := tag.String() + "+" + font.postScriptName
+
+	fontFile := w.alloc()
+	w.stream(fontFile, "", font.subset(f.used))
+
+	descriptor := w.add("<< /Type /FontDescriptor /FontName /%s /Flags 4 /FontBBox [%d %d %d %d] /ItalicAngle 0 "+
+		"/Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		baseName, font.scale(font.bbox[0]), font.scale(font.bbox[1]), font.scale(font.bbox[2]), font.scale(font.bbox[3]),
+		font.scale(font.ascent), font.scale(font.descent), font.scale(font.capHeight), fontFile)
+
+	var widths strings.Builder
+	for _, gid := range gids {
+		fmt.Fprintf(&widths, "%d [%d] ", gid, font.advance(uint16(gid)))
+	}
+	cidFont := w.add("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s "+
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> "+
+		"/FontDescriptor %d 0 R /DW 1000 /W [%s] /CIDToGIDMap /Identity >>",
+		baseName, descriptor, strings.TrimSpace(widths.String()))
+
+	toUnicode := w.alloc()
+	w.stream(toUnicode, "", f.toUnicode(gids))
+
+	return w.add("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H "+
+		"/DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>", baseName, cidFont, toUnicode)
+}
+
+// toUnicode 生成字形到Unicode的映射，使PDF中的文字可以复制和搜索
+func (f *pdfFont) toUnicode(gids []int) []byte {
+	var b bytes.Buffer
+	b.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n" +
+		"/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+
+	var entries []int
+	for _, gid := range gids {
+		if gid != 0 {
+			entries = append(entries, gid)
+		}
+	}
+	for start := 0; start < len(entries); start += 100 {
+		end := start + 100
+		if end > len(entries) {
+			end = len(entries)
+		}
+		fmt.Fprintf(&b, "%d beginbfchar\n", end-start)
+		for _, gid := range entries[start:end] {
+			fmt.Fprintf(&b, "<%04X> <", gid)
+			for _, u := range utf16.Encode([]rune{f.used[uint16(gid)]}) {
+				fmt.Fprintf(&b, "%04X", u)
+			}
+			b.WriteString(">\n")
+		}
+		b.WriteString("endbfchar\n")
+	}
+	b.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return b.Bytes()
+}
+
+// pdfImage 嵌入的图片
+type pdfImage struct {
+	name          string // 资源名，如Im1
+	width, height int
+	colorSpace    string
+	filter        string // 为空时数据需要压缩
+	data          []byte
+	alpha         []byte // 透明度通道，为nil表示不透明
+}
+
+// newPDFImage 解析图片：JPEG原样嵌入，其他格式解码后转为RGB
+func newPDFImage(name string, data []byte) (*pdfImage, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("PDF不支持的图片格式")
+	}
+	if format == "jpeg" && (config.ColorModel == color.YCbCrModel || config.ColorModel == color.GrayModel) {
+		colorSpace := "/DeviceRGB"
+		if config.ColorModel == color.GrayModel {
+			colorSpace = "/DeviceGray"
+		}
+		return &pdfImage{name: name, width: config.Width, height: config.Height,
+			colorSpace: colorSpace, filter: "/DCTDecode", data: data}, nil
+	}
+
+	var img image.Image
+	if format == "jpeg" {
+		img, err = jpeg.Decode(bytes.NewReader(data))
+	} else {
+		img, _, err = image.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解码图片失败: %v", err)
+	}
+
+	bounds := img.Bounds()
+	result := &pdfImage{name: name, width: bounds.Dx(), height: bounds.Dy(), colorSpace: "/DeviceRGB"}
+	rgb := make([]byte, 0, result.width*result.height*3)
+	alpha := make([]byte, 0, result.width*result.height)
+	opaque := true
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			rgb = append(rgb, c.R, c.G, c.B)
+			alpha = append(alpha, c.A)
+			if c.A != 0xFF {
+				opaque = false
+			}
+		}
+	}
+	result.data = rgb
+	if !opaque {
+		result.alpha = alpha
+	}
+	return result, nil
+}
+
+// write 写入图片对象，返回对象编号
+func (img *pdfImage) write(w *pdfWriter) int {
+	dict := fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /BitsPerComponent 8",
+		img.width, img.height)
+	if img.alpha != nil {
+		mask := w.alloc()
+		w.stream(mask, dict+" /ColorSpace /DeviceGray", img.alpha)
+		dict += fmt.Sprintf(" /SMask %d 0 R", mask)
+	}
+
+	num := w.alloc()
+	dict += " /ColorSpace " + img.colorSpace
+	if img.filter != "" {
+		w.rawStream(num, dict+" /Filter "+img.filter, img.data)
+	} else {
+		w.stream(num, dict, img.data)
+	}
+	return num
+}
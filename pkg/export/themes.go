@@ -0,0 +1,102 @@
+package export
+
+import (
+	"fmt"
+	"io/fs"
+	"md-manual-tool/pkg/vfs"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultTheme 默认HTML主题
+const DefaultTheme = "default"
+
+// builtinThemes 内置HTML主题
+var builtinThemes = map[string]string{
+	"default": defaultThemeCSS,
+	"github":  githubThemeCSS,
+}
+
+// LoadTheme 加载HTML主题：内置主题名或从fsys读取的CSS文件，相对路径依次基于baseDir和fsys的根目录
+// （本地文件系统为当前目录）查找；fsys为nil时使用本地文件系统
+func LoadTheme(fsys fs.FS, theme, baseDir string) (string, error) {
+	if theme == "" {
+		theme = DefaultTheme
+	}
+	if css, ok := builtinThemes[theme]; ok {
+		return strings.TrimSpace(css) + "\n", nil
+	}
+	if css, err := vfs.ReadFile(readFS(fsys), ThemeFile(fsys, theme, baseDir)); err == nil {
+		return string(css), nil
+	}
+	return "", fmt.Errorf("找不到HTML主题 %s（内置主题: %s，或指定CSS文件路径）", theme, strings.Join(ThemeNames(), ", "))
+}
+
+// ThemeFile 返回主题对应的CSS文件路径，内置主题返回空字符串；相对路径优先相对于baseDir查找
+func ThemeFile(fsys fs.FS, theme, baseDir string) string {
+	if theme == "" {
+		theme = DefaultTheme
+	}
+	if _, ok := builtinThemes[theme]; ok {
+		return ""
+	}
+	if !filepath.IsAbs(theme) && baseDir != "" {
+		candidate := filepath.Join(baseDir, theme)
+		if _, err := vfs.Stat(readFS(fsys), candidate); err == nil {
+			return candidate
+		}
+	}
+	return theme
+}
+
+// ThemeNames 返回内置主题名
+func ThemeNames() []string {
+	names := make([]string, 0, len(builtinThemes))
+	for name := range builtinThemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// defaultThemeCSS 默认主题：适合阅读和打印的手册样式
+const defaultThemeCSS = `
+body { margin: 0; background: #fff; color: #222; font-family: -apple-system, "Segoe UI", "PingFang SC", "Microsoft YaHei", "Noto Sans CJK SC", sans-serif; font-size: 15px; line-height: 1.7; }
+main { max-width: 900px; margin: 0 auto; padding: 32px; }
+h1, h2, h3, h4, h5, h6 { margin: 1.4em 0 .6em; line-height: 1.3; }
+h1 { font-size: 2em; border-bottom: 2px solid #2f5597; padding-bottom: .3em; }
+h2 { font-size: 1.5em; border-bottom: 1px solid #d9d9d9; padding-bottom: .3em; }
+a { color: #2f5597; }
+img { max-width: 100%; }
+code { background: #f3f4f6; padding: .15em .4em; border-radius: 3px; font-family: Consolas, "Courier New", monospace; font-size: .9em; }
+pre { background: #f3f4f6; padding: 14px 16px; overflow: auto; border-radius: 4px; line-height: 1.45; }
+pre code { background: none; padding: 0; }
+blockquote { margin: 1em 0; padding: .2em 1em; color: #555; background: #f8f9fb; border-left: 4px solid #2f5597; }
+table { border-collapse: collapse; margin: 1em 0; width: 100%; }
+th, td { border: 1px solid #c8c8c8; padding: 6px 12px; }
+th { background: #eef2f8; }
+hr { border: 0; border-top: 1px solid #d9d9d9; margin: 2em 0; }
+.error { color: #c00; white-space: pre-wrap; }
+@media print { main { max-width: none; padding: 0; } pre { white-space: pre-wrap; } }
+`
+
+// githubThemeCSS 仿GitHub风格主题
+const githubThemeCSS = `
+body { margin: 0; background: #fff; color: #24292f; font-family: -apple-system, "Segoe UI", "Noto Sans", Helvetica, Arial, "PingFang SC", "Microsoft YaHei", sans-serif; font-size: 16px; line-height: 1.5; }
+main { max-width: 980px; margin: 0 auto; padding: 45px; }
+h1, h2, h3, h4, h5, h6 { margin-top: 24px; margin-bottom: 16px; font-weight: 600; line-height: 1.25; }
+h1, h2 { border-bottom: 1px solid #d0d7de; padding-bottom: .3em; }
+a { color: #0969da; text-decoration: none; }
+img { max-width: 100%; }
+code { background: rgba(175, 184, 193, .2); padding: .2em .4em; border-radius: 6px; font-family: ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; font-size: 85%; }
+pre { background: #f6f8fa; padding: 16px; overflow: auto; border-radius: 6px; line-height: 1.45; }
+pre code { background: none; padding: 0; font-size: 85%; }
+blockquote { margin: 0 0 16px; padding: 0 1em; color: #57606a; border-left: .25em solid #d0d7de; }
+table { border-collapse: collapse; margin-bottom: 16px; }
+th, td { border: 1px solid #d0d7de; padding: 6px 13px; }
+th { font-weight: 600; }
+tr:nth-child(2n) { background: #f6f8fa; }
+hr { height: .25em; padding: 0; margin: 24px 0; background: #d0d7de; border: 0; }
+.error { color: #cf222e; white-space: pre-wrap; }
+`
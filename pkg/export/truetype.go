@@ -0,0 +1,526 @@
+package export
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/fs"
+	"md-manual-tool/pkg/vfs"
+	"sort"
+	"strings"
+)
+
+// trueTypeFont 解析后的TrueType字体，用于PDF嵌入
+type trueTypeFont struct {
+	tables         map[string][]byte
+	unitsPerEm     int
+	ascent         int
+	descent        int
+	capHeight      int
+	bbox           [4]int
+	numGlyphs      int
+	advances       []uint16        // 每个字形的宽度
+	cmap           map[rune]uint16 // 字符 -> 字形编号
+	postScriptName string
+}
+
+// loadTrueType 从fsys读取TrueType字体文件（.ttf或.ttc中的第一个字体）
+func loadTrueType(fsys fs.FS, path string) (*trueTypeFont, error) {
+	data, err := vfs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, fmt.Errorf("读取字体文件失败: %v", err)
+	}
+	font, err := parseTrueType(data)
+	if err != nil {
+		return nil, fmt.Errorf("解析字体文件 %s 失败: %v", path, err)
+	}
+	return font, nil
+}
+
+// parseTrueType 解析TrueType字体
+func parseTrueType(data []byte) (*trueTypeFont, error) {
+	if len(data) < 12 {
+		return nil, fmt.Errorf("文件过小")
+	}
+	offset := 0
+	if string(data[:4]) == "ttcf" {
+		// 字体集合：使用第一个字体
+		offset = int(binary.BigEndian.Uint32(data[12:16]))
+		if offset+12 > len(data) {
+			return nil, fmt.Errorf("字体集合已损坏")
+		}
+	}
+	switch string(data[offset : offset+4]) {
+	case "\x00\x01\x00\x00", "true":
+	case "OTTO":
+		return nil, fmt.Errorf("不支持CFF轮廓的OpenType字体(.otf)，请使用TrueType字体(.ttf/.ttc)")
+	default:
+		return nil, fmt.Errorf("不是TrueType字体")
+	}
+
+	font := &trueTypeFont{tables: make(map[string][]byte)}
+	numTables := int(binary.BigEndian.Uint16(data[offset+4:]))
+	for i := 0; i < numTables; i++ {
+		record := offset + 12 + i*16
+		if record+16 > len(data) {
+			return nil, fmt.Errorf("表目录已损坏")
+		}
+		tag := string(data[record : record+4])
+		start := int(binary.BigEndian.Uint32(data[record+8:]))
+		length := int(binary.BigEndian.Uint32(data[record+12:]))
+		if start < 0 || length < 0 || start+length > len(data) {
+			return nil, fmt.Errorf("表 %s 超出文件范围", tag)
+		}
+		font.tables[tag] = data[start : start+length]
+	}
+	for _, tag := range []string{"head", "hhea", "maxp", "hmtx", "loca", "glyf", "cmap"} {
+		if _, ok := font.tables[tag]; !ok {
+			return nil, fmt.Errorf("缺少必需的表 %s", tag)
+		}
+	}
+
+	head := font.tables["head"]
+	hhea := font.tables["hhea"]
+	maxp := font.tables["maxp"]
+	if len(head) < 54 || len(hhea) < 36 || len(maxp) < 6 {
+		return nil, fmt.Errorf("字体头信息已损坏")
+	}
+	font.unitsPerEm = int(binary.BigEndian.Uint16(head[18:]))
+	if font.unitsPerEm == 0 {
+		return nil, fmt.Errorf("unitsPerEm无效")
+	}
+	for i := range font.bbox {
+		font.bbox[i] = int(int16(binary.BigEndian.Uint16(head[36+i*2:])))
+	}
+	font.ascent = int(int16(binary.BigEndian.Uint16(hhea[4:])))
+	font.descent = int(int16(binary.BigEndian.Uint16(hhea[6:])))
+	font.capHeight = font.ascent
+	if os2 := font.tables["OS/2"]; len(os2) >= 90 && binary.BigEndian.Uint16(os2) >= 2 {
+		font.capHeight = int(int16(binary.BigEndian.Uint16(os2[88:])))
+	}
+	font.numGlyphs = int(binary.BigEndian.Uint16(maxp[4:]))
+
+	if err := font.parseHmtx(); err != nil {
+		return nil, err
+	}
+	if err := font.parseCmap(); err != nil {
+		return nil, err
+	}
+	font.postScriptName = font.parseName()
+	return font, nil
+}
+
+// parseHmtx 读取字形宽度
+func (f *trueTypeFont) parseHmtx() error {
+	hmtx := f.tables["hmtx"]
+	numMetrics := int(binary.BigEndian.Uint16(f.tables["hhea"][34:]))
+	if numMetrics == 0 || len(hmtx) < numMetrics*4 {
+		return fmt.Errorf("hmtx表已损坏")
+	}
+	f.advances = make([]uint16, f.numGlyphs)
+	for i := 0; i < f.numGlyphs; i++ {
+		if i < numMetrics {
+			f.advances[i] = binary.BigEndian.Uint16(hmtx[i*4:])
+		} else {
+			f.advances[i] = f.advances[numMetrics-1]
+		}
+	}
+	return nil
+}
+
+// parseCmap 读取Unicode字符映射，优先使用完整Unicode的格式12子表
+func (f *trueTypeFont) parseCmap() error {
+	cmap := f.tables["cmap"]
+	if len(cmap) < 4 {
+		return fmt.Errorf("cmap表已损坏")
+	}
+	var format4, format12 []byte
+	numTables := int(binary.BigEndian.Uint16(cmap[2:]))
+	for i := 0; i < numTables; i++ {
+		record := 4 + i*8
+		if record+8 > len(cmap) {
+			break
+		}
+		platform := binary.BigEndian.Uint16(cmap[record:])
+		encoding := binary.BigEndian.Uint16(cmap[record+2:])
+		offset := int(binary.BigEndian.Uint32(cmap[record+4:]))
+		if offset+4 > len(cmap) || !(platform == 0 || (platform == 3 && (encoding == 1 || encoding == 10))) {
+			continue
+		}
+		subtable := cmap[offset:]
+		switch binary.BigEndian.Uint16(subtable) {
+		case 4:
+			format4 = subtable
+		case 12:
+			format12 = subtable
+		}
+	}
+
+	f.cmap = make(map[rune]uint16)
+	switch {
+	case format12 != nil:
+		return f.parseCmap12(format12)
+	case format4 != nil:
+		return f.parseCmap4(format4)
+	}
+	return fmt.Errorf("没有Unicode字符映射表")
+}
+
+// parseCmap4 解析格式4（基本多文种平面）字符映射
+func (f *trueTypeFont) parseCmap4(t []byte) error {
+	if len(t) < 14 {
+		return fmt.Errorf("cmap格式4已损坏")
+	}
+	segCount := int(binary.BigEndian.Uint16(t[6:])) / 2
+	endCodes := 14
+	startCodes := endCodes + segCount*2 + 2
+	deltas := startCodes + segCount*2
+	rangeOffsets := deltas + segCount*2
+	if rangeOffsets+segCount*2 > len(t) {
+		return fmt.Errorf("cmap格式4已损坏")
+	}
+	for seg := 0; seg < segCount; seg++ {
+		end := int(binary.BigEndian.Uint16(t[endCodes+seg*2:]))
+		start := int(binary.BigEndian.Uint16(t[startCodes+seg*2:]))
+		delta := int(binary.BigEndian.Uint16(t[deltas+seg*2:]))
+		rangeOffset := int(binary.BigEndian.Uint16(t[rangeOffsets+seg*2:]))
+		for c := start; c <= end && c != 0xFFFF; c++ {
+			var gid int
+			if rangeOffset == 0 {
+				gid = (c + delta) & 0xFFFF
+			} else {
+				addr := rangeOffsets + seg*2 + rangeOffset + (c-start)*2
+				if addr+2 > len(t) {
+					continue
+				}
+				gid = int(binary.BigEndian.Uint16(t[addr:]))
+				if gid != 0 {
+					gid = (gid + delta) & 0xFFFF
+				}
+			}
+			if gid != 0 && gid < f.numGlyphs {
+				f.cmap[rune(c)] = uint16(gid)
+			}
+		}
+	}
+	return nil
+}
+
+// parseCmap12 解析格式12（完整Unicode）字符映射
+func (f *trueTypeFont) parseCmap12(t []byte) error {
+	if len(t) < 16 {
+		return fmt.Errorf("cmap格式12已损坏")
+	}
+	groups := int(binary.BigEndian.Uint32(t[12:]))
+	if 16+groups*12 > len(t) {
+		return fmt.Errorf("cmap格式12已损坏")
+	}
+	for i := 0; i < groups; i++ {
+		group := t[16+i*12:]
+		start := binary.BigEndian.Uint32(group)
+		end := binary.BigEndian.Uint32(group[4:])
+		gid := binary.BigEndian.Uint32(group[8:])
+		for c := start; c <= end && c <= 0x10FFFF; c++ {
+			if g := gid + (c - start); g != 0 && int(g) < f.numGlyphs {
+				f.cmap[rune(c)] = uint16(g)
+			}
+		}
+	}
+	return nil
+}
+
+// parseName 读取PostScript名称，只保留PDF名称允许的字符
+func (f *trueTypeFont) parseName() string {
+	name := f.tables["name"]
+	result := ""
+	if len(name) >= 6 {
+		count := int(binary.BigEndian.Uint16(name[2:]))
+		storage := int(binary.BigEndian.Uint16(name[4:]))
+		for i := 0; i < count && 6+i*12+12 <= len(name); i++ {
+			record := name[6+i*12:]
+			platform := binary.BigEndian.Uint16(record)
+			nameID := binary.BigEndian.Uint16(record[6:])
+			length := int(binary.BigEndian.Uint16(record[8:]))
+			offset := storage + int(binary.BigEndian.Uint16(record[10:]))
+			if nameID != 6 || offset+length > len(name) {
+				continue
+			}
+			value := name[offset : offset+length]
+			if platform == 3 || platform == 0 {
+				var b strings.Builder
+				for j := 0; j+1 < len(value); j += 2 {
+					b.WriteByte(value[j+1])
+				}
+				result = b.String()
+			} else {
+				result = string(value)
+			}
+			break
+		}
+	}
+
+	var b strings.Builder
+	for _, r := range result {
+		if r > 32 && r < 127 && !strings.ContainsRune("()<>[]{}/%#", r) {
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() == 0 {
+		return "EmbeddedFont"
+	}
+	return b.String()
+}
+
+// glyph 返回字符对应的字形编号，字体中没有该字符时返回0
+func (f *trueTypeFont) glyph(r rune) uint16 {
+	return f.cmap[r]
+}
+
+// advance 返回字形宽度（千分之一字号）
+func (f *trueTypeFont) advance(gid uint16) int {
+	if int(gid) >= len(f.advances) {
+		return 0
+	}
+	return int(f.advances[gid]) * 1000 / f.unitsPerEm
+}
+
+// scale 将字体单位换算为千分之一字号
+func (f *trueTypeFont) scale(v int) int {
+	return v * 1000 / f.unitsPerEm
+}
+
+// glyphData 返回字形轮廓数据
+func (f *trueTypeFont) glyphData(gid uint16) []byte {
+	loca := f.tables["loca"]
+	glyf := f.tables["glyf"]
+	longLoca := binary.BigEndian.Uint16(f.tables["head"][50:]) == 1
+	var start, end int
+	if longLoca {
+		if int(gid)*4+8 > len(loca) {
+			return nil
+		}
+		start = int(binary.BigEndian.Uint32(loca[gid*4:]))
+		end = int(binary.BigEndian.Uint32(loca[gid*4+4:]))
+	} else {
+		if int(gid)*2+4 > len(loca) {
+			return nil
+		}
+		start = int(binary.BigEndian.Uint16(loca[gid*2:])) * 2
+		end = int(binary.BigEndian.Uint16(loca[gid*2+2:])) * 2
+	}
+	if start >= end || end > len(glyf) {
+		return nil
+	}
+	return glyf[start:end]
+}
+
+// components 返回组合字形引用的字形
+func components(glyph []byte) []uint16 {
+	if len(glyph) < 10 || int16(binary.BigEndian.Uint16(glyph)) >= 0 {
+		return nil
+	}
+	var result []uint16
+	for pos := 10; pos+4 <= len(glyph); {
+		flags := binary.BigEndian.Uint16(glyph[pos:])
+		result = append(result, binary.BigEndian.Uint16(glyph[pos+2:]))
+		pos += 4
+		if flags&0x0001 != 0 {
+			pos += 4
+		} else {
+			pos += 2
+		}
+		switch {
+		case flags&0x0008 != 0:
+			pos += 2
+		case flags&0x0040 != 0:
+			pos += 4
+		case flags&0x0080 != 0:
+			pos += 8
+		}
+		if flags&0x0020 == 0 {
+			break
+		}
+	}
+	return result
+}
+
+// subset 生成只包含已用字形轮廓的字体，字形编号保持不变；used为字形编号到字符的映射
+func (f *trueTypeFont) subset(used map[uint16]rune) []byte {
+	keep := map[uint16]bool{0: true}
+	pending := make([]uint16, 0, len(used))
+	for gid := range used {
+		pending = append(pending, gid)
+	}
+	for len(pending) > 0 {
+		gid := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if keep[gid] && gid != 0 {
+			continue
+		}
+		keep[gid] = true
+		for _, component := range components(f.glyphData(gid)) {
+			if !keep[component] {
+				pending = append(pending, component)
+			}
+		}
+	}
+
+	var glyf []byte
+	loca := make([]byte, (f.numGlyphs+1)*4)
+	for gid := 0; gid < f.numGlyphs; gid++ {
+		binary.BigEndian.PutUint32(loca[gid*4:], uint32(len(glyf)))
+		if keep[uint16(gid)] {
+			glyf = append(glyf, f.glyphData(uint16(gid))...)
+			for len(glyf)%4 != 0 {
+				glyf = append(glyf, 0)
+			}
+		}
+	}
+	binary.BigEndian.PutUint32(loca[f.numGlyphs*4:], uint32(len(glyf)))
+
+	head := append([]byte(nil), f.tables["head"]...)
+	binary.BigEndian.PutUint32(head[8:], 0)  // checkSumAdjustment稍后计算
+	binary.BigEndian.PutUint16(head[50:], 1) // 使用长格式loca
+
+	tables := map[string][]byte{
+		"head": head,
+		"hhea": f.tables["hhea"],
+		"maxp": f.tables["maxp"],
+		"hmtx": f.tables["hmtx"],
+		"loca": loca,
+		"glyf": glyf,
+		"cmap": cmapFormat4(used),
+		"post": postFormat3(f.tables["post"]),
+	}
+	for _, tag := range []string{"cvt ", "fpgm", "prep"} {
+		if table, ok := f.tables[tag]; ok {
+			tables[tag] = table
+		}
+	}
+	font := buildSfnt(tables)
+
+	// 整个字体的校验和调整值
+	adjustment := 0xB1B0AFBA - tableChecksum(font)
+	headOffset := sfntTableOffset(font, "head")
+	binary.BigEndian.PutUint32(font[headOffset+8:], adjustment)
+	return font
+}
+
+// cmapFormat4 生成只包含已用字符的cmap表（Windows Unicode，格式4），每个字符一个分段
+func cmapFormat4(used map[uint16]rune) []byte {
+	chars := make(map[int]uint16)
+	for gid, r := range used {
+		if gid != 0 && r > 0 && r < 0xFFFF {
+			chars[int(r)] = gid
+		}
+	}
+	codes := make([]int, 0, len(chars))
+	for c := range chars {
+		codes = append(codes, c)
+	}
+	sort.Ints(codes)
+
+	segCount := len(codes) + 1
+	entrySelector := 0
+	for 1<<(entrySelector+1) <= segCount {
+		entrySelector++
+	}
+	searchRange := (1 << entrySelector) * 2
+
+	length := 16 + segCount*8
+	t := make([]byte, 12+length)
+	binary.BigEndian.PutUint16(t[2:], 1)  // 子表数量
+	binary.BigEndian.PutUint16(t[4:], 3)  // Windows平台
+	binary.BigEndian.PutUint16(t[6:], 1)  // Unicode BMP编码
+	binary.BigEndian.PutUint32(t[8:], 12) // 子表偏移
+	sub := t[12:]
+	binary.BigEndian.PutUint16(sub, 4)
+	binary.BigEndian.PutUint16(sub[2:], uint16(length))
+	binary.BigEndian.PutUint16(sub[6:], uint16(segCount*2))
+	binary.BigEndian.PutUint16(sub[8:], uint16(searchRange))
+	binary.BigEndian.PutUint16(sub[10:], uint16(entrySelector))
+	binary.BigEndian.PutUint16(sub[12:], uint16(segCount*2-searchRange))
+
+	endCodes := sub[14:]
+	startCodes := sub[16+segCount*2:]
+	deltas := sub[16+segCount*4:]
+	for i, c := range codes {
+		binary.BigEndian.PutUint16(endCodes[i*2:], uint16(c))
+		binary.BigEndian.PutUint16(startCodes[i*2:], uint16(c))
+		binary.BigEndian.PutUint16(deltas[i*2:], uint16(int(chars[c])-c))
+	}
+	// 结束分段
+	last := len(codes) * 2
+	binary.BigEndian.PutUint16(endCodes[last:], 0xFFFF)
+	binary.BigEndian.PutUint16(startCodes[last:], 0xFFFF)
+	binary.BigEndian.PutUint16(deltas[last:], 1)
+	return t
+}
+
+// postFormat3 生成不含字形名称的post表，沿用原字体的斜体角度等信息
+func postFormat3(original []byte) []byte {
+	post := make([]byte, 32)
+	copy(post, original)
+	binary.BigEndian.PutUint32(post, 0x00030000)
+	return post
+}
+
+// buildSfnt 将表组装为TrueType字体文件
+func buildSfnt(tables map[string][]byte) []byte {
+	tags := make([]string, 0, len(tables))
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	numTables := len(tags)
+	entrySelector := 0
+	for 1<<(entrySelector+1) <= numTables {
+		entrySelector++
+	}
+	searchRange := (1 << entrySelector) * 16
+
+	header := make([]byte, 12+16*numTables)
+	binary.BigEndian.PutUint32(header, 0x00010000)
+	binary.BigEndian.PutUint16(header[4:], uint16(numTables))
+	binary.BigEndian.PutUint16(header[6:], uint16(searchRange))
+	binary.BigEndian.PutUint16(header[8:], uint16(entrySelector))
+	binary.BigEndian.PutUint16(header[10:], uint16(numTables*16-searchRange))
+
+	var body []byte
+	for i, tag := range tags {
+		table := tables[tag]
+		record := header[12+i*16:]
+		copy(record, tag)
+		binary.BigEndian.PutUint32(record[4:], tableChecksum(table))
+		binary.BigEndian.PutUint32(record[8:], uint32(len(header)+len(body)))
+		binary.BigEndian.PutUint32(record[12:], uint32(len(table)))
+		body = append(body, table...)
+		for len(body)%4 != 0 {
+			body = append(body, 0)
+		}
+	}
+	return append(header, body...)
+}
+
+// sfntTableOffset 返回字体文件中表的偏移
+func sfntTableOffset(font []byte, tag string) int {
+	numTables := int(binary.BigEndian.Uint16(font[4:]))
+	for i := 0; i < numTables; i++ {
+		record := font[12+i*16:]
+		if string(record[:4]) == tag {
+			return int(binary.BigEndian.Uint32(record[8:]))
+		}
+	}
+	return 0
+}
+
+// tableChecksum 计算TrueType表校验和
+func tableChecksum(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(data); i += 4 {
+		var word [4]byte
+		copy(word[:], data[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
@@ -0,0 +1,124 @@
+// Package i18n 多语言手册的消息目录
+//
+// 每种语言一个目录文件，如 i18n/zh-CN.yaml、i18n/en-US.yaml，格式与配置文件相同：每行一个
+// "键: 译文"，以 # 开头的行为注释；双引号中的译文支持 \n 等转义，单引号原样保留。
+// 模板中通过 {{t "install.title"}} 引用。
+//
+// 工具自身的界面消息同样使用消息目录，内置在 locales 目录中，见 Msg 和 SetLocale。
+package i18n
+
+import (
+	"fmt"
+	"io/fs"
+	"md-manual-tool/pkg/vfs"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultDir 消息目录的默认位置，相对于配置文件所在目录
+const DefaultDir = "i18n"
+
+// langPattern 语言标签，如 zh-CN、en-US、ja
+var langPattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// Catalog 一种语言的消息目录
+type Catalog struct {
+	Lang     string
+	Messages map[string]string
+}
+
+// ValidateLang 检查语言标签，语言标签同时用于目录文件名和输出文件名
+func ValidateLang(lang string) error {
+	if !langPattern.MatchString(lang) {
+		return fmt.Errorf("无效的语言标签: %q（如 zh-CN、en-US）", lang)
+	}
+	return nil
+}
+
+// CatalogPath 返回语言在目录dir中的消息目录文件
+func CatalogPath(dir, lang string) string {
+	return filepath.Join(dir, lang+".yaml")
+}
+
+// LoadCatalog 从fsys读取语言lang在目录dir中的消息目录
+func LoadCatalog(fsys fs.FS, dir, lang string) (*Catalog, error) {
+	if err := ValidateLang(lang); err != nil {
+		return nil, err
+	}
+	path := CatalogPath(dir, lang)
+	data, err := vfs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, fmt.Errorf("读取消息目录失败: %v", err)
+	}
+	catalog, err := ParseCatalog(lang, data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return catalog, nil
+}
+
+// ParseCatalog 解析消息目录内容，重复的键视为错误
+func ParseCatalog(lang string, data []byte) (*Catalog, error) {
+	catalog := &Catalog{Lang: lang, Messages: make(map[string]string)}
+	for i, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		parts := strings.SplitN(trimmed, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("第 %d 行格式错误，应为 \"键: 译文\": %s", i+1, trimmed)
+		}
+		key := strings.TrimSpace(parts[0])
+		if _, exists := catalog.Messages[key]; exists {
+			return nil, fmt.Errorf("第 %d 行重复的键: %s", i+1, key)
+		}
+		catalog.Messages[key] = unquote(strings.TrimSpace(parts[1]))
+	}
+	return catalog, nil
+}
+
+// T 返回键对应的译文，有参数时按fmt格式化（如 "共 %d 页"）；缺少译文时返回错误
+func (c *Catalog) T(key string, args ...interface{}) (string, error) {
+	message, ok := c.Messages[key]
+	if !ok {
+		return "", fmt.Errorf("消息目录 %s 中缺少 %s", c.Lang, key)
+	}
+	if len(args) > 0 {
+		message = fmt.Sprintf(message, args...)
+	}
+	return message, nil
+}
+
+// Keys 按顺序返回所有键
+func (c *Catalog) Keys() []string {
+	keys := make([]string, 0, len(c.Messages))
+	for key := range c.Messages {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// LangPath 在文件名的扩展名前加上语言后缀，用于输出文件和语言覆盖配置，
+// 如 手册_1.1.0.md -> 手册_1.1.0.en-US.md，config.yaml -> config.en-US.yaml
+func LangPath(path, lang string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + lang + ext
+}
+
+// unquote 去掉译文两侧成对的引号，双引号中的转义（如 \n）被展开
+func unquote(value string) string {
+	if len(value) < 2 || (value[0] != '"' && value[0] != '\'') || value[len(value)-1] != value[0] {
+		return value
+	}
+	if value[0] == '"' {
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			return unquoted
+		}
+	}
+	return value[1 : len(value)-1]
+}
@@ -0,0 +1,70 @@
+package i18n
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseCatalog(t *testing.T) {
+	catalog, err := ParseCatalog("en-US", []byte(`# 安装章节
+install.title: Installation
+install.note: "Note: run as administrator"
+pages: 'Total %d pages'
+error: "Error:\t%v\n"
+`))
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	tests := []struct {
+		key  string
+		args []interface{}
+		want string
+	}{
+		{"install.title", nil, "Installation"},
+		{"install.note", nil, "Note: run as administrator"},
+		{"pages", []interface{}{12}, "Total 12 pages"},
+		{"error", []interface{}{"x"}, "Error:\tx\n"},
+	}
+	for _, tt := range tests {
+		if got, err := catalog.T(tt.key, tt.args...); err != nil || got != tt.want {
+			t.Errorf("T(%s) = %q, %v；期望 %q", tt.key, got, err, tt.want)
+		}
+	}
+	if _, err := catalog.T("install.missing"); err == nil || !strings.Contains(err.Error(), "en-US") {
+		t.Errorf("缺少译文应报错并指明语言: %v", err)
+	}
+
+	for _, bad := range []string{"a: 1\na: 2", "没有冒号", ": 空键"} {
+		if _, err := ParseCatalog("zh-CN", []byte(bad)); err == nil {
+			t.Errorf("应拒绝 %q", bad)
+		}
+	}
+}
+
+func TestLoadCatalog(t *testing.T) {
+	fsys := fstest.MapFS{"i18n/zh-CN.yaml": {Data: []byte("install.title: 安装\n")}}
+	catalog, err := LoadCatalog(fsys, "i18n", "zh-CN")
+	if err != nil || catalog.Messages["install.title"] != "安装" {
+		t.Fatalf("读取消息目录失败: %v %v", catalog, err)
+	}
+	if _, err := LoadCatalog(fsys, "i18n", "en-US"); err == nil {
+		t.Error("消息目录不存在时应报错")
+	}
+	if _, err := LoadCatalog(fsys, "i18n", "../zh-CN"); err == nil {
+		t.Error("应拒绝无效的语言标签")
+	}
+}
+
+func TestLangPath(t *testing.T) {
+	tests := map[string]string{
+		"output/手册_1.1.0.md": "output/手册_1.1.0.en-US.md",
+		"config.yaml":        "config.en-US.yaml",
+		"README":             "README.en-US",
+	}
+	for input, expected := range tests {
+		if got := LangPath(input, "en-US"); got != expected {
+			t.Errorf("LangPath(%q) = %q, 期望 %q", input, got, expected)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package i18n
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"md-manual-tool/pkg/constants"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultLocale 工具界面的默认语言
+const DefaultLocale = "zh-CN"
+
+// localeDir 内置界面消息目录所在的目录
+const localeDir = "locales"
+
+//go:embed locales/*.yaml
+var localeFS embed.FS
+
+var (
+	localeMu sync.RWMutex
+	locale   *Catalog // 当前界面语言
+	fallback *Catalog // 默认语言，当前语言缺少消息时使用
+)
+
+func init() {
+	catalog, err := LoadLocale(DefaultLocale)
+	if err != nil {
+		panic(err)
+	}
+	locale, fallback = catalog, catalog
+}
+
+// Locales 按名称顺序返回内置的界面语言
+func Locales() []string {
+	entries, _ := fs.ReadDir(localeFS, localeDir)
+	var langs []string
+	for _, entry := range entries {
+		if ext := path.Ext(entry.Name()); ext == ".yaml" {
+			langs = append(langs, strings.TrimSuffix(entry.Name(), ext))
+		}
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// LoadLocale 读取内置的界面消息目录
+func LoadLocale(lang string) (*Catalog, error) {
+	return LoadCatalog(localeFS, localeDir, lang)
+}
+
+// MatchLocale 将环境变量形式的语言（如 en_US.UTF-8、zh_CN、en）匹配为内置的界面语言，
+// 先精确匹配，再按主语言匹配；无法匹配（如 C、POSIX）时返回空字符串
+func MatchLocale(value string) string {
+	value = strings.SplitN(value, ".", 2)[0]
+	value = strings.SplitN(value, "@", 2)[0]
+	value = strings.ReplaceAll(strings.TrimSpace(value), "_", "-")
+	if value == "" {
+		return ""
+	}
+	primary := strings.SplitN(value, "-", 2)[0]
+	var byPrimary string
+	for _, lang := range Locales() {
+		if strings.EqualFold(lang, value) {
+			return lang
+		}
+		if byPrimary == "" && strings.EqualFold(strings.SplitN(lang, "-", 2)[0], primary) {
+			byPrimary = lang
+		}
+	}
+	return byPrimary
+}
+
+// DetectLocale 按POSIX的优先级从环境变量检测界面语言：LC_ALL、LC_MESSAGES、LANG 中第一个非空的决定语言，
+// 其值无法识别（如 C、POSIX）或都未设置时返回默认语言
+func DetectLocale(getenv func(string) string) string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := getenv(name); value != "" {
+			if lang := MatchLocale(value); lang != "" {
+				return lang
+			}
+			return DefaultLocale
+		}
+	}
+	return DefaultLocale
+}
+
+// SetLocale 设置界面语言，lang可以是 en-US、en_US.UTF-8 或 en 等形式
+func SetLocale(lang string) error {
+	matched := MatchLocale(lang)
+	if matched == "" {
+		return Errorf(constants.ErrUnknownLocale, lang, strings.Join(Locales(), ", "))
+	}
+	catalog, err := LoadLocale(matched)
+	if err != nil {
+		return err
+	}
+	localeMu.Lock()
+	locale = catalog
+	localeMu.Unlock()
+	return nil
+}
+
+// Locale 返回当前的界面语言
+func Locale() string {
+	localeMu.RLock()
+	defer localeMu.RUnlock()
+	return locale.Lang
+}
+
+// Msg 返回当前界面语言中键对应的消息；缺少时使用默认语言，仍缺少时返回键本身
+func Msg(key string) string {
+	localeMu.RLock()
+	current := locale
+	localeMu.RUnlock()
+	if message, ok := current.Messages[key]; ok {
+		return message
+	}
+	if message, ok := fallback.Messages[key]; ok {
+		return message
+	}
+	return key
+}
+
+// Sprintf 按当前界面语言的消息格式化
+func Sprintf(key string, args ...interface{}) string {
+	return fmt.Sprintf(Msg(key), args...)
+}
+
+// Errorf 按当前界面语言的消息生成错误，与fmt.Errorf一样支持 %w
+func Errorf(key string, args ...interface{}) error {
+	return fmt.Errorf(Msg(key), args...)
+}
@@ -0,0 +1,140 @@
+package i18n
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// verbPattern fmt格式化动词，用于比较各语言消息的参数
+var verbPattern = regexp.MustCompile(`%[-+# 0]*[0-9]*(\.[0-9]+)?[a-zA-Z%]`)
+
+// messageKeys 从 pkg/constants 中读取所有界面消息的键（Prompt*、Msg*、Err*、FileType* 常量）
+func messageKeys(t *testing.T) []string {
+	file, err := parser.ParseFile(token.NewFileSet(), "../constants/constants.go", nil, 0)
+	if err != nil {
+		t.Fatalf("解析constants失败: %v", err)
+	}
+	var keys []string
+	ast.Inspect(file, func(n ast.Node) bool {
+		spec, ok := n.(*ast.ValueSpec)
+		if !ok {
+			return true
+		}
+		for i, name := range spec.Names {
+			if !hasAnyPrefix(name.Name, "Prompt", "Msg", "Flag", "Err", "FileType") || i >= len(spec.Values) {
+				continue
+			}
+			if lit, ok := spec.Values[i].(*ast.BasicLit); ok {
+				key, _ := strconv.Unquote(lit.Value)
+				keys = append(keys, key)
+			}
+		}
+		return true
+	})
+	return keys
+}
+
+func hasAnyPrefix(s string, prefixes ...string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestLocalesComplete(t *testing.T) {
+	keys := messageKeys(t)
+	if len(keys) == 0 {
+		t.Fatal("没有读取到消息键")
+	}
+	if langs := Locales(); !reflect.DeepEqual(langs, []string{"en-US", "zh-CN"}) {
+		t.Errorf("内置界面语言: %v", langs)
+	}
+
+	base, err := LoadLocale(DefaultLocale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, lang := range Locales() {
+		catalog, err := LoadLocale(lang)
+		if err != nil {
+			t.Fatalf("读取 %s 失败: %v", lang, err)
+		}
+		// constants中的每个键在每种语言中都有消息
+		for _, key := range keys {
+			if catalog.Messages[key] == "" {
+				t.Errorf("%s 缺少消息 %s", lang, key)
+			}
+		}
+		// 各语言的键一致，格式化参数一致
+		if !reflect.DeepEqual(catalog.Keys(), base.Keys()) {
+			t.Errorf("%s 与 %s 的键不一致", lang, DefaultLocale)
+		}
+		for key, message := range catalog.Messages {
+			if got, want := verbPattern.FindAllString(message, -1), verbPattern.FindAllString(base.Messages[key], -1); !reflect.DeepEqual(got, want) {
+				t.Errorf("%s 的 %s 格式化参数为 %v，%s 中为 %v", lang, key, got, DefaultLocale, want)
+			}
+		}
+	}
+}
+
+func TestMatchLocale(t *testing.T) {
+	tests := map[string]string{
+		"en_US.UTF-8":     "en-US",
+		"en-us":           "en-US",
+		"en_GB":           "en-US",
+		"en":              "en-US",
+		"zh_CN.GB18030":   "zh-CN",
+		"zh_TW.UTF-8@cjk": "zh-CN",
+		"C":               "",
+		"POSIX":           "",
+		"":                "",
+		"fr_FR.UTF-8":     "",
+	}
+	for input, expected := range tests {
+		if got := MatchLocale(input); got != expected {
+			t.Errorf("MatchLocale(%q) = %q, 期望 %q", input, got, expected)
+		}
+	}
+
+	env := map[string]string{"LC_ALL": "C", "LANG": "en_US.UTF-8"}
+	if got := DetectLocale(func(name string) string { return env[name] }); got != DefaultLocale {
+		t.Errorf("LC_ALL=C时应使用默认语言，不应回退到LANG: %s", got)
+	}
+	env = map[string]string{"LC_MESSAGES": "en_US.UTF-8", "LANG": "zh_CN.UTF-8"}
+	if got := DetectLocale(func(name string) string { return env[name] }); got != "en-US" {
+		t.Errorf("LC_MESSAGES应优先于LANG: %s", got)
+	}
+	if got := DetectLocale(func(string) string { return "" }); got != DefaultLocale {
+		t.Errorf("未设置环境变量时应使用默认语言: %s", got)
+	}
+}
+
+func TestSetLocale(t *testing.T) {
+	defer SetLocale(DefaultLocale)
+
+	if err := SetLocale("en_US.UTF-8"); err != nil || Locale() != "en-US" {
+		t.Fatalf("设置界面语言失败: %s %v", Locale(), err)
+	}
+	if got := Sprintf("err.loadConfig", "x"); got != "failed to load config: x" {
+		t.Errorf("英文消息: %q", got)
+	}
+	if got := Msg("no.such.key"); got != "no.such.key" {
+		t.Errorf("缺少的键应原样返回: %q", got)
+	}
+
+	err := SetLocale("fr")
+	if err == nil || !strings.Contains(err.Error(), "fr") || Locale() != "en-US" {
+		t.Errorf("不支持的语言应报错且保持当前语言: %v %s", err, Locale())
+	}
+	if err := SetLocale("zh-CN"); err != nil || Sprintf("err.loadConfig", "x") != "加载配置失败: x" {
+		t.Errorf("切换回中文失败: %v", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"fmt"
 	"md-manual-tool/pkg/constants"
+	"md-manual-tool/pkg/i18n"
 	"md-manual-tool/pkg/utils"
 	"strings"
 )
@@ -54,12 +55,35 @@ func (c *Collector) CollectAll() (*InputData, error) {
 	return data, nil
 }
 
+// CollectPaths 只收集模板和配置文件路径（不需要新版本号的命令使用）
+func (c *Collector) CollectPaths() (*InputData, error) {
+	data := &InputData{}
+	if err := c.collectTemplatePath(data); err != nil {
+		return nil, err
+	}
+	if err := c.collectConfigPath(data); err != nil {
+		return nil, err
+	}
+	c.showDetectedVersion(data.TemplatePath)
+	return data, nil
+}
+
+// Ask 显示提示并读取一行回答（去掉首尾空白并转为小写）
+func (c *Collector) Ask(prompt string) (string, error) {
+	fmt.Print(prompt)
+	answer, err := c.reader.ReadString('\n')
+	if err != nil && answer == "" {
+		return "", i18n.Errorf(constants.ErrReadInput, err)
+	}
+	return strings.ToLower(strings.TrimSpace(answer)), nil
+}
+
 // collectTemplatePath 收集模板文件路径
 func (c *Collector) collectTemplatePath(data *InputData) error {
-	fmt.Print(constants.PromptTemplatePath)
+	fmt.Print(i18n.Msg(constants.PromptTemplatePath))
 	templatePath, err := c.reader.ReadString('\n')
 	if err != nil {
-		return fmt.Errorf(constants.ErrReadTemplatePath, err)
+		return i18n.Errorf(constants.ErrReadTemplatePath, err)
 	}
 	data.TemplatePath = strings.TrimSpace(templatePath)
 	return nil
@@ -67,33 +91,33 @@ func (c *Collector) collectTemplatePath(data *InputData) error {
 
 // collectConfigPath 收集配置文件路径
 func (c *Collector) collectConfigPath(data *InputData) error {
-	fmt.Print(constants.PromptConfigPath)
+	fmt.Print(i18n.Msg(constants.PromptConfigPath))
 	configPath, err := c.reader.ReadString('\n')
 	if err != nil {
-		return fmt.Errorf(constants.ErrReadConfigPath, err)
+		return i18n.Errorf(constants.ErrReadConfigPath, err)
 	}
 	data.ConfigPath = strings.TrimSpace(configPath)
 
 	// 如果用户没有输入配置文件路径，则默认使用当前目录的config.yaml
 	if data.ConfigPath == "" {
 		data.ConfigPath = constants.DefaultConfigFile
-		fmt.Printf(constants.MsgDefaultConfigUsed, data.ConfigPath)
+		fmt.Print(i18n.Sprintf(constants.MsgDefaultConfigUsed, data.ConfigPath))
 	}
 	return nil
 }
 
 // collectVersion 收集版本号
 func (c *Collector) collectVersion(data *InputData) error {
-	fmt.Print(constants.PromptVersion)
+	fmt.Print(i18n.Msg(constants.PromptVersion))
 	version, err := c.reader.ReadString('\n')
 	if err != nil {
-		return fmt.Errorf(constants.ErrReadVersion, err)
+		return i18n.Errorf(constants.ErrReadVersion, err)
 	}
 	data.Version = strings.TrimSpace(version)
 
 	// 验证版本号格式
 	if !c.versionUtils.IsValidVersionFormat(data.Version) {
-		return fmt.Errorf("版本号格式无效，请使用 x.y.z 格式（如 1.0.1）")
+		return i18n.Errorf(constants.ErrInvalidVersionFormat)
 	}
 
 	return nil
@@ -103,6 +127,6 @@ func (c *Collector) collectVersion(data *InputData) error {
 func (c *Collector) showDetectedVersion(templatePath string) {
 	oldVersion := c.versionUtils.ExtractVersionFromFilename(templatePath)
 	if oldVersion != "" {
-		fmt.Printf(constants.MsgVersionDetected, oldVersion)
+		fmt.Print(i18n.Sprintf(constants.MsgVersionDetected, oldVersion))
 	}
 }
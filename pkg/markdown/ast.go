@@ -0,0 +1,222 @@
+package markdown
+
+import "strings"
+
+// Document 解析后的Markdown文档
+type Document struct {
+	Blocks []Block
+}
+
+// Block 块级元素
+type Block interface {
+	block()
+}
+
+// Heading 标题
+type Heading struct {
+	Level   int
+	Content []Inline
+	ID      string // 锚点，由AssignIDs生成
+}
+
+// Paragraph 段落
+type Paragraph struct {
+	Content []Inline
+}
+
+// CodeBlock 代码块
+type CodeBlock struct {
+	Lang string
+	Code string
+}
+
+// List 列表
+type List struct {
+	Ordered bool
+	Start   int // 有序列表的起始序号
+	Items   []*ListItem
+}
+
+// ListItem 列表项
+type ListItem struct {
+	Blocks []Block
+	Loose  bool // 列表项内含空行，段落按<p>渲染
+}
+
+// Quote 引用
+type Quote struct {
+	Blocks []Block
+}
+
+// Rule 分隔线
+type Rule struct{}
+
+// HTMLBlock 原样保留的HTML块
+type HTMLBlock struct {
+	Raw string
+}
+
+// Alignment 表格列对齐方式
+type Alignment int
+
+// 表格列对齐方式
+const (
+	AlignNone Alignment = iota
+	AlignLeft
+	AlignCenter
+	AlignRight
+)
+
+// Table 表格
+type Table struct {
+	Align  []Alignment
+	Header [][]Inline   // 表头单元格
+	Rows   [][][]Inline // 每行的单元格，列数与表头一致
+}
+
+func (*Heading) block()   {}
+func (*Paragraph) block() {}
+func (*CodeBlock) block() {}
+func (*List) block()      {}
+func (*Quote) block()     {}
+func (*Rule) block()      {}
+func (*HTMLBlock) block() {}
+func (*Table) block()     {}
+
+// Inline 行内元素
+type Inline interface {
+	inline()
+}
+
+// Text 普通文本，段落内的换行以"\n"保留
+type Text struct {
+	Value string
+}
+
+// Code 行内代码
+type Code struct {
+	Value string
+}
+
+// Strong 粗体
+type Strong struct {
+	Children []Inline
+}
+
+// Emphasis 斜体
+type Emphasis struct {
+	Children []Inline
+}
+
+// Strike 删除线
+type Strike struct {
+	Children []Inline
+}
+
+// Link 链接
+type Link struct {
+	Href     string
+	Title    string
+	Children []Inline
+}
+
+// Image 图片
+type Image struct {
+	Src   string
+	Alt   string
+	Title string
+}
+
+// LineBreak 强制换行
+type LineBreak struct{}
+
+// RawHTML 原样保留的行内HTML标签
+type RawHTML struct {
+	Value string
+}
+
+func (*Text) inline()      {}
+func (*Code) inline()      {}
+func (*Strong) inline()    {}
+func (*Emphasis) inline()  {}
+func (*Strike) inline()    {}
+func (*Link) inline()      {}
+func (*Image) inline()     {}
+func (*LineBreak) inline() {}
+func (*RawHTML) inline()   {}
+
+// PlainText 返回行内元素的纯文本内容（忽略格式和HTML标签）
+func PlainText(inlines []Inline) string {
+	var b strings.Builder
+	for _, node := range inlines {
+		switch n := node.(type) {
+		case *Text:
+			b.WriteString(n.Value)
+		case *Code:
+			b.WriteString(n.Value)
+		case *Strong:
+			b.WriteString(PlainText(n.Children))
+		case *Emphasis:
+			b.WriteString(PlainText(n.Children))
+		case *Strike:
+			b.WriteString(PlainText(n.Children))
+		case *Link:
+			b.WriteString(PlainText(n.Children))
+		case *Image:
+			b.WriteString(n.Alt)
+		case *LineBreak:
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// Images 按文档顺序返回所有Markdown图片（不含HTML中的<img>）
+func (d *Document) Images() []*Image {
+	var images []*Image
+	var inlines func(nodes []Inline)
+	inlines = func(nodes []Inline) {
+		for _, node := range nodes {
+			switch n := node.(type) {
+			case *Image:
+				images = append(images, n)
+			case *Strong:
+				inlines(n.Children)
+			case *Emphasis:
+				inlines(n.Children)
+			case *Strike:
+				inlines(n.Children)
+			case *Link:
+				inlines(n.Children)
+			}
+		}
+	}
+	var blocks func(nodes []Block)
+	blocks = func(nodes []Block) {
+		for _, block := range nodes {
+			switch b := block.(type) {
+			case *Heading:
+				inlines(b.Content)
+			case *Paragraph:
+				inlines(b.Content)
+			case *Quote:
+				blocks(b.Blocks)
+			case *List:
+				for _, item := range b.Items {
+					blocks(item.Blocks)
+				}
+			case *Table:
+				for _, cell := range b.Header {
+					inlines(cell)
+				}
+				for _, row := range b.Rows {
+					for _, cell := range row {
+						inlines(cell)
+					}
+				}
+			}
+		}
+	}
+	blocks(d.Blocks)
+	return images
+}
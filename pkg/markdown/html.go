@@ -0,0 +1,170 @@
+package markdown
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// ToHTML 将Markdown转换为HTML片段，标题使用GitHub风格的锚点
+func ToHTML(src string) string {
+	doc := Parse(src)
+	doc.AssignIDs(SlugGitHub)
+	return doc.HTML()
+}
+
+// HTML 将文档渲染为HTML片段
+func (d *Document) HTML() string {
+	var out strings.Builder
+	writeBlocks(&out, d.Blocks, false)
+	return out.String()
+}
+
+// writeBlocks 渲染块级元素，tight为true时段落不包裹<p>（紧凑列表项）
+func writeBlocks(out *strings.Builder, blocks []Block, tight bool) {
+	for i, block := range blocks {
+		switch b := block.(type) {
+		case *Heading:
+			if b.ID != "" {
+				fmt.Fprintf(out, "<h%d id=\"%s\">%s</h%d>\n", b.Level, html.EscapeString(b.ID), InlineHTML(b.Content), b.Level)
+			} else {
+				fmt.Fprintf(out, "<h%d>%s</h%d>\n", b.Level, InlineHTML(b.Content), b.Level)
+			}
+
+		case *Paragraph:
+			if tight {
+				out.WriteString(InlineHTML(b.Content))
+				if i < len(blocks)-1 {
+					out.WriteString("\n")
+				}
+			} else {
+				fmt.Fprintf(out, "<p>%s</p>\n", InlineHTML(b.Content))
+			}
+
+		case *CodeBlock:
+			if b.Lang != "" {
+				fmt.Fprintf(out, "<pre><code class=\"language-%s\">", html.EscapeString(b.Lang))
+			} else {
+				out.WriteString("<pre><code>")
+			}
+			out.WriteString(html.EscapeString(b.Code))
+			out.WriteString("</code></pre>\n")
+
+		case *List:
+			writeList(out, b)
+
+		case *Quote:
+			out.WriteString("<blockquote>\n")
+			writeBlocks(out, b.Blocks, false)
+			out.WriteString("</blockquote>\n")
+
+		case *Rule:
+			out.WriteString("<hr>\n")
+
+		case *HTMLBlock:
+			out.WriteString(b.Raw)
+			out.WriteString("\n")
+
+		case *Table:
+			writeTable(out, b)
+		}
+	}
+}
+
+// writeList 渲染列表
+func writeList(out *strings.Builder, list *List) {
+	tag := "ul"
+	if list.Ordered {
+		tag = "ol"
+	}
+	if list.Ordered && list.Start != 1 {
+		fmt.Fprintf(out, "<ol start=\"%d\">\n", list.Start)
+	} else {
+		fmt.Fprintf(out, "<%s>\n", tag)
+	}
+	for _, item := range list.Items {
+		out.WriteString("<li>")
+		if item.Loose {
+			out.WriteString("\n")
+		}
+		writeBlocks(out, item.Blocks, !item.Loose)
+		out.WriteString("</li>\n")
+	}
+	fmt.Fprintf(out, "</%s>\n", tag)
+}
+
+// writeTable 渲染表格
+func writeTable(out *strings.Builder, table *Table) {
+	writeRow := func(cells [][]Inline, tag string) {
+		out.WriteString("<tr>\n")
+		for j, cell := range cells {
+			if style := alignStyle(table.Align[j]); style != "" {
+				fmt.Fprintf(out, "<%s style=\"text-align: %s\">%s</%s>\n", tag, style, InlineHTML(cell), tag)
+			} else {
+				fmt.Fprintf(out, "<%s>%s</%s>\n", tag, InlineHTML(cell), tag)
+			}
+		}
+		out.WriteString("</tr>\n")
+	}
+
+	out.WriteString("<table>\n<thead>\n")
+	writeRow(table.Header, "th")
+	out.WriteString("</thead>\n")
+	if len(table.Rows) > 0 {
+		out.WriteString("<tbody>\n")
+		for _, row := range table.Rows {
+			writeRow(row, "td")
+		}
+		out.WriteString("</tbody>\n")
+	}
+	out.WriteString("</table>\n")
+}
+
+// alignStyle 返回对齐方式对应的CSS值
+func alignStyle(align Alignment) string {
+	switch align {
+	case AlignLeft:
+		return "left"
+	case AlignCenter:
+		return "center"
+	case AlignRight:
+		return "right"
+	}
+	return ""
+}
+
+// InlineHTML 将行内元素渲染为HTML
+func InlineHTML(inlines []Inline) string {
+	var out strings.Builder
+	for _, node := range inlines {
+		switch n := node.(type) {
+		case *Text:
+			out.WriteString(html.EscapeString(n.Value))
+		case *Code:
+			fmt.Fprintf(&out, "<code>%s</code>", html.EscapeString(n.Value))
+		case *Strong:
+			fmt.Fprintf(&out, "<strong>%s</strong>", InlineHTML(n.Children))
+		case *Emphasis:
+			fmt.Fprintf(&out, "<em>%s</em>", InlineHTML(n.Children))
+		case *Strike:
+			fmt.Fprintf(&out, "<del>%s</del>", InlineHTML(n.Children))
+		case *Link:
+			fmt.Fprintf(&out, "<a href=\"%s\"%s>%s</a>", html.EscapeString(n.Href), titleAttr(n.Title), InlineHTML(n.Children))
+		case *Image:
+			fmt.Fprintf(&out, "<img src=\"%s\" alt=\"%s\"%s>", html.EscapeString(n.Src), html.EscapeString(n.Alt), titleAttr(n.Title))
+		case *LineBreak:
+			out.WriteString("<br>\n")
+		case *RawHTML:
+			out.WriteString(n.Value)
+		}
+	}
+	return out.String()
+}
+
+// titleAttr 生成title属性
+func titleAttr(title string) string {
+	if title == "" {
+		return ""
+	}
+	return fmt.Sprintf(" title=\"%s\"", html.EscapeString(title))
+}
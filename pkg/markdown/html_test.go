@@ -0,0 +1,45 @@
+package markdown
+
+import "testing"
+
+func TestToHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"标题", "# 安装 *指南*\n## 第二节 ##", "<h1 id=\"安装-指南\">安装 <em>指南</em></h1>\n<h2 id=\"第二节\">第二节</h2>\n"},
+		{"段落", "第一行\n第二行  \n第三行\n\n新段落", "<p>第一行\n第二行<br>\n第三行</p>\n<p>新段落</p>\n"},
+		{"强调", "**粗体** __粗__ *斜体* _斜_ ~~删除~~ snake_case_name", "<p><strong>粗体</strong> <strong>粗</strong> <em>斜体</em> <em>斜</em> <del>删除</del> snake_case_name</p>\n"},
+		{"转义", "a < b & \\*c\\*", "<p>a &lt; b &amp; *c*</p>\n"},
+		{"行内代码", "运行 `a<b && *c*`", "<p>运行 <code>a&lt;b &amp;&amp; *c*</code></p>\n"},
+		{"代码块", "```go\nfunc main() {}\n<b>\n```\n后续", "<pre><code class=\"language-go\">func main() {}\n&lt;b&gt;\n</code></pre>\n<p>后续</p>\n"},
+		{"缩进代码块", "示例：\n\n    # 安装依赖\n    go mod download\n\n        <b>\n\n后续", "<p>示例：</p>\n<pre><code># 安装依赖\ngo mod download\n\n    &lt;b&gt;\n</code></pre>\n<p>后续</p>\n"},
+		{"代码块中的制表符", "```make\nbuild:\n\tgo build\t./...\n```", "<pre><code class=\"language-make\">build:\n\tgo build\t./...\n</code></pre>\n"},
+		{"制表符缩进的代码块", "\tgo build\n\t\tif\tx", "<pre><code>go build\n\tif\tx\n</code></pre>\n"},
+		{"列表中制表符缩进的代码块", "- 运行\n\n\t\tmake\tinstall", "<ul>\n<li>\n<p>运行</p>\n<pre><code>  make\tinstall\n</code></pre>\n</li>\n</ul>\n"},
+		{"缩进不打断段落", "第一行\n    # 第二行", "<p>第一行\n# 第二行</p>\n"},
+		{"列表中的代码块", "1. 运行\n\n       make install\n2. 完成", "<ol>\n<li>\n<p>运行</p>\n<pre><code>make install\n</code></pre>\n</li>\n<li>完成</li>\n</ol>\n"},
+		{"图片", "![架构 图](images/a.png \"标题\")", "<p><img src=\"images/a.png\" alt=\"架构 图\" title=\"标题\"></p>\n"},
+		{"链接", "[**文档**](https://example.com) <https://a.com>", "<p><a href=\"https://example.com\"><strong>文档</strong></a> <a href=\"https://a.com\">https://a.com</a></p>\n"},
+		{"无序列表", "- 一\n- 二\n  - 二.一\n- 三", "<ul>\n<li>一</li>\n<li>二\n<ul>\n<li>二.一</li>\n</ul>\n</li>\n<li>三</li>\n</ul>\n"},
+		{"有序列表", "3. 三\n4. 四", "<ol start=\"3\">\n<li>三</li>\n<li>四</li>\n</ol>\n"},
+		{"松散列表", "1. 一\n\n   说明\n2. 二", "<ol>\n<li>\n<p>一</p>\n<p>说明</p>\n</li>\n<li>二</li>\n</ol>\n"},
+		{"引用", "> 注意\n> **重要**", "<blockquote>\n<p>注意\n<strong>重要</strong></p>\n</blockquote>\n"},
+		{"分隔线", "---\n***", "<hr>\n<hr>\n"},
+		{"HTML块", "<div align=\"center\">\n<img src=\"a.png\">\n</div>", "<div align=\"center\">\n<img src=\"a.png\">\n</div>\n"},
+		{"行内HTML", "换行<br>文字", "<p>换行<br>文字</p>\n"},
+		{"含空格的图片路径", "![图](./手册 1.0.assets/a b.png)", "<p><img src=\"./手册 1.0.assets/a b.png\" alt=\"图\"></p>\n"},
+		{"未闭合的标记", "a ** b * c `d", "<p>a ** b * c `d</p>\n"},
+		{"表格", "| 参数 | 说明 | 默认值 |\n|:---|:---:|---:|\n| `a\\|b` | **必填** | 1 |\n| c |", "<table>\n<thead>\n<tr>\n<th style=\"text-align: left\">参数</th>\n<th style=\"text-align: center\">说明</th>\n<th style=\"text-align: right\">默认值</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td style=\"text-align: left\"><code>a|b</code></td>\n<td style=\"text-align: center\"><strong>必填</strong></td>\n<td style=\"text-align: right\">1</td>\n</tr>\n<tr>\n<td style=\"text-align: left\">c</td>\n<td style=\"text-align: center\"></td>\n<td style=\"text-align: right\"></td>\n</tr>\n</tbody>\n</table>\n"},
+		{"无对齐表格", "a | b\n--- | ---\n1 | 2\n\n段落", "<table>\n<thead>\n<tr>\n<th>a</th>\n<th>b</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td>1</td>\n<td>2</td>\n</tr>\n</tbody>\n</table>\n<p>段落</p>\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := ToHTML(tt.input); result != tt.expected {
+				t.Errorf("ToHTML(%q)\n期望: %q\n实际: %q", tt.input, tt.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,355 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 行内语法
+var (
+	autoLinkRe = regexp.MustCompile(`^<((?:https?|ftp|mailto):[^\s<>]+)>`)
+	rawTagRe   = regexp.MustCompile(`^(?:</?[a-zA-Z][a-zA-Z0-9-]*(?:\s[^<>]*)?/?>|<!--.*?-->)`)
+)
+
+// escapable 可用反斜杠转义的字符
+const escapable = "\\`*_{}[]()#+-.!|~<>\"'"
+
+// inlineParser 行内元素解析器
+type inlineParser struct {
+	text string
+	pos  int
+	out  []Inline
+	buf  strings.Builder
+}
+
+// parseInline 解析行内元素
+func parseInline(text string) []Inline {
+	p := &inlineParser{text: text}
+	return p.parse()
+}
+
+// parse 逐字符扫描，识别到的元素加入结果，其余字符作为文本
+func (p *inlineParser) parse() []Inline {
+	for p.pos < len(p.text) {
+		c := p.text[p.pos]
+		switch c {
+		case '\\':
+			if p.pos+1 < len(p.text) {
+				next := p.text[p.pos+1]
+				if next == '\n' {
+					p.emit(&LineBreak{})
+					p.pos += 2
+					continue
+				}
+				if strings.IndexByte(escapable, next) >= 0 {
+					p.buf.WriteByte(next)
+					p.pos += 2
+					continue
+				}
+			}
+		case '`':
+			if p.codeSpan() {
+				continue
+			}
+		case '!':
+			if p.pos+1 < len(p.text) && p.text[p.pos+1] == '[' && p.link(true) {
+				continue
+			}
+		case '[':
+			if p.link(false) {
+				continue
+			}
+		case '<':
+			if p.autoLink() || p.rawHTML() {
+				continue
+			}
+		case '*', '_', '~':
+			if p.emphasis() {
+				continue
+			}
+		case '\n':
+			p.newline()
+			continue
+		}
+		p.buf.WriteByte(c)
+		p.pos++
+	}
+	p.flush()
+	return p.out
+}
+
+// flush 将缓存的文本加入结果
+func (p *inlineParser) flush() {
+	if p.buf.Len() == 0 {
+		return
+	}
+	if last, ok := lastText(p.out); ok {
+		last.Value += p.buf.String()
+	} else {
+		p.out = append(p.out, &Text{Value: p.buf.String()})
+	}
+	p.buf.Reset()
+}
+
+// lastText 返回结果中最后一个文本元素
+func lastText(out []Inline) (*Text, bool) {
+	if len(out) == 0 {
+		return nil, false
+	}
+	text, ok := out[len(out)-1].(*Text)
+	return text, ok
+}
+
+// emit 加入一个行内元素
+func (p *inlineParser) emit(node Inline) {
+	p.flush()
+	p.out = append(p.out, node)
+}
+
+// newline 处理换行：行尾两个以上空格表示强制换行，否则保留为软换行
+func (p *inlineParser) newline() {
+	text := p.buf.String()
+	trimmed := strings.TrimRight(text, " ")
+	p.buf.Reset()
+	p.buf.WriteString(trimmed)
+	if len(text)-len(trimmed) >= 2 {
+		p.emit(&LineBreak{})
+	} else {
+		p.buf.WriteByte('\n')
+	}
+	p.pos++
+	for p.pos < len(p.text) && p.text[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+// codeSpan 解析行内代码，找不到结束标记时反引号按文本处理
+func (p *inlineParser) codeSpan() bool {
+	n := runLength(p.text, p.pos, '`')
+	fence := p.text[p.pos : p.pos+n]
+	for j := p.pos + n; j < len(p.text); {
+		k := strings.Index(p.text[j:], fence)
+		if k < 0 {
+			break
+		}
+		k += j
+		if runLength(p.text, k, '`') == n {
+			code := strings.ReplaceAll(p.text[p.pos+n:k], "\n", " ")
+			p.emit(&Code{Value: strings.TrimSpace(code)})
+			p.pos = k + n
+			return true
+		}
+		j = k + runLength(p.text, k, '`')
+	}
+	p.buf.WriteString(fence)
+	p.pos += n
+	return true
+}
+
+// link 解析链接或图片：[文本](地址 "标题")
+func (p *inlineParser) link(image bool) bool {
+	start := p.pos
+	if image {
+		start++
+	}
+	closeBracket := matchBracket(p.text, start)
+	if closeBracket < 0 || closeBracket+1 >= len(p.text) || p.text[closeBracket+1] != '(' {
+		return false
+	}
+	dest, title, end, ok := parseDestination(p.text, closeBracket+2)
+	if !ok {
+		return false
+	}
+
+	label := p.text[start+1 : closeBracket]
+	if image {
+		p.emit(&Image{Src: dest, Alt: PlainText(parseInline(label)), Title: title})
+	} else {
+		p.emit(&Link{Href: dest, Title: title, Children: parseInline(label)})
+	}
+	p.pos = end
+	return true
+}
+
+// matchBracket 返回与start处'['匹配的']'位置
+func matchBracket(text string, start int) int {
+	depth := 0
+	for i := start; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// parseDestination 解析链接地址和可选的标题，返回')'之后的位置
+//
+// 地址中含空格且未用尖括号包裹时（常见于中文文档），宽松地取到')'为止
+func parseDestination(text string, pos int) (dest, title string, end int, ok bool) {
+	i := skipSpaces(text, pos)
+	if i < len(text) && text[i] == '<' {
+		closeAngle := strings.IndexByte(text[i:], '>')
+		if closeAngle < 0 {
+			return "", "", 0, false
+		}
+		dest = text[i+1 : i+closeAngle]
+		i += closeAngle + 1
+	} else {
+		depth := 0
+		j := i
+		for ; j < len(text); j++ {
+			c := text[j]
+			if c == ' ' || c == '\n' || (c == ')' && depth == 0) {
+				break
+			}
+			if c == '(' {
+				depth++
+			} else if c == ')' {
+				depth--
+			}
+		}
+		dest = text[i:j]
+		i = j
+	}
+
+	i = skipSpaces(text, i)
+	if i < len(text) && (text[i] == '"' || text[i] == '\'') {
+		if closeQuote := strings.IndexByte(text[i+1:], text[i]); closeQuote >= 0 {
+			title = text[i+1 : i+1+closeQuote]
+			i = skipSpaces(text, i+closeQuote+2)
+		}
+	}
+	if i < len(text) && text[i] == ')' {
+		return dest, title, i + 1, dest != "" || i > pos
+	}
+
+	// 宽松模式：地址中含空格
+	closeParen := strings.IndexByte(text[pos:], ')')
+	if closeParen < 0 || strings.ContainsAny(text[pos:pos+closeParen], "\n") {
+		return "", "", 0, false
+	}
+	return strings.TrimSpace(text[pos : pos+closeParen]), "", pos + closeParen + 1, true
+}
+
+// skipSpaces 跳过空白字符
+func skipSpaces(text string, i int) int {
+	for i < len(text) && (text[i] == ' ' || text[i] == '\n') {
+		i++
+	}
+	return i
+}
+
+// autoLink 解析<https://...>形式的自动链接
+func (p *inlineParser) autoLink() bool {
+	m := autoLinkRe.FindStringSubmatch(p.text[p.pos:])
+	if m == nil {
+		return false
+	}
+	p.emit(&Link{Href: m[1], Children: []Inline{&Text{Value: m[1]}}})
+	p.pos += len(m[0])
+	return true
+}
+
+// rawHTML 解析内嵌的HTML标签
+func (p *inlineParser) rawHTML() bool {
+	m := rawTagRe.FindString(p.text[p.pos:])
+	if m == "" {
+		return false
+	}
+	p.emit(&RawHTML{Value: m})
+	p.pos += len(m)
+	return true
+}
+
+// emphasis 解析粗体、斜体和删除线
+func (p *inlineParser) emphasis() bool {
+	c := p.text[p.pos]
+	n := runLength(p.text, p.pos, c)
+	if c == '~' {
+		if n != 2 {
+			return false
+		}
+		return p.delimited("~~", func(children []Inline) Inline { return &Strike{Children: children} })
+	}
+	// 下划线强调只能出现在单词边界
+	if c == '_' && isWordChar(lastRune(p.text[:p.pos])) {
+		return false
+	}
+	if n >= 2 && p.delimited(strings.Repeat(string(c), 2), func(children []Inline) Inline { return &Strong{Children: children} }) {
+		return true
+	}
+	return p.delimited(string(c), func(children []Inline) Inline { return &Emphasis{Children: children} })
+}
+
+// delimited 查找与当前位置开始标记对应的结束标记，将中间内容解析为子元素
+func (p *inlineParser) delimited(delim string, build func([]Inline) Inline) bool {
+	start := p.pos + len(delim)
+	if start >= len(p.text) || p.text[start] == ' ' || p.text[start] == '\n' {
+		return false
+	}
+	for j := start + 1; j <= len(p.text)-len(delim); j++ {
+		switch p.text[j] {
+		case '\\':
+			j++
+			continue
+		case '`':
+			// 跳过行内代码中的标记
+			n := runLength(p.text, j, '`')
+			if k := strings.Index(p.text[j+n:], p.text[j:j+n]); k >= 0 {
+				j += n + k + n - 1
+			}
+			continue
+		}
+		if !strings.HasPrefix(p.text[j:], delim) {
+			continue
+		}
+		// 单个*不与**匹配
+		if run := runLength(p.text, j, delim[0]); len(delim) == 1 && run > 1 && delim[0] == '*' {
+			j += run - 1
+			continue
+		}
+		if prev := p.text[j-1]; prev == ' ' || prev == '\n' {
+			continue
+		}
+		if delim[0] == '_' {
+			next, _ := utf8.DecodeRuneInString(p.text[j+len(delim):])
+			if isWordChar(next) {
+				continue
+			}
+		}
+		p.emit(build(parseInline(p.text[start:j])))
+		p.pos = j + len(delim)
+		return true
+	}
+	return false
+}
+
+// runLength 返回从i开始连续字符c的个数
+func runLength(text string, i int, c byte) int {
+	n := 0
+	for i+n < len(text) && text[i+n] == c {
+		n++
+	}
+	return n
+}
+
+// lastRune 返回字符串的最后一个字符
+func lastRune(s string) rune {
+	r, _ := utf8.DecodeLastRuneInString(s)
+	return r
+}
+
+// isWordChar 判断是否为单词字符（字母、数字或下划线）
+func isWordChar(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
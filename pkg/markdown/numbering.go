@@ -0,0 +1,321 @@
+package markdown
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultNumberingDepth 默认编号的标题层数：章、节、小节
+const DefaultNumberingDepth = 3
+
+var (
+	// labelRe 行尾的标签，如 {#fig:arch}
+	labelRe = regexp.MustCompile(`[ \t]*\{#([^{}\s]+)\}[ \t]*$`)
+	// figureRe 单独一行的图片，可带标签
+	figureRe = regexp.MustCompile(`^[ \t]{0,3}(!\[([^\]]*)\]\(.*\))[ \t]*$`)
+	// tableCaptionRe 表格标题行：": 标题" 或 "Table: 标题"
+	tableCaptionRe = regexp.MustCompile(`^[ \t]{0,3}(?:Table)?:[ \t]+(.*)$`)
+	// refMarkerRe 交叉引用标记
+	refMarkerRe = regexp.MustCompile(refOpen + "([^" + refClose + "]*)" + refClose)
+)
+
+// 交叉引用标记的定界符，使用Unicode行间注释字符，不会与文档内容冲突
+const (
+	refOpen  = "\uFFF9"
+	refClose = "\uFFFB"
+)
+
+// NumberingOptions 编号选项，未启用的类别不显示编号，但仍可被交叉引用
+type NumberingOptions struct {
+	Headings     bool // 为标题加上"第3章"、"3.2"形式的编号
+	Figures      bool // 为带说明文字的图片加上"图 3-1"形式的图题
+	Tables       bool // 为表格加上"表 3-2"形式的表题
+	ChapterLevel int  // 作为"章"的标题级别，0表示自动判断
+	Depth        int  // 编号的标题层数，0表示默认值
+}
+
+// NumberingResult 编号结果
+type NumberingResult struct {
+	Content  string
+	Headings int // 加上编号的标题数量
+	Figures  int
+	Tables   int
+	Refs     int // 解析的交叉引用数量
+}
+
+// RefMarker 交叉引用标记，编号完成后替换为标签对应的编号
+func RefMarker(label string) string {
+	return refOpen + label + refClose
+}
+
+// numberer 编号过程中的状态
+type numberer struct {
+	opts     NumberingOptions
+	counters []int // 各层标题的序号，下标0为章
+	figures  int   // 本章的图片序号
+	tables   int   // 本章的表格序号
+	labels   map[string]string
+	result   NumberingResult
+}
+
+// Number 为标题、图片和表格编号，并将交叉引用标记替换为最终编号
+//
+// 标签写在标题行末、图片后或表格标题行末，如 "## 安装 {#sec:install}"、"![架构](a.png){#fig:arch}"、
+// ": 参数说明 {#tbl:params}"；图片和表格按所在章编号，第一章之前的按全文顺序编号。
+// 代码块中的内容保持不变
+func Number(src string, opts NumberingOptions) (*NumberingResult, error) {
+	if opts.Depth <= 0 {
+		opts.Depth = DefaultNumberingDepth
+	}
+	lines := strings.Split(src, "\n")
+	code := CodeLines(lines)
+	if opts.ChapterLevel <= 0 {
+		opts.ChapterLevel = chapterLevel(lines, code)
+	}
+
+	n := &numberer{opts: opts, counters: make([]int, 7-opts.ChapterLevel), labels: make(map[string]string)}
+	var out []string
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if code[i] {
+			out = append(out, line)
+			continue
+		}
+
+		if m := matchHeading(line); m != nil {
+			heading, err := n.heading(line, m)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, heading)
+			continue
+		}
+
+		standalone := (i == 0 || strings.TrimSpace(lines[i-1]) == "") && (i+1 == len(lines) || strings.TrimSpace(lines[i+1]) == "")
+		if text, label := splitLabel(line); standalone && figureRe.MatchString(text) {
+			figure, err := n.figure(text, label)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, figure...)
+			continue
+		}
+
+		// 表格标题行，与表格之间可以有一个空行
+		if m := tableCaptionRe.FindStringSubmatch(line); m != nil {
+			start := i + 1
+			if start < len(lines) && strings.TrimSpace(lines[start]) == "" {
+				start++
+			}
+			if start < len(lines) && !code[start] && isTableStart(lines, start) {
+				caption, err := n.table(m[1])
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, caption, "")
+				i = n.copyTable(lines, start, &out)
+				continue
+			}
+		}
+
+		if isTableStart(lines, i) {
+			caption, _ := n.table("")
+			if n.opts.Tables {
+				if len(out) > 0 && strings.TrimSpace(out[len(out)-1]) != "" {
+					out = append(out, "")
+				}
+				out = append(out, caption, "")
+			}
+			i = n.copyTable(lines, i, &out)
+			continue
+		}
+		out = append(out, line)
+	}
+
+	content, err := n.resolve(strings.Join(out, "\n"))
+	if err != nil {
+		return nil, err
+	}
+	n.result.Content = content
+	return &n.result, nil
+}
+
+// matchHeading 匹配标题行，缩进超过3个空格的行不是标题
+func matchHeading(line string) []string {
+	if indentWidth(line) > 3 {
+		return nil
+	}
+	return headingRe.FindStringSubmatch(strings.TrimSpace(line))
+}
+
+// heading 为标题编号，返回改写后的标题行，保留行首缩进（如列表项中的标题）；文档标题等高于"章"的标题不编号
+func (n *numberer) heading(line string, m []string) (string, error) {
+	indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+	text, label := splitLabel(m[2])
+	level := len(m[1])
+	depth := level - n.opts.ChapterLevel
+	if depth < 0 {
+		if label != "" {
+			return "", fmt.Errorf("标签 %s 所在的标题级别高于章，无法编号", label)
+		}
+		return line, nil
+	}
+
+	n.counters[depth]++
+	for i := depth + 1; i < len(n.counters); i++ {
+		n.counters[i] = 0
+	}
+	if depth == 0 {
+		n.figures, n.tables = 0, 0
+	}
+
+	number := fmt.Sprintf("第%d章", n.counters[0])
+	if depth > 0 {
+		parts := make([]string, depth+1)
+		for i := range parts {
+			parts[i] = strconv.Itoa(n.counters[i])
+		}
+		number = strings.Join(parts, ".")
+	}
+	if err := n.label(label, number); err != nil {
+		return "", err
+	}
+
+	if !n.opts.Headings || depth >= n.opts.Depth {
+		if label == "" {
+			return line, nil
+		}
+		return indent + m[1] + " " + text, nil
+	}
+	n.result.Headings++
+	return indent + m[1] + " " + number + " " + text, nil
+}
+
+// figure 为带说明文字的图片编号，图题写在图片下方
+func (n *numberer) figure(image, label string) ([]string, error) {
+	alt := strings.TrimSpace(figureRe.FindStringSubmatch(image)[2])
+	if alt == "" {
+		if label != "" {
+			return nil, fmt.Errorf("标签 %s 所在的图片缺少说明文字，无法编号", label)
+		}
+		return []string{image}, nil
+	}
+
+	n.figures++
+	number := "图 " + n.sequence(n.figures)
+	if err := n.label(label, number); err != nil {
+		return nil, err
+	}
+	if !n.opts.Figures {
+		return []string{image}, nil
+	}
+	n.result.Figures++
+	return []string{image, "", number + " " + alt}, nil
+}
+
+// table 为表格编号，返回写在表格上方的表题
+func (n *numberer) table(caption string) (string, error) {
+	caption, label := splitLabel(caption)
+	caption = strings.TrimSpace(caption)
+	n.tables++
+	number := "表 " + n.sequence(n.tables)
+	if err := n.label(label, number); err != nil {
+		return "", err
+	}
+	if !n.opts.Tables {
+		return caption, nil
+	}
+	n.result.Tables++
+	return strings.TrimSpace(number + " " + caption), nil
+}
+
+// copyTable 原样复制表格各行，返回表格最后一行的位置
+func (n *numberer) copyTable(lines []string, start int, out *[]string) int {
+	*out = append(*out, lines[start], lines[start+1])
+	i := start + 2
+	for ; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "" || !strings.Contains(lines[i], "|") {
+			break
+		}
+		*out = append(*out, lines[i])
+	}
+	return i - 1
+}
+
+// sequence 图表序号：有章时为"章-序号"，否则为全文序号
+func (n *numberer) sequence(seq int) string {
+	if n.counters[0] == 0 {
+		return strconv.Itoa(seq)
+	}
+	return fmt.Sprintf("%d-%d", n.counters[0], seq)
+}
+
+// label 记录标签对应的编号
+func (n *numberer) label(label, number string) error {
+	if label == "" {
+		return nil
+	}
+	if _, exists := n.labels[label]; exists {
+		return fmt.Errorf("标签重复: %s", label)
+	}
+	n.labels[label] = number
+	return nil
+}
+
+// resolve 将交叉引用标记替换为编号，列出所有找不到的标签
+func (n *numberer) resolve(content string) (string, error) {
+	var missing []string
+	content = refMarkerRe.ReplaceAllStringFunc(content, func(marker string) string {
+		label := refMarkerRe.FindStringSubmatch(marker)[1]
+		number, ok := n.labels[label]
+		if !ok {
+			missing = append(missing, label)
+			return marker
+		}
+		n.result.Refs++
+		return number
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("未找到引用的标签: %s", strings.Join(missing, ", "))
+	}
+	return content, nil
+}
+
+// splitLabel 拆分行尾的标签
+func splitLabel(text string) (string, string) {
+	m := labelRe.FindStringSubmatchIndex(text)
+	if m == nil {
+		return text, ""
+	}
+	return text[:m[0]], text[m[2]:m[3]]
+}
+
+// chapterLevel 自动判断作为"章"的标题级别：文档只有一个一级标题且位于开头时，它是文档标题，二级标题为章；
+// 否则最高级别的标题为章
+func chapterLevel(lines []string, code []bool) int {
+	var levels []int
+	for i, line := range lines {
+		if m := matchHeading(line); m != nil && !code[i] {
+			levels = append(levels, len(m[1]))
+		}
+	}
+	if len(levels) == 0 {
+		return 1
+	}
+
+	min, count := 6, 0
+	for _, level := range levels {
+		if level < min {
+			min, count = level, 0
+		}
+		if level == min {
+			count++
+		}
+	}
+	if min == 1 && count == 1 && levels[0] == 1 && len(levels) > 1 {
+		return 2
+	}
+	return min
+}
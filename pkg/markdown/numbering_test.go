@@ -0,0 +1,112 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNumber(t *testing.T) {
+	all := NumberingOptions{Headings: true, Figures: true, Tables: true}
+	tests := []struct {
+		name     string
+		input    string
+		opts     NumberingOptions
+		expected string
+	}{
+		{
+			"文档标题下的章节",
+			"# 手册\n## 安装\n### 下载\n#### 校验\n##### 细节\n## 配置\n### 数据库",
+			all,
+			"# 手册\n## 第1章 安装\n### 1.1 下载\n#### 1.1.1 校验\n##### 细节\n## 第2章 配置\n### 2.1 数据库",
+		},
+		{
+			"一级标题为章",
+			"# 概述\n## 背景\n# 安装",
+			NumberingOptions{Headings: true, Depth: 1},
+			"# 第1章 概述\n## 背景\n# 第2章 安装",
+		},
+		{
+			"指定章级别",
+			"# 概述\n## 背景",
+			NumberingOptions{Headings: true, ChapterLevel: 2},
+			"# 概述\n## 第1章 背景",
+		},
+		{
+			"图表按章编号",
+			"# 手册\n\n## 架构\n\n![系统架构](a.png)\n\n| a | b |\n|---|---|\n| 1 | 2 |\n\n## 部署\n\n![](b.png)\n\n![部署流程](c.png)\n\n: 端口 {#tbl:ports}\n\n| 端口 |\n|---|\n| 80 |",
+			all,
+			"# 手册\n\n## 第1章 架构\n\n![系统架构](a.png)\n\n图 1-1 系统架构\n\n表 1-1\n\n| a | b |\n|---|---|\n| 1 | 2 |\n\n## 第2章 部署\n\n![](b.png)\n\n![部署流程](c.png)\n\n图 2-1 部署流程\n\n表 2-1 端口\n\n| 端口 |\n|---|\n| 80 |",
+		},
+		{
+			"段落中的图片不编号",
+			"## 一\n\n图标 ![设置](s.png)\n说明",
+			all,
+			"## 第1章 一\n\n图标 ![设置](s.png)\n说明",
+		},
+		{
+			"无章时全文编号",
+			"![甲](a.png)\n\n![乙](b.png)",
+			NumberingOptions{Figures: true},
+			"![甲](a.png)\n\n图 1 甲\n\n![乙](b.png)\n\n图 2 乙",
+		},
+		{
+			"代码块不变",
+			"## 一\n```\n## 二 {#sec:x}\n| a |\n|---|\n```",
+			all,
+			"## 第1章 一\n```\n## 二 {#sec:x}\n| a |\n|---|\n```",
+		},
+		{
+			"缩进代码和列表中的标题",
+			"# 手册\n## 安装\n\n    # 安装依赖\n    go mod download\n\n- 步骤\n  ## 配置 {#sec:config}\n\n见" + RefMarker("sec:config"),
+			all,
+			"# 手册\n## 第1章 安装\n\n    # 安装依赖\n    go mod download\n\n- 步骤\n  ## 第2章 配置\n\n见第2章",
+		},
+		{
+			"交叉引用",
+			"见" + RefMarker("fig:arch") + "、" + RefMarker("tbl:ports") + "和" + RefMarker("sec:db") + "。\n" +
+				"## 安装 {#sec:install}\n### 数据库 {#sec:db}\n\n![架构](a.png){#fig:arch}\n\nTable: 端口 {#tbl:ports}\n| 端口 |\n|---|\n| 80 |\n\n详见" + RefMarker("sec:install"),
+			NumberingOptions{},
+			"见图 1-1、表 1-1和1.1。\n## 安装\n### 数据库\n\n![架构](a.png)\n\n端口\n\n| 端口 |\n|---|\n| 80 |\n\n详见第1章",
+		},
+		{
+			"未启用编号时内容不变",
+			"# 手册\n## 安装\n\n![图](a.png)\n\n| a |\n|---|",
+			NumberingOptions{},
+			"# 手册\n## 安装\n\n![图](a.png)\n\n| a |\n|---|",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Number(tt.input, tt.opts)
+			if err != nil {
+				t.Fatalf("编号失败: %v", err)
+			}
+			if result.Content != tt.expected {
+				t.Errorf("Number(%q)\n期望: %q\n实际: %q", tt.input, tt.expected, result.Content)
+			}
+		})
+	}
+}
+
+func TestNumberErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{"未知标签", "见" + RefMarker("fig:a") + RefMarker("fig:b") + "\n## 一", "fig:a, fig:b"},
+		{"重复标签", "## 一 {#sec:a}\n## 二 {#sec:a}", "标签重复: sec:a"},
+		{"文档标题的标签", "# 手册 {#sec:a}\n## 一", "高于章"},
+		{"无说明文字的图片", "![](a.png){#fig:a}", "缺少说明文字"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Number(tt.input, NumberingOptions{Headings: true})
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("错误 = %v, 期望包含 %q", err, tt.err)
+			}
+		})
+	}
+}
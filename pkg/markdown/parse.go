@@ -0,0 +1,367 @@
+package markdown
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 块级语法
+var (
+	headingRe    = regexp.MustCompile(`^(#{1,6})[ \t]+(.*?)(?:[ \t]+#+)?[ \t]*$`)
+	fenceRe      = regexp.MustCompile("^ {0,3}(```+|~~~+)[ \t]*([^`\\s]*)")
+	ruleRe       = regexp.MustCompile(`^ {0,3}(?:(?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	listItemRe   = regexp.MustCompile(`^([ \t]*)([-*+]|\d{1,9}[.)])[ \t]+(.*)$`)
+	quoteRe      = regexp.MustCompile(`^ {0,3}>[ ]?(.*)$`)
+	htmlBlockRe  = regexp.MustCompile(`^ {0,3}<(?:[a-zA-Z][a-zA-Z0-9-]*(?:[\s/>]|$)|/[a-zA-Z]|!--)`)
+	tableDelimRe = regexp.MustCompile(`^[ \t]*\|?[ \t]*:?-+:?[ \t]*(?:\|[ \t]*:?-+:?[ \t]*)*\|?[ \t]*$`)
+	orderedRe    = regexp.MustCompile(`^\d`)
+	trailingHash = regexp.MustCompile(`^#+$`)
+)
+
+// Parse 解析Markdown文档
+//
+// 支持常用语法：标题、段落、强调、行内代码、代码块、列表（可嵌套）、表格、引用、分隔线、链接、图片和内嵌HTML
+func Parse(src string) *Document {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	return &Document{Blocks: parseBlocks(strings.Split(src, "\n"))}
+}
+
+// parseBlocks 逐行解析块级元素
+func parseBlocks(lines []string) []Block {
+	var blocks []Block
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			i++
+
+		case fenceRe.MatchString(line):
+			var code *CodeBlock
+			code, i = parseFence(lines, i)
+			blocks = append(blocks, code)
+
+		case indentWidth(line) >= 4:
+			var code *CodeBlock
+			code, i = parseIndentedCode(lines, i)
+			blocks = append(blocks, code)
+
+		case headingRe.MatchString(trimmed):
+			m := headingRe.FindStringSubmatch(trimmed)
+			text := m[2]
+			if trailingHash.MatchString(text) {
+				text = ""
+			}
+			blocks = append(blocks, &Heading{Level: len(m[1]), Content: parseInline(text)})
+			i++
+
+		case ruleRe.MatchString(line):
+			blocks = append(blocks, &Rule{})
+			i++
+
+		case quoteRe.MatchString(line):
+			var inner []string
+			for i < len(lines) && strings.TrimSpace(lines[i]) != "" {
+				if m := quoteRe.FindStringSubmatch(lines[i]); m != nil {
+					inner = append(inner, m[1])
+				} else {
+					inner = append(inner, lines[i])
+				}
+				i++
+			}
+			blocks = append(blocks, &Quote{Blocks: parseBlocks(inner)})
+
+		case listItemRe.MatchString(line):
+			var list *List
+			list, i = parseList(lines, i)
+			blocks = append(blocks, list)
+
+		case htmlBlockRe.MatchString(line):
+			var raw []string
+			for i < len(lines) && strings.TrimSpace(lines[i]) != "" {
+				raw = append(raw, lines[i])
+				i++
+			}
+			blocks = append(blocks, &HTMLBlock{Raw: strings.Join(raw, "\n")})
+
+		case isTableStart(lines, i):
+			var table *Table
+			table, i = parseTable(lines, i)
+			blocks = append(blocks, table)
+
+		default:
+			var paragraph *Paragraph
+			paragraph, i = parseParagraph(lines, i)
+			blocks = append(blocks, paragraph)
+		}
+	}
+	return blocks
+}
+
+// parseFence 解析围栏代码块，返回下一行的位置
+func parseFence(lines []string, start int) (*CodeBlock, int) {
+	m := fenceRe.FindStringSubmatch(lines[start])
+	fence := m[1]
+	code := &CodeBlock{Lang: m[2]}
+
+	var body []string
+	i := start + 1
+	for ; i < len(lines); i++ {
+		closing := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(closing, fence) && strings.Trim(closing, fence[:1]) == "" {
+			i++
+			break
+		}
+		body = append(body, lines[i])
+	}
+	if len(body) > 0 {
+		code.Code = strings.Join(body, "\n") + "\n"
+	}
+	return code, i
+}
+
+// parseIndentedCode 解析缩进代码块（每行缩进至少4列），返回下一行的位置
+//
+// 缩进代码块不能打断段落，列表项中缩进的后续内容由parseList去除缩进后再解析
+func parseIndentedCode(lines []string, start int) (*CodeBlock, int) {
+	var body []string
+	i := start
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if strings.TrimSpace(line) != "" && indentWidth(line) < 4 {
+			break
+		}
+		body = append(body, dedent(line, 4))
+	}
+	// 代码块末尾的空行不属于代码
+	for len(body) > 0 && strings.TrimSpace(body[len(body)-1]) == "" {
+		body = body[:len(body)-1]
+	}
+	return &CodeBlock{Code: strings.Join(body, "\n") + "\n"}, i
+}
+
+// parseParagraph 解析段落，遇到其他块级元素开头时结束
+func parseParagraph(lines []string, start int) (*Paragraph, int) {
+	var text []string
+	i := start
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if strings.TrimSpace(line) == "" {
+			break
+		}
+		if i > start && startsBlock(line) {
+			break
+		}
+		text = append(text, strings.TrimLeft(line, " \t"))
+	}
+	return &Paragraph{Content: parseInline(strings.Join(text, "\n"))}, i
+}
+
+// startsBlock 判断一行是否会打断段落
+func startsBlock(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return fenceRe.MatchString(line) || indentWidth(line) < 4 && headingRe.MatchString(trimmed) || ruleRe.MatchString(line) ||
+		quoteRe.MatchString(line) || listItemRe.MatchString(line) || htmlBlockRe.MatchString(line)
+}
+
+// listEntry 解析中的列表项
+type listEntry struct {
+	lines  []string
+	indent int  // 内容相对于行首的缩进，后续行按此去除缩进
+	loose  bool // 列表项内含空行
+}
+
+// parseList 解析列表，嵌套列表按缩进识别，返回下一行的位置
+func parseList(lines []string, start int) (*List, int) {
+	first := listItemRe.FindStringSubmatch(lines[start])
+	indent := indentWidth(first[1])
+	list := &List{Ordered: orderedRe.MatchString(first[2]), Start: 1}
+	if list.Ordered {
+		list.Start, _ = strconv.Atoi(strings.TrimRight(first[2], ".)"))
+	}
+	sameList := func(m []string) bool {
+		return m != nil && indentWidth(m[1]) <= indent+1 && orderedRe.MatchString(m[2]) == list.Ordered
+	}
+
+	var entries []*listEntry
+	i := start
+	for i < len(lines) {
+		line := lines[i]
+		if m := listItemRe.FindStringSubmatch(line); m != nil && indentWidth(m[1]) <= indent+1 && !ruleRe.MatchString(line) {
+			if !sameList(m) {
+				break
+			}
+			entries = append(entries, &listEntry{lines: []string{m[3]}, indent: indentWidth(m[1]) + len(m[2]) + 1})
+			i++
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" {
+			// 空行后缩进的内容仍属于当前列表项
+			next := i + 1
+			for next < len(lines) && strings.TrimSpace(lines[next]) == "" {
+				next++
+			}
+			if next < len(lines) && indentWidth(lines[next]) > indent {
+				current := entries[len(entries)-1]
+				current.loose = true
+				current.lines = append(current.lines, "")
+				i = next
+				continue
+			}
+			if next < len(lines) && sameList(listItemRe.FindStringSubmatch(lines[next])) {
+				i = next
+				continue
+			}
+			break
+		}
+
+		// 缩进的后续行或段落延续行
+		if indentWidth(line) <= indent && startsBlock(line) {
+			break
+		}
+		current := entries[len(entries)-1]
+		current.lines = append(current.lines, dedent(line, current.indent))
+		i++
+	}
+
+	for _, entry := range entries {
+		item := &ListItem{Loose: entry.loose}
+		if entry.loose {
+			item.Blocks = parseBlocks(entry.lines)
+		} else {
+			// 紧凑列表项：首行及其延续行构成一个段落，其后为嵌套内容
+			end := 1
+			for end < len(entry.lines) && !startsBlock(entry.lines[end]) {
+				end++
+			}
+			var text []string
+			for _, line := range entry.lines[:end] {
+				text = append(text, strings.TrimSpace(line))
+			}
+			item.Blocks = append([]Block{&Paragraph{Content: parseInline(strings.Join(text, "\n"))}}, parseBlocks(entry.lines[end:])...)
+		}
+		list.Items = append(list.Items, item)
+	}
+	return list, i
+}
+
+// isTableStart 判断是否为表格：表头行后紧跟分隔行
+func isTableStart(lines []string, i int) bool {
+	if i+1 >= len(lines) || !strings.Contains(lines[i], "|") || !tableDelimRe.MatchString(lines[i+1]) {
+		return false
+	}
+	return len(splitTableRow(lines[i])) == len(splitTableRow(lines[i+1]))
+}
+
+// parseTable 解析表格，返回下一行的位置
+func parseTable(lines []string, start int) (*Table, int) {
+	table := &Table{}
+	for _, cell := range splitTableRow(lines[start+1]) {
+		left, right := strings.HasPrefix(cell, ":"), strings.HasSuffix(cell, ":")
+		switch {
+		case left && right:
+			table.Align = append(table.Align, AlignCenter)
+		case left:
+			table.Align = append(table.Align, AlignLeft)
+		case right:
+			table.Align = append(table.Align, AlignRight)
+		default:
+			table.Align = append(table.Align, AlignNone)
+		}
+	}
+	columns := len(table.Align)
+	table.Header = parseTableCells(lines[start], columns)
+
+	i := start + 2
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if strings.TrimSpace(line) == "" || !strings.Contains(line, "|") {
+			break
+		}
+		table.Rows = append(table.Rows, parseTableCells(line, columns))
+	}
+	return table, i
+}
+
+// parseTableCells 解析一行单元格，补齐或截断到指定列数
+func parseTableCells(line string, columns int) [][]Inline {
+	cells := splitTableRow(line)
+	row := make([][]Inline, columns)
+	for j := 0; j < columns && j < len(cells); j++ {
+		row[j] = parseInline(cells[j])
+	}
+	return row
+}
+
+// splitTableRow 按未转义且不在行内代码中的'|'拆分单元格
+func splitTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, "\\|") {
+		line = line[:len(line)-1]
+	}
+
+	var cells []string
+	var cell strings.Builder
+	inCode := false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case c == '`':
+			inCode = !inCode
+			cell.WriteByte(c)
+		case c == '|' && !inCode:
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(c)
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// indentWidth 返回行首缩进的宽度，制表符按4列对齐
+func indentWidth(line string) int {
+	width := 0
+	for _, c := range line {
+		switch c {
+		case ' ':
+			width++
+		case '\t':
+			width += 4 - width%4
+		default:
+			return width
+		}
+	}
+	return width
+}
+
+// dedent 去掉最多n列行首缩进，其余内容原样保留；制表符跨越第n列时剩余的缩进展开为空格
+func dedent(line string, n int) string {
+	width := 0
+	for i, c := range line {
+		if width >= n {
+			return line[i:]
+		}
+		switch c {
+		case ' ':
+			width++
+		case '\t':
+			next := width + 4 - width%4
+			if next > n {
+				rest := strings.TrimLeft(line, " \t")
+				return strings.Repeat(" ", indentWidth(line)-n) + rest
+			}
+			width = next
+		default:
+			return line[i:]
+		}
+	}
+	return ""
+}
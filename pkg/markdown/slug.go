@@ -0,0 +1,74 @@
+package markdown
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// SlugStyle 标题锚点的生成规则
+type SlugStyle string
+
+// 支持的锚点风格
+const (
+	SlugGitHub SlugStyle = "github" // GitHub：转小写，去掉标点（含全角标点），空格替换为'-'
+	SlugTypora SlugStyle = "typora" // Typora：转小写，去掉ASCII标点，保留全角标点，空白替换为'-'
+)
+
+// ParseSlugStyle 解析锚点风格，为空时使用GitHub风格
+func ParseSlugStyle(s string) (SlugStyle, error) {
+	switch style := SlugStyle(strings.ToLower(strings.TrimSpace(s))); style {
+	case "":
+		return SlugGitHub, nil
+	case SlugGitHub, SlugTypora:
+		return style, nil
+	}
+	return "", fmt.Errorf("不支持的锚点风格: %s（支持 github, typora）", s)
+}
+
+// Slug 按指定风格将标题文字转换为锚点
+func Slug(text string, style SlugStyle) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(text)) {
+		switch {
+		case r == ' ' || (style == SlugTypora && unicode.IsSpace(r)):
+			b.WriteRune('-')
+		case r == '-' || r == '_':
+			b.WriteRune(r)
+		case style == SlugTypora:
+			if r >= 0x80 || unicode.IsLetter(r) || unicode.IsDigit(r) {
+				b.WriteRune(r)
+			}
+		case unicode.In(r, unicode.L, unicode.M, unicode.N, unicode.Pc):
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Slugger 为同一文档中的标题生成不重复的锚点，重复的锚点依次加上-1、-2后缀
+type Slugger struct {
+	style SlugStyle
+	seen  map[string]int
+}
+
+// NewSlugger 创建锚点生成器
+func NewSlugger(style SlugStyle) *Slugger {
+	return &Slugger{style: style, seen: make(map[string]int)}
+}
+
+// Slug 生成标题锚点
+func (s *Slugger) Slug(text string) string {
+	slug := Slug(text, s.style)
+	result := slug
+	for {
+		if _, exists := s.seen[result]; !exists {
+			break
+		}
+		s.seen[slug]++
+		result = slug + "-" + strconv.Itoa(s.seen[slug])
+	}
+	s.seen[result] = 0
+	return result
+}
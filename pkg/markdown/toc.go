@@ -0,0 +1,159 @@
+package markdown
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// TOCMarker 目录标记，单独占一行，渲染后展开为目录
+const TOCMarker = "[TOC]"
+
+// DefaultTOCDepth 默认目录深度：包含1-3级标题
+const DefaultTOCDepth = 3
+
+// tocMarkerRe 单独一行的目录标记（不区分大小写）
+var tocMarkerRe = regexp.MustCompile(`(?i)^[ \t]{0,3}\[TOC\][ \t]*$`)
+
+// TOCOptions 目录选项
+type TOCOptions struct {
+	Depth int       // 包含的最大标题级别，0表示默认值
+	Style SlugStyle // 锚点风格
+}
+
+// Headings 按文档顺序返回所有标题，包括引用和列表中的标题
+func (d *Document) Headings() []*Heading {
+	var headings []*Heading
+	var walk func(blocks []Block)
+	walk = func(blocks []Block) {
+		for _, block := range blocks {
+			switch b := block.(type) {
+			case *Heading:
+				headings = append(headings, b)
+			case *Quote:
+				walk(b.Blocks)
+			case *List:
+				for _, item := range b.Items {
+					walk(item.Blocks)
+				}
+			}
+		}
+	}
+	walk(d.Blocks)
+	return headings
+}
+
+// AssignIDs 按指定风格为所有标题生成锚点
+func (d *Document) AssignIDs(style SlugStyle) {
+	slugger := NewSlugger(style)
+	for _, h := range d.Headings() {
+		h.ID = slugger.Slug(PlainText(h.Content))
+	}
+}
+
+// InsertTOC 将单独一行的目录标记展开为带锚点链接的嵌套列表，返回展开后的内容和展开的标记数量
+//
+// 目录包含标记之后、级别不超过Depth的标题，因此文档标题和"目录"标题本身不会出现在目录中；
+// 代码块中的标记保持不变
+func InsertTOC(src string, opts TOCOptions) (string, int) {
+	depth := opts.Depth
+	if depth <= 0 {
+		depth = DefaultTOCDepth
+	}
+	lines := strings.Split(src, "\n")
+	markers := tocMarkerLines(lines)
+	if len(markers) == 0 {
+		return src, 0
+	}
+
+	doc := Parse(src)
+	doc.AssignIDs(opts.Style)
+	headings := doc.Headings()
+
+	for i := len(markers) - 1; i >= 0; i-- {
+		line := markers[i]
+		// 标记之前的标题数量，用于确定目录的起始标题
+		before := len(Parse(strings.Join(lines[:line], "\n")).Headings())
+		toc := tocList(headings[before:], depth)
+		lines = append(lines[:line], append(toc, lines[line+1:]...)...)
+	}
+	return strings.Join(lines, "\n"), len(markers)
+}
+
+// tocMarkerLines 返回代码块之外的目录标记所在行
+func tocMarkerLines(lines []string) []int {
+	var markers []int
+	code := CodeLines(lines)
+	for i, line := range lines {
+		if !code[i] && tocMarkerRe.MatchString(line) {
+			markers = append(markers, i)
+		}
+	}
+	return markers
+}
+
+// CodeLines 标记属于代码块的行，逐行处理源文本时用于跳过代码块
+//
+// 包括围栏代码块（含围栏本身）和缩进代码块：空行之后缩进至少4个空格的行，
+// 列表之后缩进的内容属于列表项，不视为代码
+func CodeLines(lines []string) []bool {
+	code := make([]bool, len(lines))
+	fence := ""
+	prevBlank, inList, indented := true, false, false
+	for i, line := range lines {
+		if m := fenceRe.FindStringSubmatch(line); m != nil {
+			code[i] = true
+			switch {
+			case fence == "":
+				fence = m[1]
+			case strings.HasPrefix(strings.TrimSpace(line), fence) && strings.Trim(strings.TrimSpace(line), fence[:1]) == "":
+				fence = ""
+			}
+			prevBlank, indented = false, false
+			continue
+		}
+		if fence != "" {
+			code[i] = true
+			continue
+		}
+
+		blank := strings.TrimSpace(line) == ""
+		switch {
+		case blank:
+		case indentWidth(line) >= 4 && (indented || prevBlank && !inList):
+			code[i], indented = true, true
+		default:
+			indented = false
+			if listItemRe.MatchString(line) {
+				inList = true
+			} else if prevBlank && indentWidth(line) == 0 {
+				inList = false
+			}
+		}
+		prevBlank = blank
+	}
+	return code
+}
+
+// tocEscaper 转义目录链接文字中的Markdown标记字符，使标题文字原样显示
+var tocEscaper = strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`, "*", `\*`, "_", `\_`, "`", "\\`", "~", `\~`, "<", `\<`)
+
+// tocList 生成目录列表，缩进相对于最高级别的标题
+func tocList(headings []*Heading, depth int) []string {
+	minLevel := 0
+	for _, h := range headings {
+		if h.Level <= depth && (minLevel == 0 || h.Level < minLevel) {
+			minLevel = h.Level
+		}
+	}
+
+	var lines []string
+	for _, h := range headings {
+		if h.Level > depth {
+			continue
+		}
+		text := tocEscaper.Replace(PlainText(h.Content))
+		lines = append(lines, fmt.Sprintf("%s- [%s](#%s)", strings.Repeat("  ", h.Level-minLevel), text, h.ID))
+	}
+	return lines
+}
@@ -0,0 +1,130 @@
+package markdown
+
+import "testing"
+
+func TestSlug(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		github string
+		typora string
+	}{
+		{"英文", "Getting Started", "getting-started", "getting-started"},
+		{"中文", "安装指南", "安装指南", "安装指南"},
+		{"中英混排", "第1章 安装 MySQL", "第1章-安装-mysql", "第1章-安装-mysql"},
+		{"ASCII标点", "What's new? (v1.0.0)", "whats-new-v100", "whats-new-v100"},
+		{"全角标点", "配置：数据库（可选）", "配置数据库可选", "配置：数据库（可选）"},
+		{"连字符和下划线", "api_key - 说明", "api_key---说明", "api_key---说明"},
+		{"制表符", "a\tb", "ab", "a-b"},
+		{"首尾空白", "  概述  ", "概述", "概述"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := Slug(tt.text, SlugGitHub); result != tt.github {
+				t.Errorf("Slug(%q, github) = %q, 期望 %q", tt.text, result, tt.github)
+			}
+			if result := Slug(tt.text, SlugTypora); result != tt.typora {
+				t.Errorf("Slug(%q, typora) = %q, 期望 %q", tt.text, result, tt.typora)
+			}
+		})
+	}
+}
+
+func TestSlugger(t *testing.T) {
+	slugger := NewSlugger(SlugGitHub)
+	var result []string
+	for _, text := range []string{"概述", "概述", "概述-1", "概述"} {
+		result = append(result, slugger.Slug(text))
+	}
+	expected := []string{"概述", "概述-1", "概述-1-1", "概述-2"}
+	for i := range expected {
+		if result[i] != expected[i] {
+			t.Errorf("重复标题的锚点 = %q, 期望 %q", result, expected)
+			break
+		}
+	}
+
+	if _, err := ParseSlugStyle("gitlab"); err == nil {
+		t.Error("不支持的锚点风格应返回错误")
+	}
+	if style, err := ParseSlugStyle(" Typora "); err != nil || style != SlugTypora {
+		t.Errorf("ParseSlugStyle(Typora) = %q, %v", style, err)
+	}
+}
+
+func TestInsertTOC(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		opts     TOCOptions
+		expected string
+		count    int
+	}{
+		{
+			"默认深度",
+			"# 手册\n\n[TOC]\n\n## 安装\n### 下载\n#### 校验\n## 配置",
+			TOCOptions{},
+			"# 手册\n\n- [安装](#安装)\n  - [下载](#下载)\n- [配置](#配置)\n\n## 安装\n### 下载\n#### 校验\n## 配置",
+			1,
+		},
+		{
+			"指定深度",
+			"[toc]\n# 一\n## 二\n### 三",
+			TOCOptions{Depth: 1},
+			"- [一](#一)\n# 一\n## 二\n### 三",
+			1,
+		},
+		{
+			"Typora锚点",
+			"[TOC]\n## 配置：数据库\n## 配置：数据库",
+			TOCOptions{Style: SlugTypora},
+			"- [配置：数据库](#配置：数据库)\n- [配置：数据库](#配置：数据库-1)\n## 配置：数据库\n## 配置：数据库",
+			1,
+		},
+		{
+			"转义标记字符",
+			"[TOC]\n## 参数 `max_size` [可选]",
+			TOCOptions{},
+			"- [参数 max\\_size \\[可选\\]](#参数-max_size-可选)\n## 参数 `max_size` [可选]",
+			1,
+		},
+		{
+			"代码块中的标记",
+			"```\n[TOC]\n```\n## 一",
+			TOCOptions{},
+			"```\n[TOC]\n```\n## 一",
+			0,
+		},
+		{
+			"行内标记不展开",
+			"见 [TOC] 说明\n## 一",
+			TOCOptions{},
+			"见 [TOC] 说明\n## 一",
+			0,
+		},
+		{
+			"多个标记",
+			"[TOC]\n# 一\n[TOC]\n## 二",
+			TOCOptions{},
+			"- [一](#一)\n  - [二](#二)\n# 一\n- [二](#二)\n## 二",
+			2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, count := InsertTOC(tt.input, tt.opts)
+			if result != tt.expected || count != tt.count {
+				t.Errorf("InsertTOC(%q)\n期望: %q (%d)\n实际: %q (%d)", tt.input, tt.expected, tt.count, result, count)
+			}
+		})
+	}
+
+	// 目录链接与HTML中的标题锚点一致
+	result, _ := InsertTOC("[TOC]\n## 安装 MySQL", TOCOptions{})
+	expected := "<ul>\n<li><a href=\"#安装-mysql\">安装 MySQL</a></li>\n</ul>\n<h2 id=\"安装-mysql\">安装 MySQL</h2>\n"
+	if html := ToHTML(result); html != expected {
+		t.Errorf("目录HTML\n期望: %q\n实际: %q", expected, html)
+	}
+}
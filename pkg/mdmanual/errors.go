@@ -0,0 +1,76 @@
+package mdmanual
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidJob 任务参数无效，所有*JobError都可以用errors.Is(err, ErrInvalidJob)判断
+var ErrInvalidJob = errors.New("无效的渲染任务")
+
+// JobError 任务参数错误，如缺少模板、版本号格式错误、选项取值无效
+type JobError struct {
+	Field  string // 出错的字段，如 "Version"、"Options.SlugStyle"
+	Reason string
+}
+
+func (e *JobError) Error() string {
+	return fmt.Sprintf("%v: %s %s", ErrInvalidJob, e.Field, e.Reason)
+}
+
+// Is 使errors.Is(err, ErrInvalidJob)成立
+func (e *JobError) Is(target error) bool {
+	return target == ErrInvalidJob
+}
+
+// TemplateError 读取、解析或执行模板失败；模板不存在时可以用errors.Is(err, fs.ErrNotExist)判断
+type TemplateError struct {
+	Path string
+	Err  error
+}
+
+func (e *TemplateError) Error() string {
+	return fmt.Sprintf("渲染模板 %s 失败: %v", e.Path, e.Err)
+}
+
+func (e *TemplateError) Unwrap() error {
+	return e.Err
+}
+
+// ProcessError 渲染后处理失败，如交叉引用的标签不存在
+type ProcessError struct {
+	Stage string // 处理阶段：numbering（编号和交叉引用）
+	Err   error
+}
+
+func (e *ProcessError) Error() string {
+	return fmt.Sprintf("处理失败（%s）: %v", e.Stage, e.Err)
+}
+
+func (e *ProcessError) Unwrap() error {
+	return e.Err
+}
+
+// ImageError 文档引用的本地图片在FS中不存在（仅在Options.StrictImages时返回）
+type ImageError struct {
+	Missing []string // 找不到的图片引用
+}
+
+func (e *ImageError) Error() string {
+	return fmt.Sprintf("找不到 %d 个图片: %s", len(e.Missing), strings.Join(e.Missing, ", "))
+}
+
+// OutputError 写入输出失败
+type OutputError struct {
+	Output string // 输出：markdown、html
+	Err    error
+}
+
+func (e *OutputError) Error() string {
+	return fmt.Sprintf("写入%s失败: %v", e.Output, e.Err)
+}
+
+func (e *OutputError) Unwrap() error {
+	return e.Err
+}
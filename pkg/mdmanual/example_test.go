@@ -0,0 +1,69 @@
+package mdmanual_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"md-manual-tool/pkg/markdown"
+	"md-manual-tool/pkg/mdmanual"
+	"testing/fstest"
+)
+
+func ExampleRender() {
+	fsys := fstest.MapFS{
+		"docs/手册_3.2.0.md":     {Data: []byte("# 手册 V3.2.0\n\n## 安装\n\n![架构](images/arch.png)\n\n依赖 MySQL 3.2.0\n")},
+		"docs/images/arch.png": {Data: []byte("png")},
+	}
+
+	var out bytes.Buffer
+	result, err := mdmanual.Render(context.Background(), mdmanual.Job{
+		FS:       fsys,
+		Template: "docs/手册_3.2.0.md",
+		Version:  "3.3.0",
+		Options: mdmanual.Options{
+			Numbering: markdown.NumberingOptions{Headings: true},
+			Version:   mdmanual.VersionOptions{Exclude: `MySQL [\d.]+`},
+		},
+		Markdown: &out,
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Print(out.String())
+	for _, r := range result.Replacements {
+		status := "替换"
+		if !r.Replaced {
+			status = "跳过（" + r.Reason + "）"
+		}
+		fmt.Printf("第%d行 %s %s\n", r.Line, r.Text, status)
+	}
+	fmt.Println(result.Images[0].Path, result.Images[0].Missing)
+	// Output:
+	// # 手册 V3.3.0
+	//
+	// ## 第1章 安装
+	//
+	// ![架构](images/arch.png)
+	//
+	// 依赖 MySQL 3.2.0
+	// 第1行 V3.2.0 替换
+	// 第7行 3.2.0 跳过（匹配排除规则）
+	// docs/images/arch.png false
+}
+
+func ExampleRender_errors() {
+	fsys := fstest.MapFS{"a.md": {Data: []byte("见{{ref \"fig:none\"}}")}}
+
+	_, err := mdmanual.Render(context.Background(), mdmanual.Job{FS: fsys, Template: "a.md", Version: "3.x"})
+	fmt.Println(errors.Is(err, mdmanual.ErrInvalidJob))
+
+	_, err = mdmanual.Render(context.Background(), mdmanual.Job{FS: fsys, Template: "a.md"})
+	var processErr *mdmanual.ProcessError
+	fmt.Println(errors.As(err, &processErr), processErr.Stage)
+	// Output:
+	// true
+	// true numbering
+}
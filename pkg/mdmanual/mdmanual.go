@@ -0,0 +1,349 @@
+// Package mdmanual 将Markdown手册模板渲染为指定版本的文档，供其他Go程序嵌入使用
+//
+// 与命令行工具不同，库不读写本地文件、不向标准输出打印：模板和图片从调用方提供的fs.FS读取，
+// 渲染结果写入调用方提供的io.Writer，处理过程通过Result和类型化的错误返回。
+//
+//	result, err := mdmanual.Render(ctx, mdmanual.Job{
+//		FS:       os.DirFS("docs"),
+//		Template: "手册_3.2.0.md",
+//		Version:  "3.3.0",
+//		Markdown: &buf,
+//	})
+package mdmanual
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"md-manual-tool/pkg/constants"
+	"md-manual-tool/pkg/export"
+	"md-manual-tool/pkg/i18n"
+	"md-manual-tool/pkg/markdown"
+	"md-manual-tool/pkg/profile"
+	"md-manual-tool/pkg/template"
+	"md-manual-tool/pkg/utils"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Job 一次渲染任务
+type Job struct {
+	FS        fs.FS             // 模板和图片所在的文件系统
+	Template  string            // 模板在FS中的路径（以/分隔），文件名中的 _x.y.z 为原版本号
+	Version   string            // 新版本号（x.y.z），为空时不替换版本号
+	Variables map[string]string // 模板变量，与命令行工具的配置项相同
+	Options   Options
+
+	Markdown io.Writer // 渲染后的Markdown，为nil时不输出
+	HTML     io.Writer // 渲染后的HTML页面（内嵌样式），为nil时不输出
+	Log      io.Writer // 渲染过程的日志，为nil时不输出
+}
+
+// Options 渲染选项，零值表示不编号、使用默认目录深度、github锚点风格和默认主题
+type Options struct {
+	Numbering    markdown.NumberingOptions
+	TOCDepth     int                // [TOC]目录包含的最大标题级别（1-6），0表示默认值
+	SlugStyle    markdown.SlugStyle // 标题锚点风格
+	HTMLTheme    string             // HTML内置主题名，见export.ThemeNames
+	Version      VersionOptions
+	StrictImages bool // 引用的本地图片不存在时返回*ImageError
+
+	// 以下选项设置后覆盖Variables中的同名项（profile、lang、i18nDir）
+	Profile profile.Profile // 部署画像，用于筛选 <!-- if --> 条件内容和模板函数has
+	Lang    string          // 语言版本，模板中的 {{t "键"}} 从FS中的 I18nDir/<Lang>.yaml 读取译文
+	I18nDir string          // 消息目录所在目录（FS中的路径），默认 i18n
+}
+
+// VersionOptions 版本号替换选项，对应命令行工具的version*配置项；设置后覆盖Variables中的同名项
+type VersionOptions struct {
+	Disable       bool     // 不按文本替换原版本号（模板使用 {{.version}} 占位符时）
+	Prefixes      []string // 允许紧贴版本号的前缀，为空时使用默认前缀
+	RequirePrefix bool     // 只替换带前缀的版本号
+	Exclude       string   // 排除规则（正则）
+	Protect       string   // 保护区域（正则）
+	Date          string   // 版本发布日期，为空时为当天
+}
+
+// Result 渲染结果
+type Result struct {
+	OldVersion    string        // 从模板文件名提取的原版本号
+	Version       string        // 新版本号
+	Replacements  []Replacement // 版本号文本替换的逐处结果
+	Sections      []Section     // 条件内容的处理结果
+	Checks        []Check       // 模板函数has的判断结果，按首次出现的顺序
+	Numbering     Numbering
+	TOCs          int     // 展开的目录标记数量
+	Images        []Image // 文档引用的图片
+	MarkdownBytes int     // 渲染后Markdown的大小
+}
+
+// Replacement 模板中一处原版本号及其处理结果
+type Replacement struct {
+	Line     int    // 行号，从1开始
+	Text     string // 原文（含前缀）
+	Replaced bool   // 是否替换
+	Reason   string // 未替换的原因
+}
+
+// Section 一段条件内容（<!-- if 条件 --> 或 <!-- else -->）及其处理结果
+type Section struct {
+	Line      int    // 条件标记所在行，从1开始
+	Condition string // 条件；else分支为 "else（原条件）"
+	Included  bool   // 是否保留
+	Lines     int    // 条件内容的行数（不含标记和嵌套的条件内容）
+}
+
+// Check 模板函数has的一种判断及其结果
+type Check struct {
+	Want   string // has的参数，如 linux 或 os=linux
+	Result bool
+	Count  int // 判断次数
+}
+
+// Numbering 编号统计
+type Numbering struct {
+	Headings int
+	Figures  int
+	Tables   int
+	Refs     int // 解析的交叉引用数量
+}
+
+// Image 文档引用的图片
+type Image struct {
+	Ref     string // 文档中的引用
+	Path    string // 在FS中的路径，远程图片为空
+	Remote  bool   // 是否为远程图片
+	Missing bool   // 本地图片在FS中不存在
+}
+
+// Render 执行渲染任务
+//
+// 参数错误返回*JobError，模板、条件内容或消息目录错误返回*TemplateError，编号和交叉引用错误返回*ProcessError，
+// 写入失败返回*OutputError；ctx取消时返回ctx.Err()
+func Render(ctx context.Context, job Job) (*Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	variables, err := job.variables()
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := fs.ReadFile(job.FS, job.Template)
+	if err != nil {
+		return nil, &TemplateError{Path: job.Template, Err: err}
+	}
+	// 画像在variables中已校验
+	prof, _ := profile.Parse(variables[constants.ConfigKeyProfile])
+	filtered, sections, err := profile.Filter(string(content), prof)
+	if err != nil {
+		return nil, &TemplateError{Path: job.Template, Err: err}
+	}
+
+	renderer := template.NewRenderer()
+	renderer.Log = job.Log
+	if lang := variables[constants.ConfigKeyLang]; lang != "" {
+		dir := variables[constants.ConfigKeyI18nDir]
+		if dir == "" {
+			dir = i18n.DefaultDir
+		}
+		if renderer.Catalog, err = i18n.LoadCatalog(job.FS, dir, lang); err != nil {
+			return nil, &TemplateError{Path: path.Join(dir, lang+".yaml"), Err: err}
+		}
+	}
+	rendered, err := renderer.Execute(job.Template, filtered, variables)
+	if err != nil {
+		return nil, &TemplateError{Path: job.Template, Err: err}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	result := &Result{OldVersion: rendered.OldVersion, Version: job.Version}
+	for _, occ := range rendered.Occurrences {
+		result.Replacements = append(result.Replacements, Replacement{
+			Line: occ.Line, Text: occ.Text, Replaced: occ.Replaced, Reason: occ.Reason,
+		})
+	}
+	for _, s := range sections {
+		result.Sections = append(result.Sections, Section{
+			Line: s.Line, Condition: s.Condition, Included: s.Included, Lines: s.Lines,
+		})
+	}
+	for _, c := range rendered.Checks {
+		result.Checks = append(result.Checks, Check{Want: c.Want, Result: c.Result, Count: c.Count})
+	}
+
+	numbered, err := markdown.Number(string(rendered.Content), job.Options.Numbering)
+	if err != nil {
+		return nil, &ProcessError{Stage: "numbering", Err: err}
+	}
+	result.Numbering = Numbering{
+		Headings: numbered.Headings, Figures: numbered.Figures, Tables: numbered.Tables, Refs: numbered.Refs,
+	}
+	output, tocs := markdown.InsertTOC(numbered.Content, markdown.TOCOptions{
+		Depth: job.Options.TOCDepth, Style: job.Options.SlugStyle,
+	})
+	result.TOCs = tocs
+	result.MarkdownBytes = len(output)
+
+	doc := markdown.Parse(output)
+	result.Images = job.images(doc)
+	if job.Options.StrictImages {
+		var missing []string
+		for _, img := range result.Images {
+			if img.Missing {
+				missing = append(missing, img.Ref)
+			}
+		}
+		if len(missing) > 0 {
+			return nil, &ImageError{Missing: missing}
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if job.Markdown != nil {
+		if _, err := io.WriteString(job.Markdown, output); err != nil {
+			return nil, &OutputError{Output: "markdown", Err: err}
+		}
+	}
+	if job.HTML != nil {
+		if _, err := job.HTML.Write(job.page(doc, variables[constants.ConfigKeyLang])); err != nil {
+			return nil, &OutputError{Output: "html", Err: err}
+		}
+	}
+	return result, nil
+}
+
+// variables 校验任务参数，并将版本号和选项合并到模板变量的副本中
+func (job *Job) variables() (map[string]string, error) {
+	if job.FS == nil {
+		return nil, &JobError{Field: "FS", Reason: "不能为空"}
+	}
+	if job.Template == "" || !fs.ValidPath(job.Template) {
+		return nil, &JobError{Field: "Template", Reason: "必须是FS中以/分隔的相对路径: " + strconv.Quote(job.Template)}
+	}
+	if job.Version != "" {
+		if _, err := utils.ParseVersion(job.Version); err != nil {
+			return nil, &JobError{Field: "Version", Reason: err.Error()}
+		}
+	}
+
+	opts := job.Options
+	if opts.TOCDepth < 0 || opts.TOCDepth > 6 {
+		return nil, &JobError{Field: "Options.TOCDepth", Reason: "必须在1到6之间"}
+	}
+	if opts.Numbering.ChapterLevel < 0 || opts.Numbering.ChapterLevel > 6 {
+		return nil, &JobError{Field: "Options.Numbering.ChapterLevel", Reason: "必须在1到6之间"}
+	}
+	if _, err := markdown.ParseSlugStyle(string(opts.SlugStyle)); err != nil {
+		return nil, &JobError{Field: "Options.SlugStyle", Reason: err.Error()}
+	}
+	if opts.HTMLTheme != "" && !isBuiltinTheme(opts.HTMLTheme) {
+		return nil, &JobError{Field: "Options.HTMLTheme", Reason: "不是内置主题: " + opts.HTMLTheme}
+	}
+
+	variables := make(map[string]string, len(job.Variables)+11)
+	for key, value := range job.Variables {
+		variables[key] = value
+	}
+	if job.Version != "" {
+		variables["version"] = job.Version
+	}
+	v := opts.Version
+	if v.Disable {
+		variables[constants.ConfigKeyVersionReplace] = "false"
+	}
+	if len(v.Prefixes) > 0 {
+		variables[constants.ConfigKeyVersionPrefixes] = strings.Join(v.Prefixes, ",")
+	}
+	if v.RequirePrefix {
+		variables[constants.ConfigKeyVersionRequirePrefix] = "true"
+	}
+	if v.Exclude != "" {
+		variables[constants.ConfigKeyVersionExclude] = v.Exclude
+	}
+	if v.Protect != "" {
+		variables[constants.ConfigKeyVersionProtect] = v.Protect
+	}
+	if v.Date != "" {
+		variables[constants.ConfigKeyVersionDate] = v.Date
+	}
+	if _, err := template.ParseVersionRules(variables); err != nil {
+		return nil, &JobError{Field: "Options.Version", Reason: err.Error()}
+	}
+
+	if len(opts.Profile) > 0 {
+		variables[constants.ConfigKeyProfile] = opts.Profile.String()
+	}
+	if _, err := profile.Parse(variables[constants.ConfigKeyProfile]); err != nil {
+		return nil, &JobError{Field: "Options.Profile", Reason: err.Error()}
+	}
+	if opts.Lang != "" {
+		variables[constants.ConfigKeyLang] = opts.Lang
+	}
+	if opts.I18nDir != "" {
+		variables[constants.ConfigKeyI18nDir] = opts.I18nDir
+	}
+	if lang := variables[constants.ConfigKeyLang]; lang != "" {
+		if err := i18n.ValidateLang(lang); err != nil {
+			return nil, &JobError{Field: "Options.Lang", Reason: err.Error()}
+		}
+	}
+	if dir := variables[constants.ConfigKeyI18nDir]; dir != "" && !fs.ValidPath(dir) {
+		return nil, &JobError{Field: "Options.I18nDir", Reason: "必须是FS中以/分隔的相对路径: " + strconv.Quote(dir)}
+	}
+	return variables, nil
+}
+
+// images 列出文档引用的图片，本地图片相对于模板所在目录在FS中查找
+func (job *Job) images(doc *markdown.Document) []Image {
+	var images []Image
+	dir := path.Dir(job.Template)
+	for _, img := range doc.Images() {
+		image := Image{Ref: img.Src}
+		switch {
+		case utils.IsRemoteImage(img.Src):
+			image.Remote = true
+		case strings.HasPrefix(img.Src, "data:"):
+		default:
+			src := img.Src
+			if unescaped, err := url.PathUnescape(src); err == nil {
+				src = unescaped
+			}
+			image.Path = path.Join(dir, src)
+			if !fs.ValidPath(image.Path) {
+				image.Missing = true
+			} else if _, err := fs.Stat(job.FS, image.Path); err != nil {
+				image.Missing = true
+			}
+		}
+		images = append(images, image)
+	}
+	return images
+}
+
+// page 生成内嵌主题样式的HTML页面，lang为页面语言，图片引用保持Markdown中的路径
+func (job *Job) page(doc *markdown.Document, lang string) []byte {
+	doc.AssignIDs(job.Options.SlugStyle)
+	css, _ := export.LoadTheme(nil, job.Options.HTMLTheme, "")
+	title := strings.TrimSuffix(path.Base(job.Template), path.Ext(job.Template))
+	if headings := doc.Headings(); len(headings) > 0 {
+		title = markdown.PlainText(headings[0].Content)
+	}
+	return export.Page(lang, title, "<style>\n"+css+"</style>", doc.HTML(), "")
+}
+
+// isBuiltinTheme 判断是否为内置主题
+func isBuiltinTheme(name string) bool {
+	for _, theme := range export.ThemeNames() {
+		if theme == name {
+			return true
+		}
+	}
+	return false
+}
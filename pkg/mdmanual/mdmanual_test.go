@@ -0,0 +1,140 @@
+package mdmanual
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"md-manual-tool/pkg/profile"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// failWriter 总是写入失败
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) { return 0, errors.New("磁盘已满") }
+
+func TestRender(t *testing.T) {
+	fsys := fstest.MapFS{
+		"手册_1.0.0.md": {Data: []byte("# 手册 {{.productName}} v1.0.0\n\n[TOC]\n\n## 安装\n\n![图](a.png) ![远程](https://a.com/b.png) ![缺失](img/none.png)\n")},
+		"a.png":       {Data: []byte("png")},
+	}
+	var md, page, log bytes.Buffer
+	result, err := Render(context.Background(), Job{
+		FS:        fsys,
+		Template:  "手册_1.0.0.md",
+		Version:   "1.1.0",
+		Variables: map[string]string{"productName": "星云"},
+		Options:   Options{HTMLTheme: "github"},
+		Markdown:  &md,
+		HTML:      &page,
+		Log:       &log,
+	})
+	if err != nil {
+		t.Fatalf("渲染失败: %v", err)
+	}
+
+	if !strings.HasPrefix(md.String(), "# 手册 星云 v1.1.0\n") || !strings.Contains(md.String(), "- [安装](#安装)") {
+		t.Errorf("Markdown错误: %q", md.String())
+	}
+	if result.OldVersion != "1.0.0" || result.TOCs != 1 || result.MarkdownBytes != md.Len() {
+		t.Errorf("结果错误: %+v", result)
+	}
+	if !strings.Contains(page.String(), "<title>手册 星云 v1.1.0</title>") || !strings.Contains(page.String(), `<h2 id="安装">`) {
+		t.Errorf("HTML错误: %q", page.String())
+	}
+	if !strings.Contains(log.String(), "版本号替换完成") {
+		t.Errorf("日志错误: %q", log.String())
+	}
+
+	want := []Image{{Ref: "a.png", Path: "a.png"}, {Ref: "https://a.com/b.png", Remote: true}, {Ref: "img/none.png", Path: "img/none.png", Missing: true}}
+	if len(result.Images) != len(want) {
+		t.Fatalf("图片错误: %+v", result.Images)
+	}
+	for i := range want {
+		if result.Images[i] != want[i] {
+			t.Errorf("图片 %d: 期望 %+v, 实际 %+v", i, want[i], result.Images[i])
+		}
+	}
+}
+
+func TestRenderProfileAndCatalog(t *testing.T) {
+	fsys := fstest.MapFS{
+		"手册.md":           {Data: []byte("# {{t \"title\"}}\n<!-- if os=linux -->\nLinux安装\n<!-- else -->\nWindows安装\n<!-- endif -->\n{{if has .profile \"enterprise\"}}企业版{{end}}\n")},
+		"lang/en-US.yaml": {Data: []byte("title: Manual\n")},
+	}
+	var md, page bytes.Buffer
+	result, err := Render(context.Background(), Job{
+		FS:        fsys,
+		Template:  "手册.md",
+		Variables: map[string]string{"profile": "os=windows"},
+		Options:   Options{Profile: profile.Profile{"os": "linux", "edition": "enterprise"}, Lang: "en-US", I18nDir: "lang"},
+		Markdown:  &md,
+		HTML:      &page,
+	})
+	if err != nil {
+		t.Fatalf("渲染失败: %v", err)
+	}
+	if expected := "# Manual\nLinux安装\n企业版\n"; md.String() != expected {
+		t.Errorf("Markdown错误: %q, 期望 %q", md.String(), expected)
+	}
+	if !strings.Contains(page.String(), `<html lang="en-US">`) {
+		t.Errorf("HTML页面语言错误: %q", page.String())
+	}
+	expectedSections := []Section{
+		{Line: 2, Condition: "os=linux", Included: true, Lines: 1},
+		{Line: 4, Condition: "else（os=linux）", Included: false, Lines: 1},
+	}
+	if !reflect.DeepEqual(result.Sections, expectedSections) {
+		t.Errorf("条件内容: %+v", result.Sections)
+	}
+	if expected := []Check{{Want: "enterprise", Result: true, Count: 1}}; !reflect.DeepEqual(result.Checks, expected) {
+		t.Errorf("has判断: %+v", result.Checks)
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.md":   {Data: []byte("# 手册\n![缺失](none.png)")},
+		"bad.md": {Data: []byte("{{.x")},
+	}
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var jobErr *JobError
+	var templateErr *TemplateError
+	var imageErr *ImageError
+	var outputErr *OutputError
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		job   Job
+		check func(error) bool
+	}{
+		{"缺少FS", context.Background(), Job{Template: "a.md"}, func(err error) bool { return errors.As(err, &jobErr) && jobErr.Field == "FS" }},
+		{"无效路径", context.Background(), Job{FS: fsys, Template: "/a.md"}, func(err error) bool { return errors.Is(err, ErrInvalidJob) }},
+		{"无效版本号", context.Background(), Job{FS: fsys, Template: "a.md", Version: "1.0"}, func(err error) bool { return errors.As(err, &jobErr) && jobErr.Field == "Version" }},
+		{"无效主题", context.Background(), Job{FS: fsys, Template: "a.md", Options: Options{HTMLTheme: "x.css"}}, func(err error) bool { return errors.Is(err, ErrInvalidJob) }},
+		{"无效排除规则", context.Background(), Job{FS: fsys, Template: "a.md", Options: Options{Version: VersionOptions{Exclude: "("}}}, func(err error) bool { return errors.Is(err, ErrInvalidJob) }},
+		{"模板不存在", context.Background(), Job{FS: fsys, Template: "none.md"}, func(err error) bool { return errors.As(err, &templateErr) && errors.Is(err, fs.ErrNotExist) }},
+		{"模板语法错误", context.Background(), Job{FS: fsys, Template: "bad.md"}, func(err error) bool { return errors.As(err, &templateErr) && templateErr.Path == "bad.md" }},
+		{"无效画像", context.Background(), Job{FS: fsys, Template: "a.md", Variables: map[string]string{"profile": "linux"}}, func(err error) bool { return errors.As(err, &jobErr) && jobErr.Field == "Options.Profile" }},
+		{"无效语言", context.Background(), Job{FS: fsys, Template: "a.md", Options: Options{Lang: "../en"}}, func(err error) bool { return errors.As(err, &jobErr) && jobErr.Field == "Options.Lang" }},
+		{"消息目录不存在", context.Background(), Job{FS: fsys, Template: "a.md", Options: Options{Lang: "en-US"}}, func(err error) bool { return errors.As(err, &templateErr) && templateErr.Path == "i18n/en-US.yaml" }},
+		{"缺失图片", context.Background(), Job{FS: fsys, Template: "a.md", Options: Options{StrictImages: true}}, func(err error) bool { return errors.As(err, &imageErr) && imageErr.Missing[0] == "none.png" }},
+		{"写入失败", context.Background(), Job{FS: fsys, Template: "a.md", HTML: failWriter{}}, func(err error) bool { return errors.As(err, &outputErr) && outputErr.Output == "html" }},
+		{"已取消", canceled, Job{FS: fsys, Template: "a.md"}, func(err error) bool { return errors.Is(err, context.Canceled) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Render(tt.ctx, tt.job)
+			if err == nil || !tt.check(err) {
+				t.Errorf("错误类型不符: %T %v", err, err)
+			}
+		})
+	}
+}
@@ -1,77 +1,455 @@
 package processor
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
+	"md-manual-tool/pkg/cache"
 	"md-manual-tool/pkg/config"
+	"md-manual-tool/pkg/constants"
+	"md-manual-tool/pkg/export"
+	"md-manual-tool/pkg/i18n"
+	"md-manual-tool/pkg/markdown"
+	"md-manual-tool/pkg/profile"
 	"md-manual-tool/pkg/template"
 	"md-manual-tool/pkg/utils"
+	"md-manual-tool/pkg/validator"
+	"md-manual-tool/pkg/vfs"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 )
 
 // Processor 处理器结构体
 type Processor struct {
-	config *config.Config
+	config     *config.Config
+	renderer   *template.Renderer
+	source     fs.FS       // 模板和图片所在的文件系统
+	output     vfs.WriteFS // 输出文件、图片和缓存写入的文件系统
+	cacheStats cache.Stats
 }
 
-// NewProcessor 创建新的处理器
+// NewProcessor 创建读写本地文件的处理器
 func NewProcessor(config *config.Config) *Processor {
+	return NewProcessorFS(config, vfs.OSFS{}, vfs.OSFS{})
+}
+
+// NewProcessorFS 创建从source读取模板和图片、向output写入结果的处理器
+//
+// 模板路径和输出路径按vfs的路径约定解释，如从ZIP包或embed.FS读取模板时使用包内的相对路径
+func NewProcessorFS(config *config.Config, source fs.FS, output vfs.WriteFS) *Processor {
 	return &Processor{
-		config: config,
+		config:   config,
+		renderer: template.NewRenderer(),
+		source:   source,
+		output:   output,
 	}
 }
 
 // Process 处理整个流程
-func (p *Processor) Process(templatePath, outputPath string) error {
-	// 1. 读取原始模板内容
-	templateContent, err := utils.ReadFile(templatePath)
+//
+// 输出文件、导出文件和图片先暂存为临时文件，全部生成成功后才替换原有输出；ctx被取消（如按下Ctrl+C）
+// 或超过jobTimeout配置的时长时中止生成并删除临时文件，原有输出保持不变
+func (p *Processor) Process(ctx context.Context, templatePath, outputPath string) (err error) {
+	timeout, err := p.config.GetDuration(constants.ConfigKeyJobTimeout, 0)
+	if err != nil {
+		return err
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	out := vfs.NewTxn(p.output)
+	defer func() {
+		pending := len(out.Pending())
+		if rollbackErr := out.Rollback(); rollbackErr != nil {
+			fmt.Print(i18n.Sprintf(constants.MsgRollbackFailed, rollbackErr))
+		} else if err != nil && pending > 0 {
+			fmt.Print(i18n.Sprintf(constants.MsgRolledBack, pending))
+		}
+		// 中断导致的失败统一报告为取消或超时
+		if err != nil {
+			if ctxErr := interrupted(ctx, timeout); ctxErr != nil {
+				err = ctxErr
+			}
+		}
+	}()
+
+	// 1. 读取原始模板内容，删除不符合部署画像的条件内容
+	templateContent, prof, sections, err := p.readTemplate(templatePath)
 	if err != nil {
-		return fmt.Errorf("读取模板文件失败: %v", err)
+		return err
 	}
 
 	// 2. 从原始模板中提取图片路径（在版本号替换之前）
 	imagePaths := utils.ExtractImages(string(templateContent))
-	fmt.Println("从原始模板中提取到的图片路径：")
-	for i, path := range imagePaths {
-		fmt.Printf("  %d. %s\n", i+1, path)
+
+	imageOptions, err := p.imageOptions()
+	if err != nil {
+		return fmt.Errorf("读取图片配置失败: %v", err)
 	}
+	imageOptions.Output = out
+	catalog, err := p.catalog()
+	if err != nil {
+		return err
+	}
+
+	// 3. 检查缓存：输入未变化且输出文件和图片完好时跳过
+	force, err := p.config.GetBool(constants.ConfigKeyForce, false)
+	if err != nil {
+		return err
+	}
+	renderCache := cache.OpenFS(p.output, filepath.Join(filepath.Dir(outputPath), cache.FileName))
+	renderCache.Force = force
+	defer func() {
+		p.cacheStats = renderCache.Stats
+		fmt.Print(i18n.Sprintf(constants.MsgCacheSummary, renderCache.Stats))
+	}()
+
+	jobKey := p.jobKey(templatePath, templateContent, imagePaths, imageOptions, catalog)
+	if !p.assetsIntact(outputPath, imageOptions) {
+		renderCache.Invalidate(outputPath)
+	}
+	if renderCache.JobUpToDate(outputPath, jobKey) {
+		fmt.Print(i18n.Sprintf(constants.MsgUpToDate, outputPath))
+		return nil
+	}
+	imageOptions.CopyCache = renderCache
 
-	// 3. 处理图片（复制到新目录）
+	// 4. 处理图片（复制到新目录）
+	// 即使模板中已没有图片也要执行，以清理图片目录中的旧图片
+	updatedContent, err := utils.CopyImagesFromTemplateWithOptions(ctx, templatePath, outputPath, imagePaths, string(templateContent), imageOptions)
+	if err != nil {
+		return fmt.Errorf("处理图片失败: %v", err)
+	}
+	templateContent = []byte(updatedContent)
 	if len(imagePaths) > 0 {
-		updatedContent, err := utils.CopyImagesFromTemplate(templatePath, outputPath, imagePaths, string(templateContent))
-		if err != nil {
-			return fmt.Errorf("处理图片失败: %v", err)
-		}
-		templateContent = []byte(updatedContent)
-		fmt.Printf("成功复制 %d 张图片\n", len(imagePaths))
+		fmt.Print(i18n.Sprintf(constants.MsgImagesCopied, len(imagePaths)))
 	}
 
-	// 4. 确保输出目录存在
-	if err := utils.EnsureDir(outputPath); err != nil {
+	// 5. 确保输出目录存在
+	if err := vfs.MkdirAll(out, filepath.Dir(outputPath)); err != nil {
 		return fmt.Errorf("创建输出目录失败: %v", err)
 	}
 
-	// 5. 渲染模板（在图片处理之后）
-	result, err := template.RenderWithContent(templatePath, string(templateContent), p.config.Variables)
+	// 6. 渲染模板（在图片处理之后）
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	rendered, err := p.render(templatePath, string(templateContent), catalog)
 	if err != nil {
 		return fmt.Errorf("渲染模板失败: %v", err)
 	}
+	profile.PrintReport(prof, sections, rendered.Checks)
+	result, err := p.postProcess(rendered.Content)
+	if err != nil {
+		return err
+	}
 
-	// 6. 写入结果文件
-	if err := utils.WriteFile(outputPath, result); err != nil {
+	// 7. 写入结果文件
+	if err := vfs.WriteFile(out, outputPath, result); err != nil {
 		return fmt.Errorf("写入结果文件失败: %v", err)
 	}
 
+	// 8. 导出其他格式
+	exported, err := p.exportFiles(ctx, templatePath, outputPath, out, result)
+	if err != nil {
+		return err
+	}
+	for _, file := range exported {
+		if err := vfs.WriteFile(out, file.Path, file.Data); err != nil {
+			return fmt.Errorf("写入导出文件失败: %v", err)
+		}
+	}
+
+	// 9. 全部生成后替换原有输出
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := out.Commit(); err != nil {
+		return fmt.Errorf("写入输出失败: %v", err)
+	}
+	for _, file := range exported {
+		fmt.Print(i18n.Sprintf(constants.MsgExported, file.Path))
+	}
+
+	// 10. 记录缓存
+	renderCache.RecordJob(outputPath, jobKey, result)
+	for _, file := range exported {
+		renderCache.RecordExtra(outputPath, file.Path, file.Data)
+	}
+	if err := renderCache.Save(); err != nil {
+		fmt.Print(i18n.Sprintf(constants.MsgSaveCacheFailed, err))
+	}
+
 	return nil
 }
 
+// interrupted 生成因ctx取消或超时而中断时返回对应的错误，否则返回nil
+func interrupted(ctx context.Context, timeout time.Duration) error {
+	switch err := ctx.Err(); {
+	case errors.Is(err, context.DeadlineExceeded):
+		return fmt.Errorf("生成超时（超过 %v），已放弃本次输出: %w", timeout, err)
+	case err != nil:
+		return fmt.Errorf("生成已取消，已放弃本次输出: %w", err)
+	}
+	return nil
+}
+
+// SourceFiles 返回生成文档所依赖的本地图片源文件和导出所用的文件（用于监视模式）
+func (p *Processor) SourceFiles(templatePath string) ([]string, error) {
+	templateContent, _, _, err := p.readTemplate(templatePath)
+	if err != nil {
+		return nil, err
+	}
+	imageOptions, err := p.imageOptions()
+	if err != nil {
+		return nil, fmt.Errorf("读取图片配置失败: %v", err)
+	}
+
+	var files []string
+	for _, imgPath := range utils.ExtractImages(string(templateContent)) {
+		if utils.IsRemoteImage(imgPath) {
+			continue
+		}
+		if resolved, err := imageOptions.Resolver.Resolve(imgPath, templatePath); err == nil {
+			files = append(files, resolved.Path)
+		}
+	}
+	return append(files, p.exportSources(templatePath)...), nil
+}
+
+// Preview 在内存中渲染的预览结果，不写入任何文件
+type Preview struct {
+	Markdown  []byte             // 渲染后的Markdown，本地图片引用已改写为预览地址
+	Images    map[string]string  // 预览地址（解码后的URL路径） -> 本地图片源文件
+	SlugStyle markdown.SlugStyle // 标题锚点风格
+	Lang      string             // 语言版本，未设置时为空
+}
+
+// Preview 在内存中渲染模板，本地图片引用改写为以urlPrefix开头的地址
+func (p *Processor) Preview(templatePath, urlPrefix string) (*Preview, error) {
+	templateContent, _, _, err := p.readTemplate(templatePath)
+	if err != nil {
+		return nil, err
+	}
+	imageOptions, err := p.imageOptions()
+	if err != nil {
+		return nil, fmt.Errorf("读取图片配置失败: %v", err)
+	}
+
+	preview := &Preview{Images: make(map[string]string), Lang: p.config.GetString(constants.ConfigKeyLang, "")}
+	mapping := make(map[string]string)
+	for i, imgPath := range utils.ExtractImages(string(templateContent)) {
+		if utils.IsRemoteImage(imgPath) {
+			continue
+		}
+		resolved, err := imageOptions.Resolver.Resolve(imgPath, templatePath)
+		if err != nil {
+			fmt.Print(i18n.Sprintf(constants.MsgWarning, err))
+			continue
+		}
+		// 以序号区分同名图片，文件名转义后写入文档，解码后的路径用于查找
+		base := filepath.Base(resolved.Path)
+		mapping[imgPath] = fmt.Sprintf("%s/%d/%s", urlPrefix, i, url.PathEscape(base))
+		preview.Images[fmt.Sprintf("%s/%d/%s", urlPrefix, i, base)] = resolved.Path
+	}
+
+	catalog, err := p.catalog()
+	if err != nil {
+		return nil, err
+	}
+	content := utils.RewriteImagePaths(string(templateContent), mapping)
+	rendered, err := p.render(templatePath, content, catalog)
+	if err != nil {
+		return nil, fmt.Errorf("渲染模板失败: %v", err)
+	}
+	if preview.Markdown, err = p.postProcess(rendered.Content); err != nil {
+		return nil, err
+	}
+	preview.SlugStyle, err = p.slugStyle()
+	return preview, err
+}
+
+// postProcess 渲染后处理：为标题、图片和表格编号并解析交叉引用，再将目录标记展开为目录
+func (p *Processor) postProcess(content []byte) ([]byte, error) {
+	numbering, err := p.numberingOptions()
+	if err != nil {
+		return nil, err
+	}
+	numbered, err := markdown.Number(string(content), numbering)
+	if err != nil {
+		return nil, fmt.Errorf("编号失败: %v", err)
+	}
+	if numbered.Headings+numbered.Figures+numbered.Tables+numbered.Refs > 0 {
+		fmt.Print(i18n.Sprintf(constants.MsgNumbered,
+			numbered.Headings, numbered.Figures, numbered.Tables, numbered.Refs))
+	}
+
+	style, err := p.slugStyle()
+	if err != nil {
+		return nil, err
+	}
+	depth, err := p.config.GetInt(constants.ConfigKeyTOCDepth, markdown.DefaultTOCDepth)
+	if err != nil {
+		return nil, err
+	}
+	if depth < 1 || depth > 6 {
+		return nil, fmt.Errorf("配置项 %s 必须在1到6之间: %d", constants.ConfigKeyTOCDepth, depth)
+	}
+
+	result, count := markdown.InsertTOC(numbered.Content, markdown.TOCOptions{Depth: depth, Style: style})
+	if count > 0 {
+		fmt.Print(i18n.Sprintf(constants.MsgTOCInserted, count, depth))
+	}
+	return []byte(result), nil
+}
+
+// numberingOptions 读取编号配置
+func (p *Processor) numberingOptions() (markdown.NumberingOptions, error) {
+	var opts markdown.NumberingOptions
+	for _, item := range p.config.GetList(constants.ConfigKeyNumbering) {
+		switch strings.ToLower(item) {
+		case "all":
+			opts.Headings, opts.Figures, opts.Tables = true, true, true
+		case "headings":
+			opts.Headings = true
+		case "figures":
+			opts.Figures = true
+		case "tables":
+			opts.Tables = true
+		case "none", "off":
+		default:
+			return opts, fmt.Errorf("不支持的编号内容: %s（支持 headings, figures, tables, all）", item)
+		}
+	}
+
+	var err error
+	if opts.Depth, err = p.config.GetInt(constants.ConfigKeyNumberingDepth, markdown.DefaultNumberingDepth); err != nil {
+		return opts, err
+	}
+	if opts.ChapterLevel, err = p.config.GetInt(constants.ConfigKeyChapterLevel, 0); err != nil {
+		return opts, err
+	}
+	if opts.ChapterLevel < 0 || opts.ChapterLevel > 6 {
+		return opts, fmt.Errorf("配置项 %s 必须在1到6之间: %d", constants.ConfigKeyChapterLevel, opts.ChapterLevel)
+	}
+	return opts, nil
+}
+
+// slugStyle 返回配置的标题锚点风格
+func (p *Processor) slugStyle() (markdown.SlugStyle, error) {
+	return markdown.ParseSlugStyle(p.config.GetString(constants.ConfigKeySlugStyle, ""))
+}
+
+// CacheStats 返回最近一次处理的缓存命中统计
+func (p *Processor) CacheStats() cache.Stats {
+	return p.cacheStats
+}
+
+// readTemplate 读取模板，按部署画像（profile）处理块级条件标记，返回处理后的内容、画像和各段条件内容的结果
+func (p *Processor) readTemplate(templatePath string) ([]byte, profile.Profile, []profile.Section, error) {
+	content, err := vfs.ReadFile(p.source, templatePath)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("读取模板文件失败: %v", err)
+	}
+	prof, err := profile.Parse(p.config.GetString(constants.ConfigKeyProfile, ""))
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("读取配置 %s 失败: %v", constants.ConfigKeyProfile, err)
+	}
+	filtered, sections, err := profile.Filter(string(content), prof)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("处理条件内容失败: %s: %v", templatePath, err)
+	}
+	return []byte(filtered), prof, sections, nil
+}
+
+// render 渲染模板，catalog为当前语言版本的消息目录
+func (p *Processor) render(templatePath, content string, catalog *i18n.Catalog) (*template.Rendered, error) {
+	renderer := *p.renderer
+	renderer.Catalog = catalog
+	return renderer.Execute(templatePath, content, p.config.Variables)
+}
+
+// catalog 读取当前语言版本（lang）在i18nDir中的消息目录，未设置lang时返回nil
+func (p *Processor) catalog() (*i18n.Catalog, error) {
+	lang := p.config.GetString(constants.ConfigKeyLang, "")
+	if lang == "" {
+		return nil, nil
+	}
+	return i18n.LoadCatalog(p.source, p.config.GetString(constants.ConfigKeyI18nDir, i18n.DefaultDir), lang)
+}
+
+// jobKey 计算文档生成任务的缓存键：模板、配置（含版本号）、消息目录、图片源文件和导出所用的文件
+func (p *Processor) jobKey(templatePath string, templateContent []byte, imagePaths []string, imageOptions *utils.ImageOptions, catalog *i18n.Catalog) string {
+	keys := make([]string, 0, len(p.config.Variables))
+	for key := range p.config.Variables {
+		if key != constants.ConfigKeyForce {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var variables strings.Builder
+	for _, key := range keys {
+		fmt.Fprintf(&variables, "%s=%s\n", key, p.config.Variables[key])
+	}
+	// 未配置发布日期时模板使用当天日期，日期变化后需要重新生成
+	fmt.Fprintf(&variables, "date:%s\n", template.VersionDate(p.config.Variables))
+	if catalog != nil {
+		for _, key := range catalog.Keys() {
+			fmt.Fprintf(&variables, "t:%s=%s\n", key, catalog.Messages[key])
+		}
+	}
+
+	sums := utils.ImageSourceChecksums(imagePaths, templatePath, imageOptions.Resolver)
+	for _, path := range p.exportSources(templatePath) {
+		data, err := vfs.ReadFile(p.source, path)
+		if err != nil {
+			sums = append(sums, path+":unreadable")
+			continue
+		}
+		sums = append(sums, path+":"+cache.Checksum(data))
+	}
+	return cache.Checksum([]byte(templatePath), templateContent, []byte(variables.String()), []byte(strings.Join(sums, "\n")))
+}
+
+// exportSources 返回导出时读取的本地文件：HTML主题的CSS文件、DOCX参考文档和PDF字体文件
+func (p *Processor) exportSources(templatePath string) []string {
+	var files []string
+	dir := filepath.Dir(templatePath)
+	if theme := export.ThemeFile(p.source, p.config.GetString(constants.ConfigKeyHTMLTheme, export.DefaultTheme), dir); theme != "" {
+		files = append(files, theme)
+	}
+	if reference := p.config.GetString(constants.ConfigKeyDOCXReference, ""); reference != "" {
+		files = append(files, export.ReferenceFile(p.source, reference, dir))
+	}
+	for _, key := range []string{constants.ConfigKeyPDFFont, constants.ConfigKeyPDFMonoFont} {
+		if font := p.config.GetString(key, ""); font != "" {
+			files = append(files, export.FontFile(p.source, font, dir))
+		}
+	}
+	return files
+}
+
+// assetsIntact 检查上次写入的图片是否仍然存在
+func (p *Processor) assetsIntact(outputPath string, imageOptions *utils.ImageOptions) bool {
+	assetDir, err := imageOptions.Layout.AssetDir(outputPath)
+	if err != nil {
+		return false
+	}
+	return utils.AssetsIntact(p.output, assetDir, outputPath)
+}
+
 // processImages 处理图片（保留原有方法以兼容）
 func (p *Processor) processImages(templatePath, outputPath, content string) (string, error) {
 	// 提取图片路径
 	imagePaths := utils.ExtractImages(content)
-	// 新增：打印所有图片路径
-	fmt.Println("模板中提取到的图片路径：")
-	for i, path := range imagePaths {
-		fmt.Printf("  %d. %s\n", i+1, path)
-	}
 	if len(imagePaths) == 0 {
 		return content, nil
 	}
@@ -82,6 +460,180 @@ func (p *Processor) processImages(templatePath, outputPath, content string) (str
 		return content, err
 	}
 
-	fmt.Printf("成功复制 %d 张图片\n", len(imagePaths))
+	fmt.Print(i18n.Sprintf(constants.MsgImagesCopied, len(imagePaths)))
 	return updatedContent, nil
 }
+
+// exportFiles 按配置的额外输出格式导出渲染后的文档，图片从暂存输出out中读取；每种格式导出前检查ctx
+func (p *Processor) exportFiles(ctx context.Context, templatePath, outputPath string, out vfs.WriteFS, content []byte) ([]export.File, error) {
+	style, err := p.slugStyle()
+	if err != nil {
+		return nil, err
+	}
+	var files []export.File
+	for _, format := range p.config.GetList(constants.ConfigKeyOutputFormats) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var exported []export.File
+		var err error
+		switch strings.ToLower(format) {
+		case "md", "markdown":
+			continue
+		case "html":
+			opts := &export.HTMLOptions{
+				Lang:      p.config.GetString(constants.ConfigKeyLang, ""),
+				Theme:     p.config.GetString(constants.ConfigKeyHTMLTheme, export.DefaultTheme),
+				ThemeDir:  filepath.Dir(templatePath),
+				SourceFS:  p.source,
+				SlugStyle: style,
+				FS:        out,
+			}
+			if opts.SelfContained, err = p.config.GetBool(constants.ConfigKeyHTMLSelfContained, false); err != nil {
+				return nil, err
+			}
+			exported, err = export.HTML(outputPath, content, opts)
+		case "docx":
+			exported, err = export.DOCX(outputPath, content, &export.DOCXOptions{
+				Reference:    p.config.GetString(constants.ConfigKeyDOCXReference, ""),
+				ReferenceDir: filepath.Dir(templatePath),
+				SourceFS:     p.source,
+				SlugStyle:    style,
+				FS:           out,
+			})
+		case "pdf":
+			opts := &export.PDFOptions{
+				Font:      p.config.GetString(constants.ConfigKeyPDFFont, ""),
+				MonoFont:  p.config.GetString(constants.ConfigKeyPDFMonoFont, ""),
+				FontDir:   filepath.Dir(templatePath),
+				SourceFS:  p.source,
+				Header:    p.config.GetString(constants.ConfigKeyPDFHeader, export.DefaultPDFHeader),
+				Footer:    p.config.GetString(constants.ConfigKeyPDFFooter, export.DefaultPDFFooter),
+				Variables: p.config.Variables,
+				SlugStyle: style,
+				FS:        out,
+			}
+			if opts.TOC, err = p.config.GetBool(constants.ConfigKeyPDFTOC, true); err != nil {
+				return nil, err
+			}
+			exported, err = export.PDF(outputPath, content, opts)
+		default:
+			return nil, fmt.Errorf("不支持的输出格式: %s（支持 md, html, docx, pdf）", format)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("导出%s失败: %v", format, err)
+		}
+		files = append(files, exported...)
+	}
+	return files, nil
+}
+
+// imageOptions 根据配置生成图片处理选项
+func (p *Processor) imageOptions() (*utils.ImageOptions, error) {
+	policy, err := utils.ParseRemoteImagePolicy(p.config.GetString(constants.ConfigKeyRemoteImages, ""))
+	if err != nil {
+		return nil, err
+	}
+
+	timeout, err := p.config.GetInt(constants.ConfigKeyRemoteTimeout, int(utils.DefaultRemoteTimeout/time.Second))
+	if err != nil {
+		return nil, err
+	}
+	maxBytes, err := p.config.GetInt(constants.ConfigKeyRemoteMaxBytes, utils.DefaultRemoteMaxBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := utils.DefaultImageOptions()
+	opts.RemotePolicy = policy
+	opts.Fetcher = utils.NewHTTPFetcher(time.Duration(timeout)*time.Second, int64(maxBytes))
+	if policy == utils.RemotePolicyDownload {
+		opts.Cache = utils.NewRemoteImageCache(p.config.GetString(constants.ConfigKeyRemoteCacheDir, ""))
+	}
+
+	if opts.EmbedImages, err = p.config.GetBool(constants.ConfigKeyEmbedImages, false); err != nil {
+		return nil, err
+	}
+	embedMaxBytes, err := p.config.GetInt(constants.ConfigKeyEmbedMaxBytes, utils.DefaultEmbedMaxBytes)
+	if err != nil {
+		return nil, err
+	}
+	opts.EmbedMaxBytes = int64(embedMaxBytes)
+
+	if opts.Optimize, err = p.optimizeOptions(); err != nil {
+		return nil, err
+	}
+	if opts.Rules, err = p.imageRules(); err != nil {
+		return nil, err
+	}
+
+	opts.Layout = &utils.AssetLayout{
+		Dir:       p.config.GetString(constants.ConfigKeyAssetDir, utils.DefaultAssetDir),
+		URLPrefix: p.config.GetString(constants.ConfigKeyAssetURLPrefix, ""),
+		Variables: p.config.Variables,
+	}
+
+	opts.Resolver = &utils.ImageResolver{SearchPaths: p.config.GetList(constants.ConfigKeyImageSearchPaths), FS: p.source}
+	if opts.Resolver.Strict, err = p.config.GetBool(constants.ConfigKeyImageStrictPaths, false); err != nil {
+		return nil, err
+	}
+	if opts.KeepStale, err = p.config.GetBool(constants.ConfigKeyKeepStaleAssets, false); err != nil {
+		return nil, err
+	}
+	opts.Output = p.output
+	return opts, nil
+}
+
+// imageRules 根据配置生成图片检查规则，imageCheck为off时不检查
+func (p *Processor) imageRules() (*validator.ImageRules, error) {
+	levelValue := p.config.GetString(constants.ConfigKeyImageCheck, "")
+	if levelValue == "off" {
+		return nil, nil
+	}
+	level, err := validator.ParseIssueLevel(levelValue)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := &validator.ImageRules{Level: level}
+	maxBytes, err := p.config.GetInt(constants.ConfigKeyImageCheckMaxBytes, 0)
+	if err != nil {
+		return nil, err
+	}
+	rules.MaxBytes = int64(maxBytes)
+	if rules.MaxWidth, err = p.config.GetInt(constants.ConfigKeyImageCheckMaxWidth, 0); err != nil {
+		return nil, err
+	}
+	if rules.MaxHeight, err = p.config.GetInt(constants.ConfigKeyImageCheckMaxHeight, 0); err != nil {
+		return nil, err
+	}
+	if rules.RequireExtension, err = p.config.GetBool(constants.ConfigKeyImageCheckExtension, true); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// optimizeOptions 根据配置生成图片优化选项
+func (p *Processor) optimizeOptions() (*utils.OptimizeOptions, error) {
+	opts := &utils.OptimizeOptions{}
+	var err error
+	if opts.MaxWidth, err = p.config.GetInt(constants.ConfigKeyImageMaxWidth, 0); err != nil {
+		return nil, err
+	}
+	if opts.MaxHeight, err = p.config.GetInt(constants.ConfigKeyImageMaxHeight, 0); err != nil {
+		return nil, err
+	}
+	if opts.PNGCompression, err = p.config.GetBool(constants.ConfigKeyPNGCompression, false); err != nil {
+		return nil, err
+	}
+	if opts.JPEGQuality, err = p.config.GetInt(constants.ConfigKeyJPEGQuality, 0); err != nil {
+		return nil, err
+	}
+	if opts.JPEGQuality < 0 || opts.JPEGQuality > 100 {
+		return nil, fmt.Errorf("配置项 %s 必须在1-100之间: %d", constants.ConfigKeyJPEGQuality, opts.JPEGQuality)
+	}
+	if opts.ConvertToWebP, err = p.config.GetBool(constants.ConfigKeyConvertToWebP, false); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
@@ -0,0 +1,391 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"md-manual-tool/pkg/cache"
+	"md-manual-tool/pkg/config"
+	"md-manual-tool/pkg/markdown"
+	"md-manual-tool/pkg/utils"
+	"md-manual-tool/pkg/vfs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestProcessIncremental(t *testing.T) {
+	tempDir := t.TempDir()
+	templatePath := filepath.Join(tempDir, "manual_1.0.0.md")
+	outputPath := filepath.Join(tempDir, "output", "manual_1.0.1.md")
+	imagePath := filepath.Join(tempDir, "images", "a.png")
+	copiedPath := filepath.Join(tempDir, "output", "manual_1.0.1.assets", "a.png")
+
+	os.MkdirAll(filepath.Dir(imagePath), 0755)
+	os.WriteFile(imagePath, []byte("png v1"), 0644)
+	os.WriteFile(templatePath, []byte("# {{.title}} 1.0.0\n![图](images/a.png)\n"), 0644)
+
+	cfg := &config.Config{Variables: map[string]string{"title": "手册", "version": "1.0.1", "imageCheck": "off"}}
+	process := func(expected cache.Stats) {
+		p := NewProcessor(cfg)
+		if err := p.Process(context.Background(), templatePath, outputPath); err != nil {
+			t.Fatalf("处理失败: %v", err)
+		}
+		if p.CacheStats() != expected {
+			t.Errorf("缓存统计错误: 期望 %+v, 实际 %+v", expected, p.CacheStats())
+		}
+	}
+
+	process(cache.Stats{JobMisses: 1, ImageMisses: 1})
+	output, err := os.ReadFile(outputPath)
+	if err != nil || string(output) != "# 手册 1.0.1\n![图](./manual_1.0.1.assets/a.png)\n" {
+		t.Fatalf("输出内容错误: %q %v", output, err)
+	}
+
+	// 输入未变化时跳过
+	process(cache.Stats{JobHits: 1})
+
+	// 配置变化时重新生成，图片未变化则跳过复制
+	cfg.Variables["title"] = "新手册"
+	process(cache.Stats{JobMisses: 1, ImageHits: 1})
+
+	// 图片变化后重新复制
+	os.WriteFile(imagePath, []byte("png v2"), 0644)
+	process(cache.Stats{JobMisses: 1, ImageMisses: 1})
+	if copied, _ := os.ReadFile(copiedPath); string(copied) != "png v2" {
+		t.Errorf("图片变化后应重新复制，实际: %s", copied)
+	}
+
+	// 已复制的图片被删除后重新生成
+	os.Remove(copiedPath)
+	process(cache.Stats{JobMisses: 1, ImageMisses: 1})
+	if _, err := os.Stat(copiedPath); err != nil {
+		t.Errorf("图片缺失时应重新复制: %v", err)
+	}
+
+	// 强制模式忽略缓存
+	cfg.Variables["force"] = "true"
+	process(cache.Stats{JobMisses: 1, ImageMisses: 1})
+}
+
+func TestProcessInterrupted(t *testing.T) {
+	// 远程图片一直不返回，直到请求被取消
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	templatePath := filepath.Join(tempDir, "manual_1.0.0.md")
+	outputDir := filepath.Join(tempDir, "output")
+	outputPath := filepath.Join(outputDir, "manual_1.0.1.md")
+	os.WriteFile(filepath.Join(tempDir, "a.png"), []byte("png"), 0644)
+	os.WriteFile(templatePath, []byte("# 手册 1.0.0\n![图](a.png)\n"), 0644)
+
+	cfg := &config.Config{Variables: map[string]string{
+		"version": "1.0.1", "imageCheck": "off", "outputFormats": "html",
+		"remoteImages": "download", "remoteCacheDir": filepath.Join(tempDir, "cache"),
+	}}
+	if err := NewProcessor(cfg).Process(context.Background(), templatePath, outputPath); err != nil {
+		t.Fatalf("处理失败: %v", err)
+	}
+	listOutput := func() []string {
+		var files []string
+		filepath.WalkDir(outputDir, func(path string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				rel, _ := filepath.Rel(outputDir, path)
+				files = append(files, filepath.ToSlash(rel))
+			}
+			return nil
+		})
+		return files
+	}
+	before := listOutput()
+	original, _ := os.ReadFile(outputPath)
+
+	// 超时：放弃本次输出，原有文件保持不变且不留下临时文件
+	os.WriteFile(templatePath, []byte("# 手册 1.0.0\n![图](a.png)\n![远程]("+server.URL+"/b.png)\n"), 0644)
+	cfg.Variables["jobTimeout"] = "100ms"
+	err := NewProcessor(cfg).Process(context.Background(), templatePath, outputPath)
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "生成超时") {
+		t.Errorf("超时应返回DeadlineExceeded: %v", err)
+	}
+	if after := listOutput(); !reflect.DeepEqual(after, before) {
+		t.Errorf("超时后输出目录被修改:\n%q\n原有:\n%q", after, before)
+	}
+	if output, _ := os.ReadFile(outputPath); string(output) != string(original) {
+		t.Errorf("超时后原有输出被修改: %q", output)
+	}
+
+	// 取消（如Ctrl+C）
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	delete(cfg.Variables, "jobTimeout")
+	if err := NewProcessor(cfg).Process(ctx, templatePath, outputPath); !errors.Is(err, context.Canceled) {
+		t.Errorf("取消后应返回Canceled: %v", err)
+	}
+	if after := listOutput(); !reflect.DeepEqual(after, before) {
+		t.Errorf("取消后输出目录被修改: %q", after)
+	}
+}
+
+func TestProcessFS(t *testing.T) {
+	// 模板来自只读的io/fs（如embed.FS），输出写入内存
+	source := fstest.MapFS{
+		"docs/manual_1.0.0.md": {Data: []byte("# 手册 1.0.0\n![图](images/a.png)\n")},
+		"docs/images/a.png":    {Data: []byte("png")},
+		"docs/i18n/en-US.yaml": {Data: []byte("title: Manual\n")},
+	}
+	output := vfs.NewMemFS(nil)
+	cfg := &config.Config{Variables: map[string]string{
+		"version": "1.0.1", "imageCheck": "off", "outputFormats": "html", "htmlSelfContained": "true",
+		"lang": "en-US", "i18nDir": "docs/i18n",
+	}}
+
+	p := NewProcessorFS(cfg, source, output)
+	if err := p.Process(context.Background(), "docs/manual_1.0.0.md", "out/manual_1.0.1.md"); err != nil {
+		t.Fatalf("处理失败: %v", err)
+	}
+	expected := []string{
+		"out/" + cache.FileName, "out/manual_1.0.1.assets/.md-manual-tool.json",
+		"out/manual_1.0.1.assets/a.png", "out/manual_1.0.1.html", "out/manual_1.0.1.md",
+	}
+	if files := output.Files(); !reflect.DeepEqual(files, expected) {
+		t.Errorf("输出文件:\n%q\n期望:\n%q", files, expected)
+	}
+	if md, _ := output.ReadFile("out/manual_1.0.1.md"); string(md) != "# 手册 1.0.1\n![图](./manual_1.0.1.assets/a.png)\n" {
+		t.Errorf("输出内容错误: %q", md)
+	}
+	// 自包含HTML从输出文件系统读取图片，页面语言为当前语言版本
+	page, _ := output.ReadFile("out/manual_1.0.1.html")
+	if !strings.Contains(string(page), "data:image/png;base64,") {
+		t.Error("HTML应内嵌输出目录中的图片")
+	}
+	if !strings.Contains(string(page), `<html lang="en-US">`) {
+		t.Errorf("HTML页面语言错误: %s", page)
+	}
+
+	// 缓存同样保存在输出文件系统中
+	p = NewProcessorFS(cfg, source, output)
+	if err := p.Process(context.Background(), "docs/manual_1.0.0.md", "out/manual_1.0.1.md"); err != nil {
+		t.Fatalf("处理失败: %v", err)
+	}
+	if p.CacheStats() != (cache.Stats{JobHits: 1}) {
+		t.Errorf("第二次处理应命中缓存: %+v", p.CacheStats())
+	}
+}
+
+func TestProcessLanguages(t *testing.T) {
+	source := fstest.MapFS{
+		"docs/manual_1.0.0.md": {Data: []byte("# {{t \"install.title\"}}\n{{.productName}} {{.version}}\n")},
+		"i18n/zh-CN.yaml":      {Data: []byte("install.title: 安装\n")},
+		"i18n/en-US.yaml":      {Data: []byte("install.title: Installation\n")},
+	}
+	output := vfs.NewMemFS(nil)
+	process := func(lang, productName string) *Processor {
+		cfg := &config.Config{Variables: map[string]string{
+			"version": "1.0.1", "imageCheck": "off", "productName": productName, "lang": lang, "i18nDir": "i18n",
+		}}
+		p := NewProcessorFS(cfg, source, output)
+		if err := p.Process(context.Background(), "docs/manual_1.0.0.md", "out/manual_1.0.1."+lang+".md"); err != nil {
+			t.Fatalf("生成 %s 失败: %v", lang, err)
+		}
+		return p
+	}
+
+	process("zh-CN", "产品数据管理")
+	process("en-US", "Product Data Management")
+	for name, expected := range map[string]string{
+		"out/manual_1.0.1.zh-CN.md": "# 安装\n产品数据管理 1.0.1\n",
+		"out/manual_1.0.1.en-US.md": "# Installation\nProduct Data Management 1.0.1\n",
+	} {
+		if data, _ := output.ReadFile(name); string(data) != expected {
+			t.Errorf("%s 内容错误: %q", name, data)
+		}
+	}
+
+	// 消息目录变化后重新生成
+	source["i18n/en-US.yaml"] = &fstest.MapFile{Data: []byte("install.title: Setup\n")}
+	if p := process("en-US", "Product Data Management"); p.CacheStats().JobMisses != 1 {
+		t.Errorf("消息目录变化后应重新生成: %+v", p.CacheStats())
+	}
+	if data, _ := output.ReadFile("out/manual_1.0.1.en-US.md"); !strings.HasPrefix(string(data), "# Setup\n") {
+		t.Errorf("应使用新的译文: %q", data)
+	}
+}
+
+func TestProcessProfile(t *testing.T) {
+	source := fstest.MapFS{
+		"docs/manual_1.0.0.md": {Data: []byte("# 安装\n" +
+			"<!-- if os=linux -->\n![Linux](images/linux.png)\n<!-- else -->\n![Windows](images/windows.png)\n<!-- endif -->\n" +
+			"{{if has .profile \"enterprise\"}}集群部署{{else}}单机部署{{end}}\n")},
+		"docs/images/linux.png":   {Data: []byte("png")},
+		"docs/images/windows.png": {Data: []byte("png")},
+	}
+	output := vfs.NewMemFS(nil)
+	cfg := &config.Config{Variables: map[string]string{
+		"version": "1.0.1", "imageCheck": "off", "profile": "edition=enterprise, os=linux",
+	}}
+	p := NewProcessorFS(cfg, source, output)
+	if err := p.Process(context.Background(), "docs/manual_1.0.0.md", "out/manual_1.0.1.md"); err != nil {
+		t.Fatalf("处理失败: %v", err)
+	}
+	if md, _ := output.ReadFile("out/manual_1.0.1.md"); string(md) != "# 安装\n![Linux](./manual_1.0.1.assets/linux.png)\n集群部署\n" {
+		t.Errorf("输出内容错误: %q", md)
+	}
+	// 被删除的条件内容中的图片不复制
+	if _, err := output.Stat("out/manual_1.0.1.assets/windows.png"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("不应复制被删除内容中的图片: %v", err)
+	}
+	if files, _ := p.SourceFiles("docs/manual_1.0.0.md"); !reflect.DeepEqual(files, []string{"docs/images/linux.png"}) {
+		t.Errorf("源文件: %q", files)
+	}
+
+	// 标记不配对时报错
+	source["docs/manual_1.0.0.md"] = &fstest.MapFile{Data: []byte("<!-- if os=linux -->\n")}
+	if err := NewProcessorFS(cfg, source, output).Process(context.Background(), "docs/manual_1.0.0.md", "out/manual_1.0.1.md"); err == nil {
+		t.Error("缺少 endif 时应报错")
+	}
+}
+
+func TestProcessExportSources(t *testing.T) {
+	source := fstest.MapFS{
+		"docs/manual_1.0.0.md": {Data: []byte("# 安装\n")},
+		"docs/corp.css":        {Data: []byte("h1 { color: red; }\n")},
+	}
+	output := vfs.NewMemFS(nil)
+	cfg := &config.Config{Variables: map[string]string{
+		"version": "1.0.1", "imageCheck": "off", "outputFormats": "html", "htmlTheme": "corp.css",
+	}}
+	process := func() *Processor {
+		p := NewProcessorFS(cfg, source, output)
+		if err := p.Process(context.Background(), "docs/manual_1.0.0.md", "out/manual_1.0.1.md"); err != nil {
+			t.Fatalf("处理失败: %v", err)
+		}
+		return p
+	}
+	process()
+
+	// 主题文件变化后重新生成
+	source["docs/corp.css"] = &fstest.MapFile{Data: []byte("h1 { color: blue; }\n")}
+	if p := process(); p.CacheStats().JobMisses != 1 {
+		t.Errorf("主题变化后应重新生成: %+v", p.CacheStats())
+	}
+	if css, _ := output.ReadFile("out/manual_1.0.1.css"); !strings.Contains(string(css), "color: blue") {
+		t.Errorf("应使用新的主题: %q", css)
+	}
+	if files, _ := NewProcessorFS(cfg, source, output).SourceFiles("docs/manual_1.0.0.md"); !reflect.DeepEqual(files, []string{"docs/corp.css"}) {
+		t.Errorf("源文件: %q", files)
+	}
+
+	// 同一路径的字体文件被替换后缓存键变化
+	source["docs/fonts/main.ttf"] = &fstest.MapFile{Data: []byte("font v1")}
+	source["mono.ttf"] = &fstest.MapFile{Data: []byte("mono v1")}
+	cfg.Variables["pdfFont"] = "fonts/main.ttf"
+	cfg.Variables["pdfMonoFont"] = "mono.ttf"
+	p := NewProcessorFS(cfg, source, output)
+	key := func() string {
+		return p.jobKey("docs/manual_1.0.0.md", source["docs/manual_1.0.0.md"].Data, nil, &utils.ImageOptions{}, nil)
+	}
+	before := key()
+	source["docs/fonts/main.ttf"] = &fstest.MapFile{Data: []byte("font v2")}
+	if key() == before {
+		t.Error("正文字体变化后缓存键应变化")
+	}
+	before = key()
+	source["mono.ttf"] = &fstest.MapFile{Data: []byte("mono v2")}
+	if key() == before {
+		t.Error("代码字体变化后缓存键应变化")
+	}
+
+	// 参考文档被修改后缓存键变化
+	source["docs/ref.docx"] = &fstest.MapFile{Data: []byte("styles v1")}
+	cfg.Variables["docxReference"] = "ref.docx"
+	before = key()
+	source["docs/ref.docx"] = &fstest.MapFile{Data: []byte("styles v2")}
+	if key() == before {
+		t.Error("参考文档变化后缓存键应变化")
+	}
+	if files, _ := p.SourceFiles("docs/manual_1.0.0.md"); !reflect.DeepEqual(files, []string{"docs/corp.css", "docs/ref.docx", "docs/fonts/main.ttf", "mono.ttf"}) {
+		t.Errorf("源文件: %q", files)
+	}
+}
+
+func TestPreview(t *testing.T) {
+	tempDir := t.TempDir()
+	templatePath := filepath.Join(tempDir, "manual_1.0.0.md")
+	imagePath := filepath.Join(tempDir, "images", "架构 图.png")
+	os.MkdirAll(filepath.Dir(imagePath), 0755)
+	os.WriteFile(imagePath, []byte("png"), 0644)
+	os.WriteFile(templatePath, []byte("# {{.title}} 1.0.0\n![图](images/架构 图.png)\n![远程](https://example.com/a.png)\n"), 0644)
+
+	cfg := &config.Config{Variables: map[string]string{"title": "手册", "version": "1.0.1"}}
+	preview, err := NewProcessor(cfg).Preview(templatePath, "/_assets")
+	if err != nil {
+		t.Fatalf("预览失败: %v", err)
+	}
+
+	expected := "# 手册 1.0.1\n![图](/_assets/0/%E6%9E%B6%E6%9E%84%20%E5%9B%BE.png)\n![远程](https://example.com/a.png)\n"
+	if string(preview.Markdown) != expected {
+		t.Errorf("预览内容错误:\n期望: %q\n实际: %q", expected, preview.Markdown)
+	}
+	if source := preview.Images["/_assets/0/架构 图.png"]; source != imagePath {
+		t.Errorf("图片映射错误: %v", preview.Images)
+	}
+	if entries, _ := os.ReadDir(tempDir); len(entries) != 2 {
+		t.Errorf("预览不应写入文件，目录内容: %v", entries)
+	}
+}
+
+func TestPreviewTOC(t *testing.T) {
+	tempDir := t.TempDir()
+	templatePath := filepath.Join(tempDir, "manual_1.0.0.md")
+	os.WriteFile(templatePath, []byte("# {{.title}}\n\n{{toc}}\n\n## 安装 {{.version}}\n### 下载\n"), 0644)
+
+	cfg := &config.Config{Variables: map[string]string{"title": "手册", "version": "1.0.1", "tocDepth": "2", "slugStyle": "typora"}}
+	preview, err := NewProcessor(cfg).Preview(templatePath, "/_assets")
+	if err != nil {
+		t.Fatalf("预览失败: %v", err)
+	}
+
+	expected := "# 手册\n\n- [安装 1.0.1](#安装-101)\n\n## 安装 1.0.1\n### 下载\n"
+	if string(preview.Markdown) != expected {
+		t.Errorf("目录展开错误:\n期望: %q\n实际: %q", expected, preview.Markdown)
+	}
+	if preview.SlugStyle != markdown.SlugTypora {
+		t.Errorf("锚点风格 = %q, 期望 typora", preview.SlugStyle)
+	}
+
+	cfg.Variables["tocDepth"] = "7"
+	if _, err := NewProcessor(cfg).Preview(templatePath, "/_assets"); err == nil {
+		t.Error("超出范围的目录深度应返回错误")
+	}
+}
+
+func TestPreviewNumbering(t *testing.T) {
+	tempDir := t.TempDir()
+	templatePath := filepath.Join(tempDir, "manual_1.0.0.md")
+	os.WriteFile(templatePath, []byte("# 手册\n\n[TOC]\n\n## 安装\n\n架构见{{ref \"fig:arch\"}}。\n\n## 架构\n\n![系统架构](https://example.com/a.png){#fig:arch}\n"), 0644)
+
+	cfg := &config.Config{Variables: map[string]string{"numbering": "headings, figures"}}
+	preview, err := NewProcessor(cfg).Preview(templatePath, "/_assets")
+	if err != nil {
+		t.Fatalf("预览失败: %v", err)
+	}
+
+	expected := "# 手册\n\n- [第1章 安装](#第1章-安装)\n- [第2章 架构](#第2章-架构)\n\n## 第1章 安装\n\n架构见图 2-1。\n\n" +
+		"## 第2章 架构\n\n![系统架构](https://example.com/a.png)\n\n图 2-1 系统架构\n"
+	if string(preview.Markdown) != expected {
+		t.Errorf("编号错误:\n期望: %q\n实际: %q", expected, preview.Markdown)
+	}
+
+	cfg.Variables["numbering"] = "chapters"
+	if _, err := NewProcessor(cfg).Preview(templatePath, "/_assets"); err == nil {
+		t.Error("不支持的编号内容应返回错误")
+	}
+}
@@ -0,0 +1,232 @@
+// Package profile 按部署画像筛选手册内容，如版本 edition=enterprise、系统 os=linux
+//
+// 模板中有两种条件写法：
+//   - 块级标记 <!-- if os=linux --> ... <!-- else --> ... <!-- endif -->，独占一行，在渲染前处理，
+//     被删除的内容中的图片不会被复制；可以嵌套，围栏代码块中的标记原样保留
+//   - 模板函数 {{if has .profile "linux"}}，按属性值或 "键=值" 判断
+package profile
+
+import (
+	"fmt"
+	"md-manual-tool/pkg/constants"
+	"md-manual-tool/pkg/i18n"
+	"md-manual-tool/pkg/markdown"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// markerRe 块级条件标记：<!-- if 条件 -->、<!-- else -->、<!-- endif -->
+var markerRe = regexp.MustCompile(`^\s*<!--\s*(if\s+(.*?)|else|endif)\s*-->\s*$`)
+
+// Profile 部署画像：属性名 -> 值
+type Profile map[string]string
+
+// Parse 解析画像，属性之间以逗号分隔，如 "edition=enterprise, os=linux"
+func Parse(value string) (Profile, error) {
+	p := make(Profile)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.SplitN(item, "=", 2)
+		key, val := strings.TrimSpace(parts[0]), ""
+		if len(parts) == 2 {
+			val = strings.TrimSpace(parts[1])
+		}
+		if key == "" || val == "" || strings.ContainsAny(key+val, " |!") {
+			return nil, fmt.Errorf("无效的画像属性: %q（应为 键=值，如 os=linux）", item)
+		}
+		if _, exists := p[key]; exists {
+			return nil, fmt.Errorf("画像属性重复: %s", key)
+		}
+		p[key] = val
+	}
+	return p, nil
+}
+
+// From 将模板数据中的画像转换为Profile：未配置时为空画像，字符串按Parse解析
+func From(value interface{}) (Profile, error) {
+	switch v := value.(type) {
+	case nil:
+		return Profile{}, nil
+	case Profile:
+		return v, nil
+	case map[string]string:
+		return Profile(v), nil
+	case string:
+		return Parse(v)
+	}
+	return nil, fmt.Errorf("无法作为画像使用的值: %v", value)
+}
+
+// String 按属性名顺序格式化，如 "edition=enterprise, os=linux"
+func (p Profile) String() string {
+	keys := make([]string, 0, len(p))
+	for key := range p {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	items := make([]string, len(keys))
+	for i, key := range keys {
+		items[i] = key + "=" + p[key]
+	}
+	return strings.Join(items, ", ")
+}
+
+// Has 判断画像是否包含某个属性值（如 linux）或键值对（如 os=linux）
+func (p Profile) Has(want string) bool {
+	want = strings.TrimSpace(want)
+	if key, val, ok := strings.Cut(want, "="); ok {
+		return p[strings.TrimSpace(key)] == strings.TrimSpace(val)
+	}
+	for _, val := range p {
+		if val == want {
+			return true
+		}
+	}
+	return false
+}
+
+// Match 判断条件是否成立。条件由空格分隔的若干项组成，全部成立时成立；每一项为 键=值、键!=值
+// 或单独的属性值，值可以用 | 列出多个，如 "os=linux|macos edition!=standard"
+func (p Profile) Match(cond string) (bool, error) {
+	terms := strings.Fields(cond)
+	if len(terms) == 0 {
+		return false, fmt.Errorf("条件为空")
+	}
+	for _, term := range terms {
+		ok, err := p.matchTerm(term)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// matchTerm 判断条件中的一项
+func (p Profile) matchTerm(term string) (bool, error) {
+	key, values, negate := "", term, false
+	if i := strings.Index(term, "!="); i >= 0 {
+		key, values, negate = term[:i], term[i+2:], true
+	} else if i := strings.Index(term, "="); i >= 0 {
+		key, values = term[:i], term[i+1:]
+	}
+	if values == "" || strings.Contains(term, "=") && key == "" {
+		return false, fmt.Errorf("无效的条件: %q（应为 键=值、键!=值 或 值）", term)
+	}
+
+	matched := false
+	for _, val := range strings.Split(values, "|") {
+		if key == "" && p.Has(val) || key != "" && p[key] == val {
+			matched = true
+			break
+		}
+	}
+	return matched != negate, nil
+}
+
+// Section 一段条件内容的处理结果
+type Section struct {
+	Line      int    // 条件标记所在行，从1开始
+	Condition string // 条件；else分支为 "else（原条件）"
+	Included  bool   // 是否保留
+	Lines     int    // 条件内容的行数（不含标记和嵌套的条件内容）
+}
+
+// frame 处理中的条件块
+type frame struct {
+	line      int
+	condition string
+	matched   bool // if条件是否成立
+	parent    bool // 外层条件块是否保留
+	section   int  // 当前分支在结果中的位置
+	inElse    bool
+}
+
+// Filter 按画像处理块级条件标记：保留条件成立的内容，删除其余内容和所有标记行
+func Filter(content string, p Profile) (string, []Section, error) {
+	lines := strings.Split(content, "\n")
+	code := markdown.CodeLines(lines)
+	var (
+		out      []string
+		sections []Section
+		stack    []*frame
+	)
+	// 当前行是否保留：最内层条件块的当前分支已包含外层的结果
+	active := func() bool {
+		return len(stack) == 0 || sections[stack[len(stack)-1].section].Included
+	}
+
+	for i, line := range lines {
+		m := markerRe.FindStringSubmatch(line)
+		if code[i] || m == nil {
+			if active() {
+				out = append(out, line)
+			}
+			if len(stack) > 0 {
+				sections[stack[len(stack)-1].section].Lines++
+			}
+			continue
+		}
+
+		switch {
+		case m[1] == "endif":
+			if len(stack) == 0 {
+				return "", nil, fmt.Errorf("第 %d 行的 <!-- endif --> 没有对应的 <!-- if -->", i+1)
+			}
+			stack = stack[:len(stack)-1]
+		case m[1] == "else":
+			if len(stack) == 0 || stack[len(stack)-1].inElse {
+				return "", nil, fmt.Errorf("第 %d 行的 <!-- else --> 没有对应的 <!-- if -->", i+1)
+			}
+			f := stack[len(stack)-1]
+			f.inElse = true
+			f.section = len(sections)
+			sections = append(sections, Section{Line: i + 1, Condition: "else（" + f.condition + "）", Included: f.parent && !f.matched})
+		default:
+			condition := strings.TrimSpace(m[2])
+			matched, err := p.Match(condition)
+			if err != nil {
+				return "", nil, fmt.Errorf("第 %d 行: %v", i+1, err)
+			}
+			parent := active()
+			stack = append(stack, &frame{line: i + 1, condition: condition, matched: matched, parent: parent, section: len(sections)})
+			sections = append(sections, Section{Line: i + 1, Condition: condition, Included: parent && matched})
+		}
+	}
+	if len(stack) > 0 {
+		return "", nil, fmt.Errorf("第 %d 行的 <!-- if %s --> 没有对应的 <!-- endif -->", stack[len(stack)-1].line, stack[len(stack)-1].condition)
+	}
+	return strings.Join(out, "\n"), sections, nil
+}
+
+// Check 模板函数has的一种判断及其结果
+type Check struct {
+	Want   string // has的参数，如 linux 或 os=linux
+	Result bool
+	Count  int // 判断次数
+}
+
+// PrintReport 输出条件内容的处理结果
+func PrintReport(p Profile, sections []Section, checks []Check) {
+	if len(sections) == 0 && len(checks) == 0 {
+		return
+	}
+	fmt.Print(i18n.Sprintf(constants.MsgProfileReport, p))
+	for _, s := range sections {
+		result := i18n.Msg(constants.MsgProfileDropped)
+		if s.Included {
+			result = i18n.Msg(constants.MsgProfileKept)
+		}
+		fmt.Print(i18n.Sprintf(constants.MsgProfileSection, s.Line, s.Condition, result, s.Lines))
+	}
+	for _, c := range checks {
+		result := i18n.Msg(constants.MsgProfileFalse)
+		if c.Result {
+			result = i18n.Msg(constants.MsgProfileTrue)
+		}
+		fmt.Print(i18n.Sprintf(constants.MsgProfileCheck, c.Want, result, c.Count))
+	}
+}
@@ -0,0 +1,111 @@
+package profile
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	p, err := Parse(" edition=enterprise, os = linux ,")
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if expected := (Profile{"edition": "enterprise", "os": "linux"}); !reflect.DeepEqual(p, expected) {
+		t.Errorf("解析结果: %v, 期望 %v", p, expected)
+	}
+	if s := p.String(); s != "edition=enterprise, os=linux" {
+		t.Errorf("String() = %q", s)
+	}
+	if p, err := Parse(""); err != nil || len(p) != 0 {
+		t.Errorf("空画像: %v %v", p, err)
+	}
+	for _, value := range []string{"linux", "os=", "=linux", "os=linux|macos", "os=linux, os=windows"} {
+		if _, err := Parse(value); err == nil {
+			t.Errorf("Parse(%q) 应报错", value)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	p := Profile{"edition": "enterprise", "os": "linux"}
+	tests := map[string]bool{
+		"linux":                              true,
+		"windows":                            false,
+		"os=linux":                           true,
+		"os=windows":                         false,
+		"os!=windows":                        true,
+		"os=windows|linux":                   true,
+		"os!=windows|linux":                  false,
+		"edition=enterprise os=linux":        true,
+		"edition=enterprise os=windows":      false,
+		"arch=x86":                           false,
+		"arch!=x86":                          true,
+		"standard|enterprise edition!=trial": true,
+	}
+	for cond, expected := range tests {
+		if got, err := p.Match(cond); err != nil || got != expected {
+			t.Errorf("Match(%q) = %v %v, 期望 %v", cond, got, err, expected)
+		}
+	}
+	for _, cond := range []string{"", "os=", "=linux", "!=linux"} {
+		if _, err := p.Match(cond); err == nil {
+			t.Errorf("Match(%q) 应报错", cond)
+		}
+	}
+	if !p.Has("linux") || !p.Has("os=linux") || p.Has("os=windows") || p.Has("windows") {
+		t.Error("Has判断错误")
+	}
+}
+
+func TestFilter(t *testing.T) {
+	content := `# 安装
+<!-- if edition=enterprise -->
+企业版
+<!-- if os=windows -->
+Windows集群
+<!-- else -->
+Linux集群
+<!-- endif -->
+<!-- else -->
+标准版
+<!-- endif -->
+` + "```" + `
+<!-- if os=windows -->
+` + "```" + `
+结束`
+	result, sections, err := Filter(content, Profile{"edition": "enterprise", "os": "linux"})
+	if err != nil {
+		t.Fatalf("处理失败: %v", err)
+	}
+	expected := "# 安装\n企业版\nLinux集群\n```\n<!-- if os=windows -->\n```\n结束"
+	if result != expected {
+		t.Errorf("处理结果:\n%s\n期望:\n%s", result, expected)
+	}
+	expectedSections := []Section{
+		{Line: 2, Condition: "edition=enterprise", Included: true, Lines: 1},
+		{Line: 4, Condition: "os=windows", Included: false, Lines: 1},
+		{Line: 6, Condition: "else（os=windows）", Included: true, Lines: 1},
+		{Line: 9, Condition: "else（edition=enterprise）", Included: false, Lines: 1},
+	}
+	if !reflect.DeepEqual(sections, expectedSections) {
+		t.Errorf("条件内容:\n%+v\n期望:\n%+v", sections, expectedSections)
+	}
+
+	// 外层条件不成立时，内层的else分支同样删除
+	result, _, err = Filter(content, Profile{"edition": "standard", "os": "windows"})
+	if err != nil || result != "# 安装\n标准版\n```\n<!-- if os=windows -->\n```\n结束" {
+		t.Errorf("处理结果: %q %v", result, err)
+	}
+
+	for _, invalid := range []string{
+		"<!-- if os=linux -->\n",
+		"<!-- endif -->\n",
+		"<!-- else -->\n",
+		"<!-- if os=linux -->\n<!-- else -->\n<!-- else -->\n<!-- endif -->",
+		"<!-- if os= -->\n<!-- endif -->",
+	} {
+		if _, _, err := Filter(invalid, Profile{}); err == nil {
+			t.Errorf("Filter(%q) 应报错", invalid)
+		}
+	}
+}
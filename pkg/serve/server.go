@@ -0,0 +1,193 @@
+package serve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"md-manual-tool/pkg/export"
+	"md-manual-tool/pkg/markdown"
+	"md-manual-tool/pkg/processor"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// 预览服务使用的路径
+const (
+	AssetPrefix = "/_assets"
+	eventsPath  = "/_events"
+)
+
+// DefaultAddr 默认监听地址，只接受本机访问
+const DefaultAddr = "127.0.0.1:8080"
+
+// RenderFunc 在内存中渲染文档，图片地址以AssetPrefix开头
+type RenderFunc func() (*processor.Preview, error)
+
+// Server 本地预览服务：在内存中渲染文档并提供HTML页面和图片，文档重新渲染后通知浏览器刷新
+type Server struct {
+	Title  string
+	render RenderFunc
+
+	mu      sync.RWMutex
+	page    []byte
+	images  map[string]string
+	clients map[chan struct{}]bool
+}
+
+// NewServer 创建预览服务
+func NewServer(title string, render RenderFunc) *Server {
+	return &Server{
+		Title:   title,
+		render:  render,
+		images:  make(map[string]string),
+		clients: make(map[chan struct{}]bool),
+	}
+}
+
+// Reload 重新渲染文档并通知已打开的页面刷新；渲染失败时页面显示错误信息
+func (s *Server) Reload() error {
+	preview, err := s.render()
+
+	var body, lang string
+	images := make(map[string]string)
+	if err != nil {
+		body = fmt.Sprintf("<h1>渲染失败</h1>\n<pre class=\"error\">%s</pre>\n", html.EscapeString(err.Error()))
+	} else {
+		doc := markdown.Parse(string(preview.Markdown))
+		doc.AssignIDs(preview.SlugStyle)
+		body = doc.HTML()
+		images = preview.Images
+		lang = preview.Lang
+	}
+
+	s.mu.Lock()
+	s.page = s.buildPage(lang, body)
+	s.images = images
+	for ch := range s.clients {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+	s.mu.Unlock()
+	return err
+}
+
+// Handler 返回预览服务的HTTP处理器
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.handlePage)
+	mux.HandleFunc(AssetPrefix+"/", s.handleAsset)
+	mux.HandleFunc(eventsPath, s.handleEvents)
+	return mux
+}
+
+// ListenAndServe 在addr上提供预览服务直到ctx结束
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("监听 %s 失败: %v", addr, err)
+	}
+	return s.Serve(ctx, listener)
+}
+
+// Serve 在listener上提供预览服务直到ctx结束
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	server := &http.Server{
+		Handler: s.Handler(),
+		// 请求上下文继承ctx，退出时结束刷新通知的长连接
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	err := server.Serve(listener)
+	if errors.Is(err, http.ErrServerClosed) {
+		<-done
+		return nil
+	}
+	return err
+}
+
+// handlePage 返回渲染后的页面
+func (s *Server) handlePage(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	s.mu.RLock()
+	page := s.page
+	s.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(page)
+}
+
+// handleAsset 返回文档引用的本地图片
+func (s *Server) handleAsset(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	path, ok := s.images[r.URL.Path]
+	s.mu.RUnlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-cache")
+	http.ServeFile(w, r, path)
+}
+
+// handleEvents 以Server-Sent Events通知页面刷新
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "不支持事件流", http.StatusInternalServerError)
+		return
+	}
+
+	ch := make(chan struct{}, 1)
+	s.mu.Lock()
+	s.clients[ch] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, ch)
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ch:
+			fmt.Fprint(w, "data: reload\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// buildPage 生成完整的HTML页面，附带自动刷新脚本
+func (s *Server) buildPage(lang, body string) []byte {
+	css, _ := export.LoadTheme(nil, export.DefaultTheme, "")
+	return export.Page(lang, s.Title, "<style>\n"+css+"</style>", body, reloadScript)
+}
+
+// reloadScript 收到刷新通知后重新加载页面，连接断开时自动重连
+const reloadScript = `
+new EventSource("` + eventsPath + `").onmessage = function () { location.reload(); };
+`
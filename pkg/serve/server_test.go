@@ -0,0 +1,86 @@
+package serve
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"md-manual-tool/pkg/processor"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestServerReload(t *testing.T) {
+	imagePath := filepath.Join(t.TempDir(), "架构.png")
+	os.WriteFile(imagePath, []byte("png data"), 0644)
+
+	title := "v1"
+	var renderErr error
+	s := NewServer("手册", func() (*processor.Preview, error) {
+		if renderErr != nil {
+			return nil, renderErr
+		}
+		return &processor.Preview{
+			Markdown: []byte("# " + title + "\n![图](" + AssetPrefix + "/0/%E6%9E%B6%E6%9E%84.png)\n"),
+			Images:   map[string]string{AssetPrefix + "/0/架构.png": imagePath},
+			Lang:     "en-US",
+		}, nil
+	})
+	if err := s.Reload(); err != nil {
+		t.Fatalf("渲染失败: %v", err)
+	}
+
+	server := httptest.NewServer(s.Handler())
+	defer server.Close()
+
+	get := func(path string) (int, string) {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("请求 %s 失败: %v", path, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	if _, page := get("/"); !strings.Contains(page, ">v1</h1>") || !strings.Contains(page, "EventSource") || !strings.Contains(page, `<html lang="en-US">`) {
+		t.Errorf("页面内容错误: %s", page)
+	}
+	if status, body := get(AssetPrefix + "/0/%E6%9E%B6%E6%9E%84.png"); status != http.StatusOK || body != "png data" {
+		t.Errorf("图片请求错误: %d %q", status, body)
+	}
+	if status, _ := get(AssetPrefix + "/1/other.png"); status != http.StatusNotFound {
+		t.Errorf("未引用的图片应返回404，实际: %d", status)
+	}
+
+	// 订阅刷新通知后重新渲染
+	resp, err := http.Get(server.URL + eventsPath)
+	if err != nil {
+		t.Fatalf("订阅刷新通知失败: %v", err)
+	}
+	defer resp.Body.Close()
+	reader := bufio.NewReader(resp.Body)
+	reader.ReadString('\n') // 连接确认
+	reader.ReadString('\n')
+
+	title = "v2"
+	s.Reload()
+	if line, _ := reader.ReadString('\n'); line != "data: reload\n" {
+		t.Errorf("刷新通知错误: %q", line)
+	}
+	if _, page := get("/"); !strings.Contains(page, ">v2</h1>") {
+		t.Errorf("重新渲染后页面未更新: %s", page)
+	}
+
+	// 渲染失败时页面显示错误
+	renderErr = fmt.Errorf("模板语法错误 <x>")
+	if err := s.Reload(); err == nil {
+		t.Error("渲染失败时应返回错误")
+	}
+	if _, page := get("/"); !strings.Contains(page, "模板语法错误 &lt;x&gt;") {
+		t.Errorf("页面应显示渲染错误: %s", page)
+	}
+}
@@ -3,89 +3,162 @@ package template
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"md-manual-tool/pkg/constants"
+	"md-manual-tool/pkg/i18n"
+	"md-manual-tool/pkg/markdown"
+	"md-manual-tool/pkg/profile"
+	"os"
 	"regexp"
 	"strings"
 	"text/template"
 )
 
+// Renderer 模板渲染器
+//
+// 每次渲染的中间状态（如被保护的图片路径）都保存在调用内部，同一个渲染器可以被多个goroutine同时使用
+type Renderer struct {
+	Log     io.Writer     // 渲染过程的日志输出，默认为标准输出
+	Catalog *i18n.Catalog // {{t "键"}} 使用的消息目录，为nil时模板不能使用t函数
+	funcs   template.FuncMap
+}
+
+// Rendered 一次渲染的结果
+type Rendered struct {
+	Content     []byte
+	OldVersion  string              // 从模板文件名提取的原版本号
+	Occurrences []VersionOccurrence // 版本号文本替换的逐处结果，未做文本替换时为空
+	Checks      []profile.Check     // 模板函数has的判断结果，按首次出现的顺序
+}
+
+// NewRenderer 创建模板渲染器
+func NewRenderer() *Renderer {
+	return &Renderer{Log: os.Stdout, funcs: funcMap()}
+}
+
 // Render 渲染模板
 func Render(templatePath string, variables map[string]string) ([]byte, error) {
-	fmt.Printf("开始渲染模板: %s\n", templatePath)
+	fmt.Print(i18n.Sprintf(constants.MsgRenderStart, templatePath))
 
 	// 读取模板文件
 	templateContent, err := ioutil.ReadFile(templatePath)
 	if err != nil {
 		return nil, err
 	}
-	fmt.Printf("模板文件大小: %d 字节\n", len(templateContent))
+	fmt.Print(i18n.Sprintf(constants.MsgTemplateSize, len(templateContent)))
+	return RenderWithContent(templatePath, string(templateContent), variables)
+}
+
+// RenderWithContent 使用已读取的模板内容进行渲染
+func RenderWithContent(templatePath string, templateContent string, variables map[string]string) ([]byte, error) {
+	return NewRenderer().Render(templatePath, templateContent, variables)
+}
+
+// Render 使用已读取的模板内容进行渲染
+func (r *Renderer) Render(templatePath string, templateContent string, variables map[string]string) ([]byte, error) {
+	rendered, err := r.Execute(templatePath, templateContent, variables)
+	if err != nil {
+		return nil, err
+	}
+	return rendered.Content, nil
+}
+
+// Execute 使用已读取的模板内容进行渲染，返回渲染内容和版本号替换结果
+func (r *Renderer) Execute(templatePath string, templateContent string, variables map[string]string) (*Rendered, error) {
+	log := r.Log
+	if log == nil {
+		log = ioutil.Discard
+	}
+	fmt.Fprint(log, i18n.Sprintf(constants.MsgRenderStart, templatePath))
 
 	// 从模板文件名中提取版本号
+	rendered := &Rendered{}
 	oldVersion := extractVersionFromFilename(templatePath)
 	if oldVersion != "" {
-		fmt.Printf("从文件名提取的版本号: %s\n", oldVersion)
+		fmt.Fprint(log, i18n.Sprintf(constants.MsgVersionFromName, oldVersion))
 	}
+	rendered.OldVersion = oldVersion
 
-	// 如果有新版本号且找到了原版本号，进行替换
+	// 如果有新版本号且找到了原版本号，按规则进行替换；使用版本号占位符的模板不做文本替换
+	templateContent, placeholders := stripPlaceholderMarker(templateContent)
+	replace, err := versionReplaceEnabled(variables)
+	if err != nil {
+		return nil, err
+	}
 	if newVersion, exists := variables["version"]; exists && oldVersion != "" {
-		fmt.Printf("进行版本号替换: %s -> %s\n", oldVersion, newVersion)
-		content := string(templateContent)
-		// 替换内容中的版本号（排除图片路径）
-		content = replaceVersionInContent(content, oldVersion, newVersion)
-		templateContent = []byte(content)
-		fmt.Printf("版本号替换完成\n")
+		if placeholders || !replace {
+			fmt.Fprint(log, i18n.Msg(constants.MsgVersionPlaceholders))
+		} else {
+			rules, err := ParseVersionRules(variables)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Fprint(log, i18n.Sprintf(constants.MsgVersionReplacing, oldVersion, newVersion))
+			templateContent, rendered.Occurrences = replaceVersionInContent(templateContent, oldVersion, newVersion, rules)
+			printVersionReport(log, rendered.Occurrences, newVersion)
+		}
 	}
 
 	// 创建模板
-	tmpl, err := template.New("md").Parse(string(templateContent))
+	checks := &profileChecks{}
+	tmpl, err := template.New("md").Funcs(r.funcs).Funcs(template.FuncMap{"t": r.translate, "has": checks.has}).Parse(templateContent)
 	if err != nil {
 		return nil, err
 	}
 
 	// 渲染模板
 	var result bytes.Buffer
-	err = tmpl.Execute(&result, variables)
+	err = tmpl.Execute(&result, templateData(variables, oldVersion, placeholders))
 	if err != nil {
 		return nil, err
 	}
 
-	fmt.Printf("模板渲染完成，结果大小: %d 字节\n", result.Len())
-	return result.Bytes(), nil
+	fmt.Fprint(log, i18n.Sprintf(constants.MsgRenderDone, result.Len()))
+	rendered.Content = result.Bytes()
+	rendered.Checks = checks.checks
+	return rendered, nil
 }
 
-// RenderWithContent 使用已读取的模板内容进行渲染
-func RenderWithContent(templatePath string, templateContent string, variables map[string]string) ([]byte, error) {
-	fmt.Printf("开始渲染模板内容: %s\n", templatePath)
-
-	// 从模板文件名中提取版本号
-	oldVersion := extractVersionFromFilename(templatePath)
-	if oldVersion != "" {
-		fmt.Printf("从文件名提取的版本号: %s\n", oldVersion)
+// funcMap 模板函数
+//
+// toc 输出目录标记，渲染完成后由处理器根据文档中的标题展开为目录；
+// ref 输出交叉引用标记，编号完成后替换为标签对应的最终编号，如 {{ref "fig:arch"}} -> 图 3-1
+func funcMap() template.FuncMap {
+	return template.FuncMap{
+		"toc": func() string { return markdown.TOCMarker },
+		"ref": markdown.RefMarker,
 	}
+}
 
-	// 如果有新版本号且找到了原版本号，进行替换
-	if newVersion, exists := variables["version"]; exists && oldVersion != "" {
-		fmt.Printf("进行版本号替换: %s -> %s\n", oldVersion, newVersion)
-		// 替换内容中的版本号（排除图片路径）
-		templateContent = replaceVersionInContent(templateContent, oldVersion, newVersion)
-		fmt.Printf("版本号替换完成\n")
+// translate 模板函数t：返回消息目录中键对应的译文，如 {{t "install.title"}}、{{t "pages" 12}}
+func (r *Renderer) translate(key string, args ...interface{}) (string, error) {
+	if r.Catalog == nil {
+		return "", fmt.Errorf("未配置languages，不能使用 t \"%s\"", key)
 	}
+	return r.Catalog.T(key, args...)
+}
 
-	// 创建模板
-	tmpl, err := template.New("md").Parse(templateContent)
-	if err != nil {
-		return nil, err
-	}
+// profileChecks 记录一次渲染中模板函数has的判断结果
+type profileChecks struct {
+	checks []profile.Check
+}
 
-	// 渲染模板
-	var result bytes.Buffer
-	err = tmpl.Execute(&result, variables)
+// has 模板函数：判断画像是否包含属性值或键值对，如 {{if has .profile "linux"}}、{{if has .profile "edition=enterprise"}}
+func (c *profileChecks) has(value interface{}, want string) (bool, error) {
+	p, err := profile.From(value)
 	if err != nil {
-		return nil, err
+		return false, err
 	}
-
-	fmt.Printf("模板渲染完成，结果大小: %d 字节\n", result.Len())
-	return result.Bytes(), nil
+	result := p.Has(want)
+	for i := range c.checks {
+		if c.checks[i].Want == want {
+			c.checks[i].Count++
+			return result, nil
+		}
+	}
+	c.checks = append(c.checks, profile.Check{Want: want, Result: result, Count: 1})
+	return result, nil
 }
 
 // extractVersionFromFilename 从文件名中提取版本号
@@ -99,66 +172,47 @@ func extractVersionFromFilename(filename string) string {
 	return ""
 }
 
-// replaceVersionInContent 在内容中替换版本号（排除图片路径）
-func replaceVersionInContent(content, oldVersion, newVersion string) string {
-	// 先保护图片路径，避免被版本号替换影响
-	protectedContent := protectImagePaths(content)
-
-	// 替换各种可能的版本号格式
-	replacements := []string{
-		oldVersion,               // 直接替换
-		"v" + oldVersion,         // v1.0.0 格式
-		"版本 " + oldVersion,       // 版本 1.0.0 格式
-		"Version " + oldVersion,  // Version 1.0.0 格式
-		"版本号：" + oldVersion,      // 版本号：1.0.0 格式
-		"Version: " + oldVersion, // Version: 1.0.0 格式
-	}
-
-	result := protectedContent
-	for _, old := range replacements {
-		new := strings.Replace(old, oldVersion, newVersion, -1)
-		result = strings.Replace(result, new, old, -1) // 恢复被保护的图片路径
-		result = strings.Replace(result, old, new, -1) // 进行版本号替换
-	}
-
-	// 恢复图片路径
-	result = restoreImagePaths(result)
+// imagePathRe 图片引用
+var imagePathRe = regexp.MustCompile(`!\[.*?\]\([^)]*\.(png|jpg|jpeg|gif|bmp|webp|svg|ico|tiff|tif)\)`)
 
-	return result
+// imagePaths 一次替换中被保护的图片路径
+//
+// 占位符由私用区字符组成的前缀加序号构成，前缀保证不出现在原内容中，因此占位符不会与模板文字冲突；
+// 序号不含点，也不会被版本号匹配
+type imagePaths struct {
+	prefix string
+	paths  []string
 }
 
-// protectImagePaths 保护图片路径，避免被版本号替换影响
-func protectImagePaths(content string) string {
-	// 匹配图片路径的正则表达式
-	imagePattern := `!\[.*?\]\([^)]*\.(png|jpg|jpeg|gif|bmp|webp|svg|ico|tiff|tif)\)`
-	re := regexp.MustCompile(imagePattern)
-
-	// 用占位符替换图片路径
-	imagePaths := make(map[string]string)
-	counter := 0
+// protectImagePaths 用占位符替换图片引用，避免被版本号替换影响
+func protectImagePaths(content string) (string, *imagePaths) {
+	prefix := "\uE000"
+	for strings.Contains(content, prefix) {
+		prefix += "\uE000"
+	}
+	images := &imagePaths{prefix: prefix}
 
-	protectedContent := re.ReplaceAllStringFunc(content, func(match string) string {
-		placeholder := fmt.Sprintf("__IMAGE_PATH_%d__", counter)
-		imagePaths[placeholder] = match
-		counter++
+	protected := imagePathRe.ReplaceAllStringFunc(content, func(match string) string {
+		placeholder := images.placeholder(len(images.paths))
+		images.paths = append(images.paths, match)
 		return placeholder
 	})
+	return protected, images
+}
 
-	// 将图片路径映射存储到全局变量中（这里简化处理，实际应该用更好的方式）
-	globalImagePaths = imagePaths
-
-	return protectedContent
+// placeholder 第i个图片引用的占位符
+func (p *imagePaths) placeholder(i int) string {
+	return fmt.Sprintf("%s%d\uE001", p.prefix, i)
 }
 
-// restoreImagePaths 恢复图片路径
-func restoreImagePaths(content string) string {
-	result := content
-	for placeholder, imagePath := range globalImagePaths {
-		result = strings.Replace(result, placeholder, imagePath, -1)
+// restore 恢复图片引用
+func (p *imagePaths) restore(content string) string {
+	if len(p.paths) == 0 {
+		return content
+	}
+	pairs := make([]string, 0, 2*len(p.paths))
+	for i, path := range p.paths {
+		pairs = append(pairs, p.placeholder(i), path)
 	}
-	globalImagePaths = nil // 清理全局变量
-	return result
+	return strings.NewReplacer(pairs...).Replace(content)
 }
-
-// 全局变量存储图片路径映射（简化实现）
-var globalImagePaths map[string]string
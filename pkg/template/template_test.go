@@ -0,0 +1,104 @@
+package template
+
+import (
+	"fmt"
+	"md-manual-tool/pkg/i18n"
+	"md-manual-tool/pkg/profile"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestRendererConcurrent(t *testing.T) {
+	renderer := NewRenderer()
+	var wg sync.WaitGroup
+	errs := make(chan error, 64)
+	for i := 0; i < 64; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			content := fmt.Sprintf("# {{.title}} 1.0.0\n![图%d](images/1.0.0/%d.png)\n![图](images/1.0.0/%d-b.png) 1.0.0\n", i, i, i)
+			variables := map[string]string{"title": fmt.Sprintf("手册%d", i), "version": fmt.Sprintf("2.0.%d", i)}
+
+			result, err := renderer.Render("手册_1.0.0.md", content, variables)
+			if err != nil {
+				errs <- err
+				return
+			}
+			expected := fmt.Sprintf("# 手册%d 2.0.%d\n![图%d](images/1.0.0/%d.png)\n![图](images/1.0.0/%d-b.png) 2.0.%d\n", i, i, i, i, i, i)
+			if string(result) != expected {
+				errs <- fmt.Errorf("并发渲染结果错误:\n期望: %q\n实际: %q", expected, result)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	content := "# {{t \"install.title\"}}\n{{t \"install.pages\" 3}}\n"
+	renderer := NewRenderer()
+	if _, err := renderer.Render("手册.md", content, nil); err == nil {
+		t.Error("未配置消息目录时t函数应报错")
+	}
+
+	renderer.Catalog = &i18n.Catalog{Lang: "en-US", Messages: map[string]string{
+		"install.title": "Installation",
+		"install.pages": "%d pages",
+	}}
+	result, err := renderer.Render("手册.md", content, nil)
+	if err != nil || string(result) != "# Installation\n3 pages\n" {
+		t.Errorf("翻译结果错误: %q %v", result, err)
+	}
+	if _, err := renderer.Render("手册.md", "{{t \"missing\"}}", nil); err == nil {
+		t.Error("缺少译文时应报错")
+	}
+}
+
+func TestHas(t *testing.T) {
+	content := "{{if has .profile \"linux\"}}L{{end}}{{if has .profile \"os=windows\"}}W{{end}}{{if has .profile \"linux\"}}!{{end}}"
+	rendered, err := NewRenderer().Execute("手册.md", content, map[string]string{"profile": "edition=enterprise, os=linux"})
+	if err != nil {
+		t.Fatalf("渲染失败: %v", err)
+	}
+	if string(rendered.Content) != "L!" {
+		t.Errorf("渲染结果错误: %q", rendered.Content)
+	}
+	expected := []profile.Check{{Want: "linux", Result: true, Count: 2}, {Want: "os=windows", Result: false, Count: 1}}
+	if !reflect.DeepEqual(rendered.Checks, expected) {
+		t.Errorf("判断记录: %+v, 期望 %+v", rendered.Checks, expected)
+	}
+
+	// 未配置画像时条件均不成立
+	if result, err := NewRenderer().Render("手册.md", content, nil); err != nil || string(result) != "" {
+		t.Errorf("未配置画像时渲染结果错误: %q %v", result, err)
+	}
+	if _, err := NewRenderer().Render("手册.md", content, map[string]string{"profile": "linux"}); err == nil {
+		t.Error("无效的画像应报错")
+	}
+}
+
+func TestProtectImagePaths(t *testing.T) {
+	// 模板中与占位符形式相似的文字保持不变
+	content := "__IMAGE_PATH_0__  1.0.0 ![图](a_1.0.0.png)"
+	result, err := RenderWithContent("手册_1.0.0.md", content, map[string]string{"version": "1.1.0"})
+	if err != nil {
+		t.Fatalf("渲染失败: %v", err)
+	}
+	if expected := "__IMAGE_PATH_0__  1.1.0 ![图](a_1.0.0.png)"; string(result) != expected {
+		t.Errorf("期望: %q\n实际: %q", expected, result)
+	}
+
+	// 内容中已有与占位符相同的文字时，换用更长的前缀
+	original := "\uE0000\uE001 ![a](1.png) ![b](2.png)"
+	protected, images := protectImagePaths(original)
+	if images.prefix != "\uE000\uE000" || len(images.paths) != 2 {
+		t.Errorf("占位符前缀错误: %q %q", images.prefix, images.paths)
+	}
+	if restored := images.restore(protected); restored != original {
+		t.Errorf("恢复图片路径错误: %q", restored)
+	}
+}
@@ -0,0 +1,341 @@
+package template
+
+import (
+	"fmt"
+	"io"
+	"md-manual-tool/pkg/constants"
+	"md-manual-tool/pkg/i18n"
+	"md-manual-tool/pkg/utils"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PlaceholderMarker 模板使用版本号占位符的标记，带有该标记的模板不做版本号文本替换，渲染时标记被去掉
+const PlaceholderMarker = "<!-- version:placeholders -->"
+
+// VersionPlaceholder 迁移时替换版本号文本的占位符
+const VersionPlaceholder = "{{.version}}"
+
+// DefaultVersionPrefixes 默认允许紧贴版本号的前缀
+var DefaultVersionPrefixes = []string{"v", "V", "Version", "版本", "版本号："}
+
+var (
+	// urlRe 链接地址，其中的版本号属于外部资源，不替换
+	urlRe = regexp.MustCompile(`(?i)\b(?:https?|ftp)://[^\s<>()\[\]"']+`)
+	// keepRegionRe 模板中用注释标记的保护区域
+	keepRegionRe = regexp.MustCompile(`(?s)<!--\s*version:keep\s*-->.*?<!--\s*/version:keep\s*-->`)
+)
+
+// VersionRules 版本号替换规则
+//
+// 版本号前后不能紧贴字母、数字或"数字分隔的点"，因此13.2.0中的3.2.0、3.2.0.1中的3.2.0不会被替换；
+// 紧贴字母的前缀（如V3.2.0）需要在Prefixes中列出
+type VersionRules struct {
+	Prefixes      []string       // 允许的前缀，前缀与版本号之间可以有空格
+	RequirePrefix bool           // 只替换带前缀的版本号
+	Exclude       *regexp.Regexp // 排除规则，匹配范围内的版本号不替换，如 MySQL \d+(\.\d+)+
+	Protect       *regexp.Regexp // 自定义保护区域
+}
+
+// VersionOccurrence 模板中一处版本号及其处理结果
+type VersionOccurrence struct {
+	Line     int    // 行号，从1开始
+	Start    int    // 版本号在内容中的起止位置（不含前缀）
+	End      int    // 版本号结束位置
+	Prefix   string // 匹配到的前缀
+	Text     string // 原文（含前缀）
+	Replaced bool   // 是否替换
+	Reason   string // 未替换的原因
+}
+
+// DefaultVersionRules 返回默认替换规则
+func DefaultVersionRules() *VersionRules {
+	return &VersionRules{Prefixes: DefaultVersionPrefixes}
+}
+
+// ParseVersionRules 从配置项读取替换规则，未配置的项使用默认值
+func ParseVersionRules(variables map[string]string) (*VersionRules, error) {
+	rules := DefaultVersionRules()
+	if value := strings.TrimSpace(variables[constants.ConfigKeyVersionPrefixes]); value != "" {
+		rules.Prefixes = nil
+		for _, prefix := range strings.Split(value, ",") {
+			if prefix = strings.TrimSpace(prefix); prefix != "" {
+				rules.Prefixes = append(rules.Prefixes, prefix)
+			}
+		}
+	}
+	if value := strings.TrimSpace(variables[constants.ConfigKeyVersionRequirePrefix]); value != "" {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("配置项 %s 不是有效的布尔值: %s", constants.ConfigKeyVersionRequirePrefix, value)
+		}
+		rules.RequirePrefix = b
+	}
+
+	var err error
+	if rules.Exclude, err = compileRule(variables, constants.ConfigKeyVersionExclude); err != nil {
+		return nil, err
+	}
+	if rules.Protect, err = compileRule(variables, constants.ConfigKeyVersionProtect); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// compileRule 编译正则配置项，未配置时返回nil
+func compileRule(variables map[string]string, key string) (*regexp.Regexp, error) {
+	value := strings.TrimSpace(variables[key])
+	if value == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(value)
+	if err != nil {
+		return nil, fmt.Errorf("配置项 %s 不是有效的正则表达式: %v", key, err)
+	}
+	return re, nil
+}
+
+// FindVersions 查找内容中所有的版本号，并按规则判断是否替换
+func (r *VersionRules) FindVersions(content, version string) []VersionOccurrence {
+	if version == "" {
+		return nil
+	}
+	protected := spans(content, urlRe, keepRegionRe, r.Protect)
+	excluded := spans(content, r.Exclude)
+
+	var result []VersionOccurrence
+	for offset := 0; ; {
+		i := strings.Index(content[offset:], version)
+		if i < 0 {
+			break
+		}
+		start := offset + i
+		end := start + len(version)
+		offset = end
+
+		occ := VersionOccurrence{Start: start, End: end, Line: strings.Count(content[:start], "\n") + 1}
+		before, prefix := r.prefix(content[:start])
+		switch {
+		case !versionBoundaryBefore(before) || !versionBoundaryAfter(content[end:]):
+			occ.Reason = i18n.Msg(constants.MsgVersionNotStandalone)
+		case r.RequirePrefix && prefix == "":
+			occ.Reason = i18n.Msg(constants.MsgVersionNoPrefix)
+		case inSpans(protected, start, end):
+			occ.Reason = i18n.Msg(constants.MsgVersionProtected)
+		case inSpans(excluded, start, end):
+			occ.Reason = i18n.Msg(constants.MsgVersionExcluded)
+		default:
+			occ.Replaced = true
+		}
+		occ.Prefix = prefix
+		occ.Text = prefix + version
+		result = append(result, occ)
+	}
+	return result
+}
+
+// prefix 查找版本号前最长的前缀，返回前缀之前的内容和前缀（含前缀与版本号之间的空格）
+func (r *VersionRules) prefix(before string) (string, string) {
+	trimmed := strings.TrimRight(before, " \t")
+	rest, found := before, ""
+	for _, prefix := range r.Prefixes {
+		if !strings.HasSuffix(trimmed, prefix) || len(prefix) <= len(found) {
+			continue
+		}
+		// 以字母开头的前缀本身也需要是独立的，如 "dev3.2.0" 中的 v 不算前缀
+		candidate := strings.TrimSuffix(trimmed, prefix)
+		if isLetter(rune(prefix[0])) && !versionBoundaryBefore(candidate) {
+			continue
+		}
+		rest, found = candidate, prefix
+	}
+	if found == "" {
+		return before, ""
+	}
+	return rest, before[len(rest):]
+}
+
+// versionBoundaryBefore 判断版本号之前是否为边界：不能紧贴字母、数字、下划线或点
+func versionBoundaryBefore(before string) bool {
+	r := lastRune(before)
+	return !(isLetter(r) || isDigit(r) || r == '_' || r == '.')
+}
+
+// versionBoundaryAfter 判断版本号之后是否为边界：不能紧贴字母、数字、下划线，
+// 点之后不能是数字（句末的点可以）
+func versionBoundaryAfter(after string) bool {
+	if after == "" {
+		return true
+	}
+	r := rune(after[0])
+	if r == '.' {
+		return len(after) == 1 || !isDigit(rune(after[1]))
+	}
+	return !(isLetter(r) || isDigit(r) || r == '_')
+}
+
+// isLetter 判断是否为ASCII字母，中文等其他字符视为边界
+func isLetter(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+}
+
+// isDigit 判断是否为ASCII数字
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+// lastRune 返回字符串最后一个字节对应的字符，只用于判断ASCII字符
+func lastRune(s string) rune {
+	if s == "" {
+		return 0
+	}
+	return rune(s[len(s)-1])
+}
+
+// spans 返回所有正则匹配的区间
+func spans(content string, patterns ...*regexp.Regexp) [][]int {
+	var result [][]int
+	for _, re := range patterns {
+		if re != nil {
+			result = append(result, re.FindAllStringIndex(content, -1)...)
+		}
+	}
+	return result
+}
+
+// inSpans 判断区间是否与任一匹配区间重叠
+func inSpans(spans [][]int, start, end int) bool {
+	for _, span := range spans {
+		if start < span[1] && end > span[0] {
+			return true
+		}
+	}
+	return false
+}
+
+// replaceVersionInContent 按规则替换内容中的版本号（排除图片路径），返回替换后的内容和每一处版本号的处理结果
+func replaceVersionInContent(content, oldVersion, newVersion string, rules *VersionRules) (string, []VersionOccurrence) {
+	// 先保护图片路径，避免被版本号替换影响
+	protectedContent, images := protectImagePaths(content)
+
+	occurrences := rules.FindVersions(protectedContent, oldVersion)
+	var b strings.Builder
+	last := 0
+	for _, occ := range occurrences {
+		if occ.Replaced {
+			b.WriteString(protectedContent[last:occ.Start])
+			b.WriteString(newVersion)
+			last = occ.End
+		}
+	}
+	b.WriteString(protectedContent[last:])
+
+	// 恢复图片路径
+	return images.restore(b.String()), occurrences
+}
+
+// printVersionReport 输出每一处版本号的处理结果
+func printVersionReport(w io.Writer, occurrences []VersionOccurrence, newVersion string) {
+	replaced := 0
+	for _, occ := range occurrences {
+		if occ.Replaced {
+			replaced++
+			fmt.Fprint(w, i18n.Sprintf(constants.MsgVersionReplaced, occ.Line, occ.Text, occ.Prefix, newVersion))
+		} else {
+			fmt.Fprint(w, i18n.Sprintf(constants.MsgVersionSkipped, occ.Line, occ.Text, occ.Reason))
+		}
+	}
+	fmt.Fprint(w, i18n.Sprintf(constants.MsgVersionSummary, replaced, len(occurrences)-replaced))
+}
+
+// templateData 生成模板数据：配置项原样提供，versionInfo和prevVersion为结构化的版本号，
+// versionDate默认为当天。带有占位符标记的模板中version同样为结构化的版本号，
+// 其他模板中version保持为字符串，{{if eq .version "3.2.0"}} 等原有写法不受影响
+func templateData(variables map[string]string, oldVersion string, placeholders bool) map[string]interface{} {
+	data := make(map[string]interface{}, len(variables)+3)
+	for key, value := range variables {
+		data[key] = value
+	}
+	if version, err := utils.ParseVersion(variables["version"]); err == nil {
+		data["versionInfo"] = version
+		if placeholders {
+			data["version"] = version
+		}
+	}
+	if prevVersion, err := utils.ParseVersion(oldVersion); err == nil {
+		data["prevVersion"] = prevVersion
+	}
+	data[constants.ConfigKeyVersionDate] = VersionDate(variables)
+	return data
+}
+
+// VersionDate 返回模板中 {{.versionDate}} 的取值：配置的发布日期，未配置时为当天
+func VersionDate(variables map[string]string) string {
+	if date := variables[constants.ConfigKeyVersionDate]; date != "" {
+		return date
+	}
+	return time.Now().Format("2006-01-02")
+}
+
+// versionReplaceEnabled 读取是否按文本替换版本号，默认开启
+func versionReplaceEnabled(variables map[string]string) (bool, error) {
+	value := strings.TrimSpace(variables[constants.ConfigKeyVersionReplace])
+	if value == "" {
+		return true, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("配置项 %s 不是有效的布尔值: %s", constants.ConfigKeyVersionReplace, value)
+	}
+	return b, nil
+}
+
+// stripPlaceholderMarker 去掉占位符标记所在的行，返回去掉后的内容和是否带有标记
+func stripPlaceholderMarker(content string) (string, bool) {
+	i := strings.Index(content, PlaceholderMarker)
+	if i < 0 {
+		return content, false
+	}
+	end := i + len(PlaceholderMarker)
+	if strings.HasPrefix(content[end:], "\r\n") {
+		end += 2
+	} else if strings.HasPrefix(content[end:], "\n") {
+		end++
+	}
+	return content[:i] + content[end:], true
+}
+
+// MigrateVersions 将按规则会被替换的版本号文本改写为 {{.version}} 占位符，返回改写后的内容和改写的数量
+//
+// confirm对每一处版本号询问是否改写，参数为版本号和所在行的原文；有改写时在模板开头加上占位符标记，
+// 使未改写的版本号在渲染时保持原样
+func MigrateVersions(content, oldVersion string, rules *VersionRules, confirm func(occ VersionOccurrence, line string) (bool, error)) (string, int, error) {
+	lines := strings.Split(content, "\n")
+	protectedContent, images := protectImagePaths(content)
+	var b strings.Builder
+	last, count := 0, 0
+	for _, occ := range rules.FindVersions(protectedContent, oldVersion) {
+		if !occ.Replaced {
+			continue
+		}
+		ok, err := confirm(occ, strings.TrimRight(lines[occ.Line-1], "\r"))
+		if err != nil {
+			return "", 0, err
+		}
+		if ok {
+			b.WriteString(protectedContent[last:occ.Start])
+			b.WriteString(VersionPlaceholder)
+			last = occ.End
+			count++
+		}
+	}
+	b.WriteString(protectedContent[last:])
+	result := images.restore(b.String())
+
+	if count > 0 && !strings.Contains(result, PlaceholderMarker) {
+		result = PlaceholderMarker + "\n" + result
+	}
+	return result, count, nil
+}
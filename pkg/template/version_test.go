@@ -0,0 +1,144 @@
+package template
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReplaceVersionInContent(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		variables map[string]string
+		expected  string
+	}{
+		{"独立版本号", "版本 3.2.0，升级到3.2.0。", nil, "版本 3.3.0，升级到3.3.0。"},
+		{"更长的版本号", "13.2.0 3.2.0.1 3.2.01 a3.2.0 3.2.0b", nil, "13.2.0 3.2.0.1 3.2.01 a3.2.0 3.2.0b"},
+		{"默认前缀", "v3.2.0 V3.2.0 Version3.2.0 版本号：3.2.0 dev3.2.0", nil, "v3.3.0 V3.3.0 Version3.3.0 版本号：3.3.0 dev3.2.0"},
+		{"自定义前缀", "R3.2.0 v3.2.0", map[string]string{"versionPrefixes": "R"}, "R3.3.0 v3.2.0"},
+		{"只替换带前缀的版本号", "V3.2.0 版本：3.2.0 3.2.0", map[string]string{"versionPrefixes": "V, 版本：", "versionRequirePrefix": "true"}, "V3.3.0 版本：3.3.0 3.2.0"},
+		{"排除规则", "本产品 3.2.0 依赖 MySQL 3.2.0", map[string]string{"versionExclude": `MySQL \d+(\.\d+)+`}, "本产品 3.3.0 依赖 MySQL 3.2.0"},
+		{"链接地址", "[3.2.0 下载](https://example.com/3.2.0/a.zip)", nil, "[3.3.0 下载](https://example.com/3.2.0/a.zip)"},
+		{"图片路径", "3.2.0 ![图](images/3.2.0/a.png)", nil, "3.3.0 ![图](images/3.2.0/a.png)"},
+		{"保护区域", "3.2.0\n<!-- version:keep -->\n## 3.2.0 更新\n<!-- /version:keep -->", nil, "3.3.0\n<!-- version:keep -->\n## 3.2.0 更新\n<!-- /version:keep -->"},
+		{"自定义保护区域", "3.2.0\n```\n3.2.0\n```", map[string]string{"versionProtect": "(?s)```.*?```"}, "3.3.0\n```\n3.2.0\n```"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := ParseVersionRules(tt.variables)
+			if err != nil {
+				t.Fatalf("解析规则失败: %v", err)
+			}
+			result, _ := replaceVersionInContent(tt.input, "3.2.0", "3.3.0", rules)
+			if result != tt.expected {
+				t.Errorf("期望: %q\n实际: %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestFindVersions(t *testing.T) {
+	rules, _ := ParseVersionRules(map[string]string{"versionExclude": `MySQL [\d.]+`})
+	content := "# 手册 V3.2.0\n依赖 MySQL 3.2.0\n见 https://a.com/3.2.0\n13.2.0"
+	var report []string
+	for _, occ := range rules.FindVersions(content, "3.2.0") {
+		if content[occ.Start:occ.End] != "3.2.0" {
+			t.Errorf("位置错误: %+v", occ)
+		}
+		report = append(report, fmt.Sprintf("%d|%s|%s", occ.Line, occ.Text, occ.Reason))
+	}
+	expected := []string{"1|V3.2.0|", "2|3.2.0|匹配排除规则", "3|3.2.0|位于保护区域", "4|3.2.0|不是独立的版本号"}
+	if strings.Join(report, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("替换报告错误:\n期望: %q\n实际: %q", expected, report)
+	}
+
+	for key, value := range map[string]string{"versionExclude": "(", "versionRequirePrefix": "yes"} {
+		if _, err := ParseVersionRules(map[string]string{key: value}); err == nil || !strings.Contains(err.Error(), key) {
+			t.Errorf("无效的 %s 应返回错误: %v", key, err)
+		}
+	}
+}
+
+func TestVersionPlaceholders(t *testing.T) {
+	content := "版本 {{.version}}（主版本 {{.versionInfo.Major}}.{{.versionInfo.Minor}}，补丁 {{.versionInfo.Patch}}），" +
+		"上一版本 {{.prevVersion}}，发布于 {{.versionDate}}；旧文 3.2.0"
+	variables := map[string]string{"version": "3.3.1", "versionDate": "2024-05-01"}
+
+	result, err := RenderWithContent("手册_3.2.0.md", content, variables)
+	if err != nil {
+		t.Fatalf("渲染失败: %v", err)
+	}
+	expected := "版本 3.3.1（主版本 3.3，补丁 1），上一版本 3.2.0，发布于 2024-05-01；旧文 3.3.1"
+	if string(result) != expected {
+		t.Errorf("期望: %q\n实际: %q", expected, result)
+	}
+
+	// 带占位符标记的模板不做文本替换，标记行被去掉
+	result, err = RenderWithContent("手册_3.2.0.md", PlaceholderMarker+"\n"+content, variables)
+	if err != nil {
+		t.Fatalf("渲染失败: %v", err)
+	}
+	expected = strings.Replace(expected, "旧文 3.3.1", "旧文 3.2.0", 1)
+	if string(result) != expected {
+		t.Errorf("期望: %q\n实际: %q", expected, result)
+	}
+
+	// 带占位符标记的模板中version为结构化的版本号
+	result, err = RenderWithContent("手册_3.2.0.md", PlaceholderMarker+"\n{{.version.Major}}.{{.version.Minor}}", variables)
+	if err != nil || string(result) != "3.3" {
+		t.Errorf("结构化版本号: %q %v", result, err)
+	}
+
+	// 未带标记的模板中version仍为字符串，可以直接比较
+	result, err = RenderWithContent("手册_3.2.0.md", `{{if eq .version "3.3.1"}}新版{{end}}`, variables)
+	if err != nil || string(result) != "新版" {
+		t.Errorf("version应为字符串: %q %v", result, err)
+	}
+
+	// 配置关闭文本替换
+	variables["versionReplace"] = "false"
+	if result, _ = RenderWithContent("手册_3.2.0.md", content, variables); string(result) != expected {
+		t.Errorf("关闭文本替换后期望: %q\n实际: %q", expected, result)
+	}
+
+	// 未指定发布日期时为当天
+	result, _ = RenderWithContent("a.md", "{{.versionDate}}", map[string]string{})
+	if string(result) != time.Now().Format("2006-01-02") {
+		t.Errorf("默认发布日期错误: %q", result)
+	}
+	if date := VersionDate(map[string]string{"versionDate": "2024-05-01"}); date != "2024-05-01" {
+		t.Errorf("配置的发布日期: %q", date)
+	}
+}
+
+func TestMigrateVersions(t *testing.T) {
+	content := "# 手册 V3.2.0\n![图](images/3.2.0.png)\n安装 3.2.0，依赖 MySQL 3.2.0\n升级到 3.2.0 后重启"
+	rules, _ := ParseVersionRules(map[string]string{"versionExclude": `MySQL [\d.]+`})
+
+	var asked []string
+	answers := []bool{true, false, true}
+	result, count, err := MigrateVersions(content, "3.2.0", rules, func(occ VersionOccurrence, line string) (bool, error) {
+		asked = append(asked, fmt.Sprintf("%d:%s", occ.Line, line))
+		return answers[len(asked)-1], nil
+	})
+	if err != nil {
+		t.Fatalf("迁移失败: %v", err)
+	}
+
+	expected := PlaceholderMarker + "\n# 手册 V{{.version}}\n![图](images/3.2.0.png)\n安装 3.2.0，依赖 MySQL 3.2.0\n升级到 {{.version}} 后重启"
+	if result != expected || count != 2 {
+		t.Errorf("期望: %q (2)\n实际: %q (%d)", expected, result, count)
+	}
+	if len(asked) != 3 || asked[1] != "3:安装 3.2.0，依赖 MySQL 3.2.0" {
+		t.Errorf("确认的版本号错误: %q", asked)
+	}
+
+	// 迁移后的模板渲染时只替换占位符
+	rendered, _ := RenderWithContent("手册_3.2.0.md", result, map[string]string{"version": "3.3.0"})
+	if want := "# 手册 V3.3.0\n![图](images/3.2.0.png)\n安装 3.2.0，依赖 MySQL 3.2.0\n升级到 3.3.0 后重启"; string(rendered) != want {
+		t.Errorf("迁移后渲染错误: %q", rendered)
+	}
+}
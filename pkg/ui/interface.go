@@ -3,6 +3,9 @@ package ui
 import (
 	"fmt"
 	"md-manual-tool/pkg/constants"
+	"md-manual-tool/pkg/i18n"
+	"path/filepath"
+	"time"
 )
 
 // Interface UI交互接口
@@ -15,17 +18,17 @@ func NewInterface() *Interface {
 
 // ShowSuccess 显示成功信息
 func (ui *Interface) ShowSuccess(outputPath string) {
-	fmt.Printf(constants.MsgFileGenerated, outputPath)
+	fmt.Print(i18n.Sprintf(constants.MsgFileGenerated, outputPath))
 }
 
 // ShowError 显示错误信息
 func (ui *Interface) ShowError(message string) {
-	fmt.Printf("错误：%s\n", message)
+	fmt.Print(i18n.Sprintf(constants.MsgError, message))
 }
 
 // ShowValidationErrors 显示验证错误
 func (ui *Interface) ShowValidationErrors(errors []string) {
-	fmt.Println("验证失败：")
+	fmt.Println(i18n.Msg(constants.MsgValidationFailed))
 	for _, err := range errors {
 		fmt.Printf("  - %s\n", err)
 	}
@@ -33,7 +36,7 @@ func (ui *Interface) ShowValidationErrors(errors []string) {
 
 // ShowProgress 显示进度信息
 func (ui *Interface) ShowProgress(message string) {
-	fmt.Printf("正在处理：%s\n", message)
+	fmt.Print(i18n.Sprintf(constants.MsgProgress, message))
 }
 
 // ShowInfo 显示信息
@@ -45,3 +48,22 @@ func (ui *Interface) ShowInfo(message string) {
 func (ui *Interface) ShowInfoWithFormat(format string, args ...interface{}) {
 	fmt.Printf(format, args...)
 }
+
+// ShowChangedFiles 显示发生变化的文件
+func (ui *Interface) ShowChangedFiles(files []string) {
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		names = append(names, filepath.Base(f))
+	}
+	fmt.Print(i18n.Sprintf(constants.MsgFilesChanged, time.Now().Format("15:04:05"), names))
+}
+
+// ShowRenderResult 显示一次生成的简要结果
+func (ui *Interface) ShowRenderResult(outputPath string, elapsed time.Duration, stats string, err error) {
+	now := time.Now().Format("15:04:05")
+	if err != nil {
+		fmt.Print(i18n.Sprintf(constants.MsgRenderFailed, now, elapsed.Round(time.Millisecond), err))
+		return
+	}
+	fmt.Print(i18n.Sprintf(constants.MsgRenderSucceeded, now, outputPath, elapsed.Round(time.Millisecond), stats))
+}
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"encoding/base64"
+	"path"
+	"strings"
+)
+
+// DefaultEmbedMaxBytes 默认内嵌图片大小上限，超过该大小的图片仍复制到.assets目录
+const DefaultEmbedMaxBytes = 512 * 1024
+
+// imageMimeTypes 图片扩展名对应的MIME类型
+var imageMimeTypes = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".bmp":  "image/bmp",
+	".webp": "image/webp",
+	".svg":  "image/svg+xml",
+	".ico":  "image/x-icon",
+	".tiff": "image/tiff",
+	".tif":  "image/tiff",
+}
+
+// ImageMimeType 根据文件扩展名获取图片的MIME类型
+func ImageMimeType(filename string) string {
+	if mimeType, ok := imageMimeTypes[strings.ToLower(path.Ext(filename))]; ok {
+		return mimeType
+	}
+	return "application/octet-stream"
+}
+
+// EncodeDataURI 将图片内容编码为base64格式的data URI
+func EncodeDataURI(filename string, data []byte) string {
+	return "data:" + ImageMimeType(filename) + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+// shouldEmbed 判断图片是否应以data URI内嵌
+func shouldEmbed(opts *ImageOptions, size int) bool {
+	if !opts.EmbedImages {
+		return false
+	}
+	return opts.EmbedMaxBytes <= 0 || int64(size) <= opts.EmbedMaxBytes
+}
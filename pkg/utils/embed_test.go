@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCopyImagesFromTemplateEmbed(t *testing.T) {
+	tempDir := t.TempDir()
+	imagesDir := filepath.Join(tempDir, "images")
+	if err := os.MkdirAll(imagesDir, 0755); err != nil {
+		t.Fatalf("创建图片目录失败: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(imagesDir, "small.png"), []byte("small"), 0644); err != nil {
+		t.Fatalf("创建测试图片失败: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(imagesDir, "large.jpg"), []byte(strings.Repeat("x", 64)), 0644); err != nil {
+		t.Fatalf("创建测试图片失败: %v", err)
+	}
+
+	templatePath := filepath.Join(tempDir, "template.md")
+	outputPath := filepath.Join(tempDir, "manual.md")
+	content := "![小图](./images/small.png)\n![大图](./images/large.jpg)\n"
+
+	opts := DefaultImageOptions()
+	opts.EmbedImages = true
+	opts.EmbedMaxBytes = 32
+
+	updated, err := CopyImagesFromTemplateWithOptions(context.Background(), templatePath, outputPath, ExtractImages(content), content, opts)
+	if err != nil {
+		t.Fatalf("内嵌图片失败: %v", err)
+	}
+
+	expected := "![小图](data:image/png;base64," + base64.StdEncoding.EncodeToString([]byte("small")) + ")"
+	if !strings.Contains(updated, expected) {
+		t.Errorf("小图未内嵌为data URI: %s", updated)
+	}
+	if !strings.Contains(updated, "![大图](./manual.assets/large.jpg)") {
+		t.Errorf("超过阈值的图片应复制到.assets: %s", updated)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "manual.assets", "small.png")); !os.IsNotExist(err) {
+		t.Error("内嵌的图片不应写入.assets目录")
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "manual.assets", "large.jpg")); err != nil {
+		t.Errorf("超过阈值的图片未复制: %v", err)
+	}
+}
+
+func TestImageMimeType(t *testing.T) {
+	cases := map[string]string{
+		"a.PNG":  "image/png",
+		"b.jpeg": "image/jpeg",
+		"c.svg":  "image/svg+xml",
+		"d.txt":  "application/octet-stream",
+	}
+	for filename, expected := range cases {
+		if actual := ImageMimeType(filename); actual != expected {
+			t.Errorf("%s: 期望 %s, 实际 %s", filename, expected, actual)
+		}
+	}
+}
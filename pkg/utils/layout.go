@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// DefaultAssetDir 默认图片目录：与输出文件同级的<文件名>.assets（Typora约定）
+const DefaultAssetDir = "{name}.assets"
+
+// layoutPlaceholder 布局模式中的占位符，如 {version}
+var layoutPlaceholder = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// AssetLayout 图片输出布局
+type AssetLayout struct {
+	Dir       string            // 图片目录模式，相对路径基于输出文件所在目录
+	URLPrefix string            // 图片引用前缀（如 /static/img/{product}），为空时使用相对于输出文件的路径
+	Variables map[string]string // 占位符取值，{name}固定为输出文件名（不含扩展名）
+}
+
+// AssetDir 计算输出文件对应的图片目录
+func (l *AssetLayout) AssetDir(outputPath string) (string, error) {
+	pattern := DefaultAssetDir
+	if l != nil && l.Dir != "" {
+		pattern = l.Dir
+	}
+
+	dir, err := l.expand(pattern, outputPath)
+	if err != nil {
+		return "", err
+	}
+	dir = filepath.FromSlash(dir)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(filepath.Dir(outputPath), dir)
+	}
+	return filepath.Clean(dir), nil
+}
+
+// Reference 计算Markdown中引用图片所用的路径
+func (l *AssetLayout) Reference(outputPath, assetPath string) (string, error) {
+	if l != nil && l.URLPrefix != "" {
+		prefix, err := l.expand(l.URLPrefix, outputPath)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(prefix, "/") + "/" + filepath.Base(assetPath), nil
+	}
+
+	outputDir, err := filepath.Abs(filepath.Dir(outputPath))
+	if err != nil {
+		return "", fmt.Errorf("获取输出目录失败: %v", err)
+	}
+	absAssetPath, err := filepath.Abs(assetPath)
+	if err != nil {
+		return "", fmt.Errorf("获取图片路径失败: %v", err)
+	}
+
+	rel, err := filepath.Rel(outputDir, absAssetPath)
+	if err != nil {
+		// 不同盘符等无法计算相对路径时使用绝对路径
+		return filepath.ToSlash(absAssetPath), nil
+	}
+	rel = filepath.ToSlash(rel)
+	if !strings.HasPrefix(rel, "../") {
+		rel = "./" + rel
+	}
+	return rel, nil
+}
+
+// expand 替换模式中的占位符
+func (l *AssetLayout) expand(pattern, outputPath string) (string, error) {
+	name := strings.TrimSuffix(filepath.Base(outputPath), filepath.Ext(outputPath))
+
+	var missing []string
+	result := layoutPlaceholder.ReplaceAllStringFunc(pattern, func(match string) string {
+		key := match[1 : len(match)-1]
+		if key == "name" {
+			return name
+		}
+		if l != nil {
+			if value, ok := l.Variables[key]; ok && value != "" {
+				return value
+			}
+		}
+		missing = append(missing, key)
+		return match
+	})
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("图片布局 %s 中的占位符没有对应的配置项: %s", pattern, strings.Join(missing, ", "))
+	}
+	return result, nil
+}
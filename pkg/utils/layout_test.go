@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAssetLayout(t *testing.T) {
+	base := filepath.Join(string(filepath.Separator), "work", "output")
+	outputPath := filepath.Join(base, "docs", "manual_3.2.0.md")
+	variables := map[string]string{"product": "pdm", "version": "3.2.0"}
+
+	tests := []struct {
+		name      string
+		layout    *AssetLayout
+		expectDir string
+		expectRef string
+	}{
+		{"默认布局", nil, filepath.Join(base, "docs", "manual_3.2.0.assets"), "./manual_3.2.0.assets/a.png"},
+		{"共享目录", &AssetLayout{Dir: "../assets/{product}/{version}", Variables: variables}, filepath.Join(base, "assets", "pdm", "3.2.0"), "../assets/pdm/3.2.0/a.png"},
+		{"URL前缀", &AssetLayout{Dir: "static/img", URLPrefix: "/static/img/{version}/", Variables: variables}, filepath.Join(base, "docs", "static", "img"), "/static/img/3.2.0/a.png"},
+	}
+
+	for _, tt := range tests {
+		dir, err := tt.layout.AssetDir(outputPath)
+		if err != nil {
+			t.Fatalf("%s: 计算图片目录失败: %v", tt.name, err)
+		}
+		if dir != tt.expectDir {
+			t.Errorf("%s: 图片目录期望 %s, 实际 %s", tt.name, tt.expectDir, dir)
+		}
+
+		ref, err := tt.layout.Reference(outputPath, filepath.Join(dir, "a.png"))
+		if err != nil {
+			t.Fatalf("%s: 计算引用路径失败: %v", tt.name, err)
+		}
+		if ref != tt.expectRef {
+			t.Errorf("%s: 引用路径期望 %s, 实际 %s", tt.name, tt.expectRef, ref)
+		}
+	}
+
+	if _, err := (&AssetLayout{Dir: "assets/{edition}"}).AssetDir(outputPath); err == nil || !strings.Contains(err.Error(), "edition") {
+		t.Errorf("未知占位符应返回错误: %v", err)
+	}
+}
+
+func TestCopyImagesFromTemplateSharedLayout(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tempDir, "images"), 0755); err != nil {
+		t.Fatalf("创建图片目录失败: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "images", "a.png"), []byte("png"), 0644); err != nil {
+		t.Fatalf("创建测试图片失败: %v", err)
+	}
+
+	content := "![图](images/a.png)"
+	opts := DefaultImageOptions()
+	opts.Layout = &AssetLayout{Dir: "../assets/{product}/{version}", Variables: map[string]string{"product": "pdm", "version": "3.2.0"}}
+
+	outputPath := filepath.Join(tempDir, "wiki", "manual.md")
+	updated, err := CopyImagesFromTemplateWithOptions(context.Background(), filepath.Join(tempDir, "t.md"), outputPath, ExtractImages(content), content, opts)
+	if err != nil {
+		t.Fatalf("复制图片失败: %v", err)
+	}
+	if updated != "![图](../assets/pdm/3.2.0/a.png)" {
+		t.Errorf("图片引用错误: %s", updated)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "assets", "pdm", "3.2.0", "a.png")); err != nil {
+		t.Errorf("图片未写入共享目录: %v", err)
+	}
+}
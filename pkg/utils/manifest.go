@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"md-manual-tool/pkg/vfs"
+	"path/filepath"
+	"sort"
+)
+
+// AssetManifestName 图片目录中记录工具写入文件的清单文件名
+const AssetManifestName = ".md-manual-tool.json"
+
+// AssetManifest 图片目录清单，记录每个输出文件写入了哪些图片
+// 图片目录可能被多个输出文件共享，因此按输出文件分别记录
+type AssetManifest struct {
+	Outputs map[string][]string `json:"outputs"`
+}
+
+// LoadAssetManifest 读取图片目录中的清单，不存在时返回空清单
+func LoadAssetManifest(fsys fs.FS, assetDir string) (*AssetManifest, error) {
+	manifest := &AssetManifest{Outputs: make(map[string][]string)}
+
+	data, err := vfs.ReadFile(fsys, filepath.Join(assetDir, AssetManifestName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return manifest, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取图片清单失败: %v", err)
+	}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("解析图片清单失败: %v", err)
+	}
+	if manifest.Outputs == nil {
+		manifest.Outputs = make(map[string][]string)
+	}
+	return manifest, nil
+}
+
+// Save 保存清单，没有任何记录时删除清单文件
+func (m *AssetManifest) Save(fsys vfs.WriteFS, assetDir string) error {
+	path := filepath.Join(assetDir, AssetManifestName)
+	if len(m.Outputs) == 0 {
+		if err := vfs.Remove(fsys, path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("删除图片清单失败: %v", err)
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("生成图片清单失败: %v", err)
+	}
+	return vfs.WriteFile(fsys, path, data)
+}
+
+// referencedByOthers 判断文件是否被其他输出文件引用
+func (m *AssetManifest) referencedByOthers(outputKey, filename string) bool {
+	for key, files := range m.Outputs {
+		if key == outputKey {
+			continue
+		}
+		for _, f := range files {
+			if f == filename {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// UpdateAssetManifest 更新输出文件在图片目录中的记录，并删除不再引用的旧图片
+// keepStale为true时保留旧图片（仍记录在清单中，以便之后清理），返回被删除的文件名
+func UpdateAssetManifest(fsys vfs.WriteFS, assetDir, outputPath string, written []string, keepStale bool) ([]string, error) {
+	if _, err := vfs.Stat(fsys, assetDir); errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	manifest, err := LoadAssetManifest(fsys, assetDir)
+	if err != nil {
+		return nil, err
+	}
+
+	outputKey := manifestOutputKey(assetDir, outputPath)
+	current := make(map[string]bool)
+	for _, f := range written {
+		current[f] = true
+	}
+
+	var removed []string
+	for _, f := range manifest.Outputs[outputKey] {
+		if current[f] {
+			continue
+		}
+		if keepStale {
+			current[f] = true
+			continue
+		}
+		if manifest.referencedByOthers(outputKey, f) {
+			continue
+		}
+		if err := vfs.Remove(fsys, filepath.Join(assetDir, f)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return removed, fmt.Errorf("删除过期图片失败 %s: %v", f, err)
+		}
+		removed = append(removed, f)
+	}
+
+	files := make([]string, 0, len(current))
+	for f := range current {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	if len(files) == 0 {
+		delete(manifest.Outputs, outputKey)
+	} else {
+		manifest.Outputs[outputKey] = files
+	}
+
+	if err := manifest.Save(fsys, assetDir); err != nil {
+		return removed, err
+	}
+
+	// 图片目录已清空时一并删除（目录非空时删除会失败，忽略即可）
+	if len(manifest.Outputs) == 0 {
+		vfs.Remove(fsys, assetDir)
+	}
+	return removed, nil
+}
+
+// manifestOutputKey 清单中输出文件的键：相对于图片目录的路径
+func manifestOutputKey(assetDir, outputPath string) string {
+	absAssetDir, err1 := filepath.Abs(assetDir)
+	absOutput, err2 := filepath.Abs(outputPath)
+	if err1 == nil && err2 == nil {
+		if rel, err := filepath.Rel(absAssetDir, absOutput); err == nil {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.Base(outputPath)
+}
+
+// AssetsIntact 检查清单中记录的该输出文件的图片是否都仍存在
+func AssetsIntact(fsys fs.FS, assetDir, outputPath string) bool {
+	manifest, err := LoadAssetManifest(fsys, assetDir)
+	if err != nil {
+		return false
+	}
+	for _, f := range manifest.Outputs[manifestOutputKey(assetDir, outputPath)] {
+		if _, err := vfs.Stat(fsys, filepath.Join(assetDir, f)); err != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"context"
+	"md-manual-tool/pkg/vfs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCopyImagesFromTemplatePruneStale(t *testing.T) {
+	tempDir := t.TempDir()
+	templatePath := filepath.Join(tempDir, "t.md")
+	outputPath := filepath.Join(tempDir, "manual.md")
+	assetDir := filepath.Join(tempDir, "manual.assets")
+	writeTestFile(t, filepath.Join(tempDir, "images", "a.png"), "a")
+	writeTestFile(t, filepath.Join(tempDir, "images", "b.png"), "b")
+
+	render := func(content string, keepStale bool) {
+		opts := DefaultImageOptions()
+		opts.KeepStale = keepStale
+		if _, err := CopyImagesFromTemplateWithOptions(context.Background(), templatePath, outputPath, ExtractImages(content), content, opts); err != nil {
+			t.Fatalf("复制图片失败: %v", err)
+		}
+	}
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(assetDir, name))
+		return err == nil
+	}
+
+	render("![a](images/a.png)\n![b](images/b.png)", false)
+	// 用户手动放入的文件不在清单中，不应被删除
+	writeTestFile(t, filepath.Join(assetDir, "manual.png"), "manual")
+
+	render("![a](images/a.png)", true)
+	if !exists("b.png") {
+		t.Error("keepStale时不应删除旧图片")
+	}
+
+	render("![a](images/a.png)", false)
+	if exists("b.png") {
+		t.Error("不再引用的图片应被删除")
+	}
+	if !exists("a.png") || !exists("manual.png") {
+		t.Error("仍在引用的图片和非工具写入的文件不应被删除")
+	}
+
+	manifest, err := LoadAssetManifest(vfs.OSFS{}, assetDir)
+	if err != nil {
+		t.Fatalf("读取清单失败: %v", err)
+	}
+	if files := manifest.Outputs["../manual.md"]; len(files) != 1 || files[0] != "a.png" {
+		t.Errorf("清单内容错误: %v", manifest.Outputs)
+	}
+}
+
+func TestUpdateAssetManifestShared(t *testing.T) {
+	fsys := vfs.NewMemFS(map[string]string{"out/assets/shared.png": "shared", "out/assets/only-a.png": "a"})
+	assetDir := "out/assets"
+	outputA := "out/a.md"
+	outputB := "out/b.md"
+	if _, err := UpdateAssetManifest(fsys, assetDir, outputA, []string{"shared.png", "only-a.png"}, false); err != nil {
+		t.Fatalf("更新清单失败: %v", err)
+	}
+	if _, err := UpdateAssetManifest(fsys, assetDir, outputB, []string{"shared.png"}, false); err != nil {
+		t.Fatalf("更新清单失败: %v", err)
+	}
+
+	// a.md 不再引用任何图片：only-a.png被删除，shared.png仍被b.md引用
+	removed, err := UpdateAssetManifest(fsys, assetDir, outputA, nil, false)
+	if err != nil {
+		t.Fatalf("更新清单失败: %v", err)
+	}
+	if len(removed) != 1 || removed[0] != "only-a.png" {
+		t.Errorf("删除的文件错误: %v", removed)
+	}
+	if !AssetsIntact(fsys, assetDir, outputB) {
+		t.Error("被其他输出引用的图片不应删除")
+	}
+
+	// b.md 也不再引用：目录被清空并删除
+	if _, err := UpdateAssetManifest(fsys, assetDir, outputB, nil, false); err != nil {
+		t.Fatalf("更新清单失败: %v", err)
+	}
+	if _, err := fsys.Stat(assetDir); !os.IsNotExist(err) {
+		t.Error("清空后的图片目录应被删除")
+	}
+}
+
+func TestCopyImagesFromTemplateMemFS(t *testing.T) {
+	fsys := vfs.NewMemFS(map[string]string{
+		"docs/手册_1.0.0.md":  "![a](images/a.png) ![b](../shared/b.png)",
+		"docs/images/a.png": "a",
+		"shared/b.png":      "b",
+	})
+	content := "![a](images/a.png) ![b](../shared/b.png)"
+	opts := DefaultImageOptions()
+	opts.Resolver = &ImageResolver{Strict: true, FS: fsys}
+	opts.Output = fsys
+
+	updated, err := CopyImagesFromTemplateWithOptions(context.Background(), "docs/手册_1.0.0.md", "out/手册_1.1.0.md", ExtractImages(content), content, opts)
+	if err != nil {
+		t.Fatalf("复制图片失败: %v", err)
+	}
+	if expected := "![a](./手册_1.1.0.assets/a.png) ![b](./手册_1.1.0.assets/b.png)"; updated != expected {
+		t.Errorf("图片路径: %q, 期望 %q", updated, expected)
+	}
+
+	expected := []string{
+		"docs/images/a.png", "docs/手册_1.0.0.md",
+		"out/手册_1.1.0.assets/.md-manual-tool.json", "out/手册_1.1.0.assets/a.png", "out/手册_1.1.0.assets/b.png",
+		"shared/b.png",
+	}
+	if files := fsys.Files(); !reflect.DeepEqual(files, expected) {
+		t.Errorf("文件列表:\n%q\n期望:\n%q", files, expected)
+	}
+	if data, _ := fsys.ReadFile("out/手册_1.1.0.assets/b.png"); string(data) != "b" {
+		t.Errorf("图片内容错误: %q", data)
+	}
+}
@@ -0,0 +1,168 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"md-manual-tool/pkg/constants"
+	"md-manual-tool/pkg/i18n"
+	"path"
+	"strings"
+
+	_ "image/gif"
+)
+
+// DefaultJPEGQuality 重新编码JPEG时的默认质量
+const DefaultJPEGQuality = 85
+
+// OptimizeOptions 图片优化选项
+type OptimizeOptions struct {
+	MaxWidth       int  // 最大宽度，0表示不限制
+	MaxHeight      int  // 最大高度，0表示不限制
+	PNGCompression bool // 是否以最高压缩率重新编码PNG
+	JPEGQuality    int  // JPEG重新编码质量（1-100），0表示不重新编码
+	ConvertToWebP  bool // 是否转换为WebP无损格式
+}
+
+// Enabled 是否启用了任何优化
+func (o *OptimizeOptions) Enabled() bool {
+	return o != nil && (o.MaxWidth > 0 || o.MaxHeight > 0 || o.PNGCompression || o.JPEGQuality > 0 || o.ConvertToWebP)
+}
+
+// OptimizeImage 按选项处理图片，返回新的文件名和内容
+// 仅处理PNG、JPEG和GIF，其他格式或无法解码的图片原样返回
+func OptimizeImage(filename string, data []byte, opts *OptimizeOptions) (string, []byte, error) {
+	if !opts.Enabled() {
+		return filename, data, nil
+	}
+
+	ext := strings.ToLower(path.Ext(filename))
+	if ext != ".png" && ext != ".jpg" && ext != ".jpeg" && ext != ".gif" {
+		return filename, data, nil
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		fmt.Print(i18n.Sprintf(constants.MsgOptimizeSkipped, filename, err))
+		return filename, data, nil
+	}
+
+	resized := false
+	if scaled := resizeToFit(img, opts.MaxWidth, opts.MaxHeight); scaled != img {
+		b := img.Bounds()
+		fmt.Print(i18n.Sprintf(constants.MsgImageScaled, filename, b.Dx(), b.Dy(), scaled.Bounds().Dx(), scaled.Bounds().Dy()))
+		img = scaled
+		resized = true
+	}
+
+	if opts.ConvertToWebP {
+		encoded, err := EncodeWebPLossless(img)
+		if err != nil {
+			return filename, data, fmt.Errorf("转换WebP失败 %s: %v", filename, err)
+		}
+		// WebP不比原图小时保留原格式（缩放过的图片仍按原格式重新编码）
+		if len(encoded) < len(data) {
+			newFilename := strings.TrimSuffix(filename, path.Ext(filename)) + ".webp"
+			fmt.Print(i18n.Sprintf(constants.MsgImageOptimized, newFilename, len(data), len(encoded)))
+			return newFilename, encoded, nil
+		}
+	}
+
+	var buf bytes.Buffer
+	switch {
+	case format == "jpeg" && (opts.JPEGQuality > 0 || resized):
+		quality := opts.JPEGQuality
+		if quality <= 0 {
+			quality = DefaultJPEGQuality
+		}
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+			return filename, data, fmt.Errorf("编码JPEG失败 %s: %v", filename, err)
+		}
+	case format == "png" && (opts.PNGCompression || resized):
+		encoder := &png.Encoder{CompressionLevel: png.BestCompression}
+		if err := encoder.Encode(&buf, img); err != nil {
+			return filename, data, fmt.Errorf("编码PNG失败 %s: %v", filename, err)
+		}
+	default:
+		return filename, data, nil
+	}
+
+	// 未缩放时，重新编码后反而变大则保留原图
+	if !resized && buf.Len() >= len(data) {
+		return filename, data, nil
+	}
+
+	fmt.Print(i18n.Sprintf(constants.MsgImageOptimized, filename, len(data), buf.Len()))
+	return filename, buf.Bytes(), nil
+}
+
+// resizeToFit 按比例缩小图片使其不超过最大宽高，无需缩放时返回原图
+func resizeToFit(img image.Image, maxWidth, maxHeight int) image.Image {
+	b := img.Bounds()
+	width, height := b.Dx(), b.Dy()
+	scale := 1.0
+	if maxWidth > 0 && width > maxWidth {
+		scale = float64(maxWidth) / float64(width)
+	}
+	if maxHeight > 0 && height > maxHeight {
+		if s := float64(maxHeight) / float64(height); s < scale {
+			scale = s
+		}
+	}
+	if scale >= 1.0 {
+		return img
+	}
+
+	newWidth := maxInt(1, int(float64(width)*scale+0.5))
+	newHeight := maxInt(1, int(float64(height)*scale+0.5))
+	return boxResize(img, newWidth, newHeight)
+}
+
+// boxResize 使用区域平均法缩小图片，适合截图类图片
+func boxResize(img image.Image, newWidth, newHeight int) image.Image {
+	b := img.Bounds()
+	src := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+
+	dst := image.NewRGBA(image.Rect(0, 0, newWidth, newHeight))
+	srcWidth, srcHeight := b.Dx(), b.Dy()
+	for y := 0; y < newHeight; y++ {
+		y0 := y * srcHeight / newHeight
+		y1 := maxInt(y0+1, (y+1)*srcHeight/newHeight)
+		for x := 0; x < newWidth; x++ {
+			x0 := x * srcWidth / newWidth
+			x1 := maxInt(x0+1, (x+1)*srcWidth/newWidth)
+
+			var r, g, bl, a, n uint32
+			for sy := y0; sy < y1; sy++ {
+				offset := sy*src.Stride + x0*4
+				for sx := x0; sx < x1; sx++ {
+					r += uint32(src.Pix[offset])
+					g += uint32(src.Pix[offset+1])
+					bl += uint32(src.Pix[offset+2])
+					a += uint32(src.Pix[offset+3])
+					offset += 4
+					n++
+				}
+			}
+
+			i := y*dst.Stride + x*4
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(bl / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+// maxInt 返回较大值
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,136 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// encodeTestPNG 生成指定尺寸的测试PNG
+func encodeTestPNG(t *testing.T, width, height int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{uint8(x), uint8(y), 128, 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("编码测试PNG失败: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestOptimizeImageResize(t *testing.T) {
+	data := encodeTestPNG(t, 200, 100)
+
+	filename, optimized, err := OptimizeImage("shot.png", data, &OptimizeOptions{MaxWidth: 50})
+	if err != nil {
+		t.Fatalf("优化图片失败: %v", err)
+	}
+	if filename != "shot.png" {
+		t.Errorf("未转换格式时文件名不应改变: %s", filename)
+	}
+
+	img, err := png.Decode(bytes.NewReader(optimized))
+	if err != nil {
+		t.Fatalf("解码优化后的图片失败: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 50 || b.Dy() != 25 {
+		t.Errorf("缩放尺寸错误: 期望 50x25, 实际 %dx%d", b.Dx(), b.Dy())
+	}
+}
+
+func TestOptimizeImageWebP(t *testing.T) {
+	screenshot := testScreenshot(400, 300)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, screenshot); err != nil {
+		t.Fatalf("编码测试PNG失败: %v", err)
+	}
+	data := buf.Bytes()
+
+	filename, optimized, err := OptimizeImage("shot.png", data, &OptimizeOptions{ConvertToWebP: true})
+	if err != nil {
+		t.Fatalf("转换WebP失败: %v", err)
+	}
+	if filename != "shot.webp" {
+		t.Errorf("扩展名应改为.webp，实际: %s", filename)
+	}
+	if len(optimized) >= len(data) {
+		t.Errorf("WebP应比原图小: %d 字节 -> %d 字节", len(data), len(optimized))
+	}
+	assertSameImage(t, screenshot, decodeTestWebP(t, optimized))
+
+	// WebP不比原图小时保留原图和扩展名
+	data = encodeTestPNG(t, 16, 16)
+	filename, optimized, err = OptimizeImage("tiny.png", data, &OptimizeOptions{ConvertToWebP: true})
+	if err != nil {
+		t.Fatalf("转换WebP失败: %v", err)
+	}
+	if encoded, _ := EncodeWebPLossless(mustDecodePNG(t, data)); len(encoded) < len(data) {
+		t.Fatalf("测试图片的WebP应不比原图小: %d -> %d", len(data), len(encoded))
+	}
+	if filename != "tiny.png" || !bytes.Equal(optimized, data) {
+		t.Errorf("WebP不比原图小时应保留原图: %s %d 字节", filename, len(optimized))
+	}
+}
+
+// mustDecodePNG 解码测试PNG
+func mustDecodePNG(t *testing.T, data []byte) image.Image {
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("解码测试PNG失败: %v", err)
+	}
+	return img
+}
+
+func TestOptimizeImagePassThrough(t *testing.T) {
+	opts := &OptimizeOptions{MaxWidth: 10, ConvertToWebP: true}
+
+	// 无法解码的图片原样返回
+	filename, data, err := OptimizeImage("broken.png", []byte("not a png"), opts)
+	if err != nil || filename != "broken.png" || string(data) != "not a png" {
+		t.Errorf("无法解码的图片应原样返回: %s %q %v", filename, data, err)
+	}
+
+	// 不支持的格式原样返回
+	filename, data, err = OptimizeImage("logo.svg", []byte("<svg/>"), opts)
+	if err != nil || filename != "logo.svg" || string(data) != "<svg/>" {
+		t.Errorf("SVG应原样返回: %s %q %v", filename, data, err)
+	}
+}
+
+func TestCopyImagesFromTemplateOptimize(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tempDir, "images"), 0755); err != nil {
+		t.Fatalf("创建图片目录失败: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testScreenshot(400, 300)); err != nil {
+		t.Fatalf("编码测试PNG失败: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "images", "shot.png"), buf.Bytes(), 0644); err != nil {
+		t.Fatalf("创建测试图片失败: %v", err)
+	}
+
+	content := `![截图](./images/shot.png) <img src="images/shot.png" width="300">`
+	opts := DefaultImageOptions()
+	opts.Optimize = &OptimizeOptions{ConvertToWebP: true}
+
+	updated, err := CopyImagesFromTemplateWithOptions(context.Background(), filepath.Join(tempDir, "t.md"), filepath.Join(tempDir, "manual.md"), ExtractImages(content), content, opts)
+	if err != nil {
+		t.Fatalf("复制图片失败: %v", err)
+	}
+	if strings.Count(updated, "./manual.assets/shot.webp") != 2 {
+		t.Errorf("图片引用未更新为.webp: %s", updated)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "manual.assets", "shot.webp")); err != nil {
+		t.Errorf("转换后的图片不存在: %v", err)
+	}
+}
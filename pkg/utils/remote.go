@@ -0,0 +1,211 @@
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"md-manual-tool/pkg/constants"
+	"md-manual-tool/pkg/i18n"
+	"md-manual-tool/pkg/validator"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// RemoteImagePolicy 远程图片（http/https）的处理策略
+type RemoteImagePolicy string
+
+const (
+	// RemotePolicyKeep 保留远程地址，不做任何处理
+	RemotePolicyKeep RemoteImagePolicy = "keep"
+	// RemotePolicyDownload 下载到.assets目录并改写为本地路径
+	RemotePolicyDownload RemoteImagePolicy = "download"
+	// RemotePolicyReject 模板中出现远程图片时报错
+	RemotePolicyReject RemoteImagePolicy = "reject"
+)
+
+// 远程图片默认限制
+const (
+	DefaultRemoteTimeout  = 30 * time.Second
+	DefaultRemoteMaxBytes = 20 * 1024 * 1024
+)
+
+// ParseRemoteImagePolicy 解析远程图片策略，空字符串视为keep
+func ParseRemoteImagePolicy(value string) (RemoteImagePolicy, error) {
+	switch RemoteImagePolicy(strings.ToLower(strings.TrimSpace(value))) {
+	case "", RemotePolicyKeep:
+		return RemotePolicyKeep, nil
+	case RemotePolicyDownload:
+		return RemotePolicyDownload, nil
+	case RemotePolicyReject:
+		return RemotePolicyReject, nil
+	}
+	return "", fmt.Errorf("无效的远程图片策略: %s（可选值: keep、download、reject）", value)
+}
+
+// IsRemoteImage 判断图片路径是否为http/https远程地址
+func IsRemoteImage(imgPath string) bool {
+	lower := strings.ToLower(strings.TrimSpace(imgPath))
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// ImageFetcher 远程图片下载器接口，便于替换为测试桩或其他实现
+type ImageFetcher interface {
+	// Fetch 下载远程图片，ctx取消时中止下载
+	Fetch(ctx context.Context, url string) ([]byte, error)
+}
+
+// HTTPFetcher 基于net/http的远程图片下载器
+type HTTPFetcher struct {
+	Client   *http.Client
+	MaxBytes int64
+}
+
+// NewHTTPFetcher 创建带超时和大小限制的下载器
+func NewHTTPFetcher(timeout time.Duration, maxBytes int64) *HTTPFetcher {
+	return &HTTPFetcher{
+		Client:   &http.Client{Timeout: timeout},
+		MaxBytes: maxBytes,
+	}
+}
+
+// Fetch 下载远程图片内容
+func (f *HTTPFetcher) Fetch(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("下载失败: %v", err)
+	}
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("下载失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("下载失败: HTTP状态码 %d", resp.StatusCode)
+	}
+
+	// 优先根据Content-Length快速拒绝超大文件
+	if f.MaxBytes > 0 && resp.ContentLength > f.MaxBytes {
+		return nil, fmt.Errorf("图片大小 %d 字节超过限制 %d 字节", resp.ContentLength, f.MaxBytes)
+	}
+
+	reader := io.Reader(resp.Body)
+	if f.MaxBytes > 0 {
+		reader = io.LimitReader(resp.Body, f.MaxBytes+1)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %v", err)
+	}
+	if f.MaxBytes > 0 && int64(len(data)) > f.MaxBytes {
+		return nil, fmt.Errorf("图片大小超过限制 %d 字节", f.MaxBytes)
+	}
+
+	return data, nil
+}
+
+// RemoteImageCache 远程图片本地下载缓存，以URL的SHA-256作为文件名
+type RemoteImageCache struct {
+	Dir string
+}
+
+// NewRemoteImageCache 创建下载缓存，dir为空时使用用户缓存目录
+func NewRemoteImageCache(dir string) *RemoteImageCache {
+	if dir == "" {
+		userCacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return nil
+		}
+		dir = filepath.Join(userCacheDir, "md-manual-tool", "remote-images")
+	}
+	return &RemoteImageCache{Dir: dir}
+}
+
+// Get 从缓存中读取图片
+func (c *RemoteImageCache) Get(rawURL string) ([]byte, bool) {
+	if c == nil {
+		return nil, false
+	}
+	data, err := os.ReadFile(c.path(rawURL))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// Put 将图片写入缓存
+func (c *RemoteImageCache) Put(rawURL string, data []byte) error {
+	if c == nil {
+		return nil
+	}
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return fmt.Errorf("创建缓存目录失败: %v", err)
+	}
+	return os.WriteFile(c.path(rawURL), data, 0644)
+}
+
+// path 缓存文件路径
+func (c *RemoteImageCache) path(rawURL string) string {
+	return filepath.Join(c.Dir, hashURL(rawURL))
+}
+
+// hashURL 计算URL的SHA-256摘要
+func hashURL(rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	return hex.EncodeToString(sum[:])
+}
+
+// fetchRemoteImage 按缓存优先的方式获取远程图片
+func fetchRemoteImage(ctx context.Context, rawURL string, opts *ImageOptions) ([]byte, error) {
+	if data, ok := opts.Cache.Get(rawURL); ok {
+		fmt.Print(i18n.Sprintf(constants.MsgRemoteCacheHit, rawURL))
+		return data, nil
+	}
+
+	if opts.Fetcher == nil {
+		return nil, fmt.Errorf("未配置远程图片下载器")
+	}
+
+	fmt.Print(i18n.Sprintf(constants.MsgRemoteDownload, rawURL))
+	data, err := opts.Fetcher.Fetch(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := opts.Cache.Put(rawURL, data); err != nil {
+		fmt.Print(i18n.Sprintf(constants.MsgRemoteCacheFailed, err))
+	}
+	return data, nil
+}
+
+// remoteImageFilename 根据URL和图片内容生成.assets目录中的文件名，如 logo-1a2b3c4d.png
+//
+// 文件名带有URL摘要，不同地址（主机、路径或查询参数不同）的同名图片不会互相覆盖；
+// 扩展名按图片内容识别，无法识别时沿用URL中的扩展名
+func remoteImageFilename(rawURL string, data []byte) string {
+	name, ext := "", ""
+	if u, err := url.Parse(rawURL); err == nil {
+		if base := path.Base(u.Path); base != "/" && base != "." {
+			ext = path.Ext(base)
+			name = strings.TrimSuffix(base, ext)
+		}
+	}
+	if name == "" {
+		name = "image"
+	}
+	switch format := validator.SniffImageFormat(data); format {
+	case "":
+	case "jpeg":
+		ext = ".jpg"
+	default:
+		ext = "." + format
+	}
+	return name + "-" + hashURL(rawURL)[:8] + ext
+}
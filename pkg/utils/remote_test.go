@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newImageServer 创建返回固定图片内容的本地HTTP桩，并统计请求次数
+func newImageServer(t *testing.T, body string) (*httptest.Server, *int32) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		if r.URL.Path == "/missing.png" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server, &hits
+}
+
+func TestCopyImagesFromTemplateRemoteDownload(t *testing.T) {
+	server, hits := newImageServer(t, "remote png content")
+	tempDir := t.TempDir()
+
+	templatePath := filepath.Join(tempDir, "template.md")
+	outputPath := filepath.Join(tempDir, "out", "manual.md")
+	content := "![远程](" + server.URL + "/img/logo.png?v=1)\n"
+	filename := "logo-" + hashURL(server.URL + "/img/logo.png?v=1")[:8] + ".png"
+
+	opts := &ImageOptions{
+		RemotePolicy: RemotePolicyDownload,
+		Fetcher:      NewHTTPFetcher(time.Second, 1024),
+		Cache:        &RemoteImageCache{Dir: filepath.Join(tempDir, "cache")},
+	}
+
+	for i := 0; i < 2; i++ {
+		updated, err := CopyImagesFromTemplateWithOptions(context.Background(), templatePath, outputPath, ExtractImages(content), content, opts)
+		if err != nil {
+			t.Fatalf("下载远程图片失败: %v", err)
+		}
+		if !strings.Contains(updated, "](./manual.assets/"+filename+")") {
+			t.Errorf("远程图片路径未被改写: %s", updated)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(tempDir, "out", "manual.assets", filename))
+	if err != nil {
+		t.Fatalf("下载的图片不存在: %v", err)
+	}
+	if string(data) != "remote png content" {
+		t.Errorf("下载的图片内容不正确: %s", data)
+	}
+	if n := atomic.LoadInt32(hits); n != 1 {
+		t.Errorf("第二次渲染应命中缓存，实际请求次数: %d", n)
+	}
+}
+
+func TestRemoteImageFilename(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n")
+	urls := []string{
+		"https://a.example.com/x/logo.png",
+		"https://b.example.com/y/logo.png",
+		"https://a.example.com/img.png?id=1",
+		"https://a.example.com/img.png?id=2",
+	}
+	seen := make(map[string]string)
+	for _, rawURL := range urls {
+		name := remoteImageFilename(rawURL, png)
+		if other, ok := seen[name]; ok {
+			t.Errorf("%s 与 %s 的文件名相同: %s", rawURL, other, name)
+		}
+		seen[name] = rawURL
+	}
+
+	// 扩展名按内容识别
+	tests := []struct {
+		url      string
+		data     []byte
+		expected string
+	}{
+		{"https://example.com/avatar", png, "avatar-*.png"},
+		{"https://example.com/photo.png", []byte{0xff, 0xd8, 0xff, 0xe0}, "photo-*.jpg"},
+		{"https://example.com/chart.svg", []byte("unknown"), "chart-*.svg"},
+		{"https://example.com/", png, "image-*.png"},
+	}
+	for _, tt := range tests {
+		name := remoteImageFilename(tt.url, tt.data)
+		if ok, _ := filepath.Match(tt.expected, name); !ok {
+			t.Errorf("remoteImageFilename(%q) = %q, 期望 %s", tt.url, name, tt.expected)
+		}
+	}
+}
+
+func TestCopyImagesFromTemplateRemoteKeep(t *testing.T) {
+	tempDir := t.TempDir()
+	content := `![远程](https://example.com/a.png) <img src="http://example.com/b.jpg" />`
+
+	updated, err := CopyImagesFromTemplateWithOptions(context.Background(), filepath.Join(tempDir, "t.md"), filepath.Join(tempDir, "o.md"), ExtractImages(content), content, DefaultImageOptions())
+	if err != nil {
+		t.Fatalf("保留远程图片失败: %v", err)
+	}
+	if updated != content {
+		t.Errorf("keep策略不应修改内容，实际: %s", updated)
+	}
+}
+
+func TestCopyImagesFromTemplateRemoteReject(t *testing.T) {
+	tempDir := t.TempDir()
+	content := "![远程](https://example.com/a.png)"
+	outputPath := filepath.Join(tempDir, "o.md")
+
+	opts := DefaultImageOptions()
+	opts.RemotePolicy = RemotePolicyReject
+	if _, err := CopyImagesFromTemplateWithOptions(context.Background(), filepath.Join(tempDir, "t.md"), outputPath, ExtractImages(content), content, opts); err == nil {
+		t.Fatal("reject策略应返回错误")
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "o.assets")); !os.IsNotExist(err) {
+		t.Error("reject策略不应创建图片目录")
+	}
+}
+
+func TestHTTPFetcherLimits(t *testing.T) {
+	server, _ := newImageServer(t, strings.Repeat("x", 100))
+
+	if _, err := NewHTTPFetcher(time.Second, 10).Fetch(context.Background(), server.URL+"/big.png"); err == nil {
+		t.Error("超过大小限制应返回错误")
+	}
+	if _, err := NewHTTPFetcher(time.Second, 0).Fetch(context.Background(), server.URL+"/missing.png"); err == nil {
+		t.Error("HTTP 404应返回错误")
+	}
+	if data, err := NewHTTPFetcher(time.Second, 100).Fetch(context.Background(), server.URL+"/ok.png"); err != nil || len(data) != 100 {
+		t.Errorf("正常下载失败: %v", err)
+	}
+}
+
+func TestCopyImagesCanceled(t *testing.T) {
+	server, hits := newImageServer(t, "png")
+	tempDir := t.TempDir()
+	outputPath := filepath.Join(tempDir, "o.md")
+	content := "![a](" + server.URL + "/a.png)"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	opts := DefaultImageOptions()
+	opts.RemotePolicy = RemotePolicyDownload
+	_, err := CopyImagesFromTemplateWithOptions(ctx, filepath.Join(tempDir, "t.md"), outputPath, ExtractImages(content), content, opts)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("取消后应返回context.Canceled: %v", err)
+	}
+	if atomic.LoadInt32(hits) != 0 {
+		t.Error("取消后不应再下载图片")
+	}
+	if _, err := NewHTTPFetcher(time.Second, 0).Fetch(ctx, server.URL+"/ok.png"); err == nil {
+		t.Error("已取消的下载应返回错误")
+	}
+}
+
+func TestParseRemoteImagePolicy(t *testing.T) {
+	for input, expected := range map[string]RemoteImagePolicy{
+		"":         RemotePolicyKeep,
+		"keep":     RemotePolicyKeep,
+		"Download": RemotePolicyDownload,
+		"reject":   RemotePolicyReject,
+	} {
+		policy, err := ParseRemoteImagePolicy(input)
+		if err != nil || policy != expected {
+			t.Errorf("解析 %q: 期望 %s, 实际 %s (%v)", input, expected, policy, err)
+		}
+	}
+	if _, err := ParseRemoteImagePolicy("inline"); err == nil {
+		t.Error("无效策略应返回错误")
+	}
+}
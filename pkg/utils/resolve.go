@@ -0,0 +1,163 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"md-manual-tool/pkg/constants"
+	"md-manual-tool/pkg/i18n"
+	"md-manual-tool/pkg/vfs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// legacyImageDirs 未配置搜索路径时兜底查找的常见图片目录（仅按文件名匹配）
+var legacyImageDirs = []string{"images", "img", "assets", "pics", "pictures"}
+
+// ImageResolver 本地图片路径解析器
+//
+// 未配置搜索路径且非严格模式时沿用兼容策略（含当前工作目录和常见目录按文件名匹配）；
+// 配置了搜索路径时只按顺序在模板目录和各搜索目录中查找字面相对路径；
+// 严格模式只接受相对于模板目录的字面路径。
+type ImageResolver struct {
+	SearchPaths []string // 有序的搜索目录，相对路径基于模板所在目录
+	Strict      bool     // 严格模式
+	FS          fs.FS    // 模板和图片所在的文件系统，为nil时使用本地文件系统
+}
+
+// ResolvedImage 图片路径解析结果
+type ResolvedImage struct {
+	Source string // 模板中的原始路径
+	Path   string // 解析得到的文件路径
+	Rule   string // 命中的解析规则
+}
+
+// imageCandidate 待尝试的候选路径
+type imageCandidate struct {
+	path string
+	rule string
+}
+
+// Resolve 解析模板中引用的本地图片
+func (r *ImageResolver) Resolve(imgPath, templatePath string) (ResolvedImage, error) {
+	fmt.Print(i18n.Sprintf(constants.MsgResolveImage, imgPath, templatePath))
+
+	// 如果已经是绝对路径，直接返回
+	if filepath.IsAbs(imgPath) {
+		fmt.Print(i18n.Sprintf(constants.MsgImageAbsolute, imgPath))
+		return ResolvedImage{Source: imgPath, Path: imgPath, Rule: "绝对路径"}, nil
+	}
+
+	fsys := r.fs()
+	for _, candidate := range r.candidates(imgPath, templatePath) {
+		if _, err := vfs.Stat(fsys, candidate.path); err == nil {
+			fmt.Print(i18n.Sprintf(constants.MsgImageFound, candidate.rule, candidate.path))
+			return ResolvedImage{Source: imgPath, Path: candidate.path, Rule: candidate.rule}, nil
+		}
+	}
+
+	if r != nil && r.Strict {
+		return ResolvedImage{}, fmt.Errorf("严格模式下无法找到图片文件: %s", imgPath)
+	}
+	return ResolvedImage{}, fmt.Errorf("无法找到图片文件: %s", imgPath)
+}
+
+// fs 返回图片所在的文件系统
+func (r *ImageResolver) fs() fs.FS {
+	if r == nil || r.FS == nil {
+		return vfs.OSFS{}
+	}
+	return r.FS
+}
+
+// candidates 按优先级生成候选路径
+func (r *ImageResolver) candidates(imgPath, templatePath string) []imageCandidate {
+	templateDir := filepath.Dir(templatePath)
+	literal := filepath.FromSlash(literalImagePath(imgPath))
+
+	// 1. 相对于模板目录的字面路径
+	candidates := []imageCandidate{{filepath.Join(templateDir, literal), i18n.Msg(constants.MsgRuleTemplateDir)}}
+	if r != nil && r.Strict {
+		return candidates
+	}
+
+	// 2. 按顺序尝试配置的搜索目录
+	if r != nil && len(r.SearchPaths) > 0 {
+		for i, dir := range r.SearchPaths {
+			if !filepath.IsAbs(dir) {
+				dir = filepath.Join(templateDir, dir)
+			}
+			candidates = append(candidates, imageCandidate{filepath.Join(dir, literal), i18n.Sprintf(constants.MsgRuleSearchPath, i+1, r.SearchPaths[i])})
+		}
+		return candidates
+	}
+
+	return append(candidates, legacyCandidates(imgPath, templateDir)...)
+}
+
+// legacyCandidates 未配置搜索路径时的兼容策略
+func legacyCandidates(imgPath, templateDir string) []imageCandidate {
+	var candidates []imageCandidate
+
+	// 尝试相对于当前工作目录
+	currentDir, err := os.Getwd()
+	if err == nil {
+		candidates = append(candidates, imageCandidate{filepath.Join(currentDir, filepath.FromSlash(literalImagePath(imgPath))), i18n.Msg(constants.MsgRuleWorkingDir)})
+	}
+
+	// 尝试常见的图片目录（仅按文件名匹配，可能命中其他目录中的同名图片）
+	base := imageBaseName(imgPath)
+	for _, dir := range legacyImageDirs {
+		candidates = append(candidates, imageCandidate{filepath.Join(templateDir, dir, base), i18n.Sprintf(constants.MsgRuleTemplateDirByName, dir)})
+		if err == nil {
+			candidates = append(candidates, imageCandidate{filepath.Join(currentDir, dir, base), i18n.Sprintf(constants.MsgRuleWorkingDirByName, dir)})
+		}
+	}
+
+	return candidates
+}
+
+// literalImagePath 去除URL参数并统一分隔符为斜杠，保留 ./ 和 ../ 等相对部分
+func literalImagePath(imgPath string) string {
+	if idx := strings.Index(imgPath, "?"); idx != -1 {
+		imgPath = imgPath[:idx]
+	}
+	return strings.ReplaceAll(imgPath, "\\", "/")
+}
+
+// PrintResolveReport 输出图片解析报告
+func PrintResolveReport(resolved []ResolvedImage) {
+	if len(resolved) == 0 {
+		return
+	}
+	fmt.Println(i18n.Msg(constants.MsgResolveReport))
+	for i, r := range resolved {
+		fmt.Printf("  %d. %s -> %s [%s]\n", i+1, r.Source, r.Path, r.Rule)
+	}
+}
+
+// ImageSourceChecksums 计算模板引用的图片源文件摘要，远程图片以URL本身参与计算
+func ImageSourceChecksums(imagePaths []string, templatePath string, resolver *ImageResolver) []string {
+	sums := make([]string, 0, len(imagePaths))
+	for _, imgPath := range imagePaths {
+		if IsRemoteImage(imgPath) {
+			sums = append(sums, imgPath)
+			continue
+		}
+		resolved, err := resolver.Resolve(imgPath, templatePath)
+		if err != nil {
+			sums = append(sums, imgPath+":missing")
+			continue
+		}
+		data, err := vfs.ReadFile(resolver.fs(), resolved.Path)
+		if err != nil {
+			sums = append(sums, imgPath+":unreadable")
+			continue
+		}
+		sum := sha256.Sum256(data)
+		sums = append(sums, imgPath+":"+hex.EncodeToString(sum[:]))
+	}
+	return sums
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestFile 创建测试文件及其目录
+func writeTestFile(t *testing.T, path, content string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("创建目录失败: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("创建文件失败: %v", err)
+	}
+}
+
+func TestImageResolver(t *testing.T) {
+	tempDir := t.TempDir()
+	templatePath := filepath.Join(tempDir, "docs", "manual.md")
+	writeTestFile(t, filepath.Join(tempDir, "docs", "figures", "arch.png"), "local")
+	writeTestFile(t, filepath.Join(tempDir, "shared", "figures", "arch.png"), "shared")
+	writeTestFile(t, filepath.Join(tempDir, "docs", "images", "logo.png"), "unrelated")
+
+	tests := []struct {
+		name     string
+		resolver *ImageResolver
+		imgPath  string
+		expected string
+		rule     string
+	}{
+		{"字面路径优先", &ImageResolver{SearchPaths: []string{"../shared"}}, "figures/arch.png", filepath.Join(tempDir, "docs", "figures", "arch.png"), "模板目录"},
+		{"按顺序查找搜索路径", &ImageResolver{SearchPaths: []string{"missing", "../shared"}}, "./figures/arch.png?v=2", filepath.Join(tempDir, "docs", "figures", "arch.png"), "模板目录"},
+		{"Windows分隔符", &ImageResolver{SearchPaths: []string{"missing", "../shared"}}, "figures\\arch.png", filepath.Join(tempDir, "docs", "figures", "arch.png"), "模板目录"},
+		{"兼容策略按文件名匹配", nil, "other/logo.png", filepath.Join(tempDir, "docs", "images", "logo.png"), "按文件名匹配"},
+	}
+
+	for _, tt := range tests {
+		resolved, err := tt.resolver.Resolve(tt.imgPath, templatePath)
+		if err != nil {
+			t.Errorf("%s: 解析失败: %v", tt.name, err)
+			continue
+		}
+		if resolved.Path != tt.expected || !strings.Contains(resolved.Rule, tt.rule) {
+			t.Errorf("%s: 期望 %s [%s], 实际 %s [%s]", tt.name, tt.expected, tt.rule, resolved.Path, resolved.Rule)
+		}
+	}
+
+	// 只存在于搜索路径中的图片
+	os.Remove(filepath.Join(tempDir, "docs", "figures", "arch.png"))
+	resolved, err := (&ImageResolver{SearchPaths: []string{"missing", "../shared"}}).Resolve("figures/arch.png", templatePath)
+	if err != nil || resolved.Path != filepath.Join(tempDir, "shared", "figures", "arch.png") || !strings.Contains(resolved.Rule, "搜索路径[2]") {
+		t.Errorf("应在第二个搜索路径中找到图片: %+v %v", resolved, err)
+	}
+
+	// 配置了搜索路径或严格模式时不再按文件名匹配
+	for _, resolver := range []*ImageResolver{{SearchPaths: []string{"figures"}}, {Strict: true}} {
+		if resolved, err := resolver.Resolve("other/logo.png", templatePath); err == nil {
+			t.Errorf("不应按文件名匹配到无关图片: %+v", resolved)
+		}
+	}
+}
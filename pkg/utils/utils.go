@@ -1,141 +1,85 @@
 package utils
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"os"
+	"io/fs"
+	"md-manual-tool/pkg/constants"
+	"md-manual-tool/pkg/i18n"
+	"md-manual-tool/pkg/validator"
+	"md-manual-tool/pkg/vfs"
+	"path"
 	"path/filepath"
 	"regexp"
 	"strings"
 )
 
-// EnsureDir 确保目录存在，如果不存在则创建
-func EnsureDir(path string) error {
-	// 处理Windows路径
-	path = filepath.Clean(path)
-
-	// 处理UNC路径（网络路径）
-	if strings.HasPrefix(path, `\\`) {
-		// 对于网络路径，确保路径格式正确
-		path = strings.ReplaceAll(path, "/", "\\")
-	} else {
-		// 对于本地路径，转换为绝对路径
-		if !filepath.IsAbs(path) {
-			absPath, err := filepath.Abs(path)
-			if err != nil {
-				return fmt.Errorf("获取绝对路径失败: %v", err)
-			}
-			path = absPath
-		}
-	}
-
-	// 检查路径是否有效
-	// 只检查Windows不允许的字符，不检查中文等合法字符
-	invalidChars := []string{"<", ">", "\"", "|", "?", "*"}
-	for _, char := range invalidChars {
-		if strings.Contains(path, char) {
-			return fmt.Errorf("路径包含无效字符 '%s': %s", char, path)
-		}
-	}
-
-	// 检查冒号（:）的使用是否合法
-	// 只允许在盘符位置使用冒号
-	if strings.Count(path, ":") > 1 || (strings.Contains(path, ":") && !strings.HasPrefix(path, `\\`) && !strings.HasPrefix(path, `//`)) {
-		// 检查冒号是否在正确的位置（盘符后）
-		parts := strings.Split(path, ":")
-		if len(parts) > 1 && !strings.HasPrefix(parts[1], "\\") {
-			return fmt.Errorf("冒号使用位置不正确: %s", path)
-		}
-	}
-
-	// 如果是文件路径，则创建其父目录
-	if strings.Contains(path, ".") {
-		path = filepath.Dir(path)
-	}
-
-	// 处理长路径
-	if len(path) > 260 {
-		// 添加长路径前缀
-		if !strings.HasPrefix(path, `\\?\`) {
-			path = `\\?\` + path
-		}
-	}
+// imageExtensions 支持的图片格式
+var imageExtensions = []string{
+	`png`,  // PNG格式
+	`jpg`,  // JPG格式
+	`jpeg`, // JPEG格式
+	`gif`,  // GIF格式
+	`bmp`,  // BMP格式
+	`webp`, // WebP格式
+	`svg`,  // SVG格式
+	`ico`,  // ICO格式
+	`tiff`, // TIFF格式
+	`tif`,  // TIF格式
+}
 
-	// 创建目录
-	err := os.MkdirAll(path, 0755)
-	if err != nil {
+// EnsureDir 确保目录存在，如果不存在则创建
+//
+// dir总是被当作目录，名称中带点的目录（如 output/v3.2、~/.docs）也会被创建；
+// 路径按当前操作系统的规则检查，Windows上超长路径自动加上长路径前缀
+func EnsureDir(dir string) error {
+	if err := (vfs.OSFS{}).MkdirAll(dir); err != nil {
 		return fmt.Errorf("创建目录失败: %v", err)
 	}
-
 	return nil
 }
 
-// WriteFile 写入文件
-func WriteFile(path string, content []byte) error {
-	// 处理长路径
-	if len(path) > 260 {
-		// 添加长路径前缀
-		if !strings.HasPrefix(path, `\\?\`) {
-			path = `\\?\` + path
-		}
-	}
-
-	// 确保目录存在
-	if err := EnsureDir(path); err != nil {
+// EnsureFileDir 确保文件所在的目录存在，file是文件路径
+func EnsureFileDir(file string) error {
+	if err := vfs.ValidatePath(file); err != nil {
 		return err
 	}
+	return EnsureDir(filepath.Dir(file))
+}
 
-	// 写入文件
-	return os.WriteFile(path, content, 0644)
+// WriteFile 写入本地文件，自动创建所在目录
+func WriteFile(path string, content []byte) error {
+	return vfs.WriteFile(vfs.OSFS{}, path, content)
 }
 
-// ReadFile 读取文件内容
+// ReadFile 读取本地文件内容
 func ReadFile(path string) ([]byte, error) {
-	// 处理长路径
-	if len(path) > 260 {
-		// 添加长路径前缀
-		if !strings.HasPrefix(path, `\\?\`) {
-			path = `\\?\` + path
-		}
-	}
-	return os.ReadFile(path)
+	return vfs.OSFS{}.ReadFile(path)
 }
 
 // ExtractImages 从Markdown内容中提取图片路径
 func ExtractImages(content string) []string {
-	fmt.Println("ExtractImages收到的content内容如下:\n" + content)
-
-	// 支持的图片格式
-	imagePatterns := []string{
-		`png`,  // PNG格式
-		`jpg`,  // JPG格式
-		`jpeg`, // JPEG格式
-		`gif`,  // GIF格式
-		`bmp`,  // BMP格式
-		`webp`, // WebP格式
-		`svg`,  // SVG格式
-		`ico`,  // ICO格式
-		`tiff`, // TIFF格式
-		`tif`,  // TIF格式
-	}
-
 	var paths []string
 
 	// 1. 匹配Markdown格式图片 ![alt](path)
-	mdPattern := `(?s)!\[.*?\]\((.+?\.(?:` + strings.Join(imagePatterns, "|") + `)(?:\?[^)]*)?)\)`
+	mdPattern := `(?s)!\[.*?\]\((.+?\.(?:` + strings.Join(imageExtensions, "|") + `)(?:\?[^)]*)?)\)`
 	mdRe := regexp.MustCompile(mdPattern)
 	mdMatches := mdRe.FindAllStringSubmatch(content, -1)
 
 	for _, match := range mdMatches {
 		if len(match) > 1 {
-			// 保持原始路径格式，只去除首尾空格
-			path := strings.TrimSpace(match[1])
+			// 保持原始路径格式，只去除首尾空格并还原Markdown转义的反斜杠
+			path := unescapeMarkdownPath(strings.TrimSpace(match[1]))
 			paths = append(paths, path)
 		}
 	}
 
 	// 2. 匹配HTML格式图片 <img src="path" ... />
 	// 更宽松的正则表达式，可以匹配各种格式的HTML图片标签
-	htmlPattern := `<img\s+[^>]*?src=["']([^"']+?\.(?:` + strings.Join(imagePatterns, "|") + `)(?:[^"'>]*)?)["'][^>]*?>`
+	htmlPattern := `<img\s+[^>]*?src=["']([^"']+?\.(?:` + strings.Join(imageExtensions, "|") + `)(?:[^"'>]*)?)["'][^>]*?>`
 	htmlRe := regexp.MustCompile(htmlPattern)
 	htmlMatches := htmlRe.FindAllStringSubmatch(content, -1)
 
@@ -145,7 +89,7 @@ func ExtractImages(content string) []string {
 			path := strings.TrimSpace(match[1])
 
 			// 修复错误格式的路径，如果路径末尾有多余的.png等后缀
-			for _, ext := range imagePatterns {
+			for _, ext := range imageExtensions {
 				doubleExt := "." + ext + "." + ext
 				if strings.HasSuffix(path, doubleExt) {
 					path = path[:len(path)-len("."+ext)]
@@ -156,15 +100,14 @@ func ExtractImages(content string) []string {
 		}
 	}
 
-	// 打印提取到的图片路径
-	fmt.Printf("提取到的图片路径数量: %d\n", len(paths))
-	for i, path := range paths {
-		fmt.Printf("图片路径 %d: %s\n", i+1, path)
-	}
-
 	return paths
 }
 
+// unescapeMarkdownPath 还原Markdown链接地址中转义的反斜杠（\\ -> \）
+func unescapeMarkdownPath(path string) string {
+	return strings.ReplaceAll(path, `\\`, `\`)
+}
+
 // normalizeImagePath 标准化图片路径
 func normalizeImagePath(path string) string {
 	// 移除URL参数（如 ?v=123）
@@ -190,84 +133,11 @@ func normalizeImagePath(path string) string {
 
 // resolveImagePath 解析图片路径（支持绝对路径和相对路径）
 func resolveImagePath(imgPath, templatePath string) (string, error) {
-	fmt.Printf("解析图片路径: %s (相对于模板: %s)\n", imgPath, templatePath)
-
-	// 如果已经是绝对路径，直接返回
-	if filepath.IsAbs(imgPath) {
-		fmt.Printf("图片路径是绝对路径: %s\n", imgPath)
-		return imgPath, nil
-	}
-
-	// 处理相对路径 - 保持原始格式，但尝试多种解析方式
-	templateDir := filepath.Dir(templatePath)
-
-	// 1. 直接拼接模板目录和图片路径
-	resolvedPath := filepath.Join(templateDir, imgPath)
-	if _, err := os.Stat(resolvedPath); err == nil {
-		fmt.Printf("找到图片文件: %s\n", resolvedPath)
-		return resolvedPath, nil
-	}
-
-	// 2. 处理以 ./ 开头的路径
-	if strings.HasPrefix(imgPath, "./") {
-		relativePath := imgPath[2:] // 移除 ./
-		resolvedPath = filepath.Join(templateDir, relativePath)
-		if _, err := os.Stat(resolvedPath); err == nil {
-			fmt.Printf("找到图片文件 (./): %s\n", resolvedPath)
-			return resolvedPath, nil
-		}
-	}
-
-	// 3. 处理以 ../ 开头的路径
-	if strings.HasPrefix(imgPath, "../") {
-		relativePath := imgPath[3:] // 移除 ../
-		parentDir := filepath.Dir(templateDir)
-		resolvedPath = filepath.Join(parentDir, relativePath)
-		if _, err := os.Stat(resolvedPath); err == nil {
-			fmt.Printf("找到图片文件 (../): %s\n", resolvedPath)
-			return resolvedPath, nil
-		}
-	}
-
-	// 4. 尝试相对于当前工作目录
-	currentDir, err := os.Getwd()
-	if err == nil {
-		resolvedPath = filepath.Join(currentDir, imgPath)
-		if _, err := os.Stat(resolvedPath); err == nil {
-			fmt.Printf("在当前目录找到图片文件: %s\n", resolvedPath)
-			return resolvedPath, nil
-		}
-	}
-
-	// 5. 尝试常见的图片目录
-	commonDirs := []string{"images", "img", "assets", "pics", "pictures"}
-	for _, dir := range commonDirs {
-		// 相对于模板目录
-		resolvedPath = filepath.Join(templateDir, dir, filepath.Base(imgPath))
-		if _, err := os.Stat(resolvedPath); err == nil {
-			fmt.Printf("在 %s 目录找到图片文件: %s\n", dir, resolvedPath)
-			return resolvedPath, nil
-		}
-
-		// 相对于当前工作目录
-		resolvedPath = filepath.Join(currentDir, dir, filepath.Base(imgPath))
-		if _, err := os.Stat(resolvedPath); err == nil {
-			fmt.Printf("在当前目录的 %s 目录找到图片文件: %s\n", dir, resolvedPath)
-			return resolvedPath, nil
-		}
-	}
-
-	// 6. 尝试处理Windows路径分隔符
-	if strings.Contains(imgPath, "\\") {
-		normalizedPath := strings.ReplaceAll(imgPath, "\\", "/")
-		resolvedPath = filepath.Join(templateDir, normalizedPath)
-		if _, err := os.Stat(resolvedPath); err == nil {
-			fmt.Printf("找到图片文件 (Windows路径): %s\n", resolvedPath)
-			return resolvedPath, nil
-		}
+	resolved, err := (*ImageResolver)(nil).Resolve(imgPath, templatePath)
+	if err != nil {
+		return "", err
 	}
-
-	return "", fmt.Errorf("无法找到图片文件: %s", imgPath)
+	return resolved.Path, nil
 }
 
 // UpdateImagePaths 更新Markdown内容中的图片路径
@@ -275,143 +145,354 @@ func UpdateImagePaths(content string, mdPath string) string {
 	// 获取Markdown文件名（不含扩展名）
 	mdName := strings.TrimSuffix(filepath.Base(mdPath), filepath.Ext(mdPath))
 
-	// 支持的图片格式
-	imagePatterns := []string{
-		`\.png`,  // PNG格式
-		`\.jpg`,  // JPG格式
-		`\.jpeg`, // JPEG格式
-		`\.gif`,  // GIF格式
-		`\.bmp`,  // BMP格式
-		`\.webp`, // WebP格式
-		`\.svg`,  // SVG格式
-		`\.ico`,  // ICO格式
-		`\.tiff`, // TIFF格式
-		`\.tif`,  // TIF格式
-	}
+	return replaceImageRefs(content, func(path string) (string, bool) {
+		// 远程图片不属于本地资源，保持原样
+		if IsRemoteImage(path) {
+			return "", false
+		}
+		return "./" + mdName + ".assets/" + imageBaseName(path), true
+	})
+}
+
+// RewriteImagePaths 按映射表替换Markdown内容中的图片路径，映射表中不存在的路径保持不变
+func RewriteImagePaths(content string, mapping map[string]string) string {
+	return replaceImageRefs(content, func(path string) (string, bool) {
+		newPath, ok := mapping[path]
+		return newPath, ok
+	})
+}
 
-	updatedContent := content
+// replaceImageRefs 遍历Markdown和HTML格式的图片引用，由replace决定每个路径的新值
+func replaceImageRefs(content string, replace func(path string) (string, bool)) string {
+	exts := strings.Join(imageExtensions, "|")
 
 	// 1. 更新Markdown格式的图片路径
-	mdPattern := `!\[.*?\]\(([^\\)]+?(` + strings.Join(imagePatterns, "|") + `)(\\?[^\\)]*)?)\)`
-	mdRe := regexp.MustCompile(mdPattern)
-
-	updatedContent = mdRe.ReplaceAllStringFunc(updatedContent, func(match string) string {
-		// 提取图片路径
+	mdRe := regexp.MustCompile(`(?s)(!\[.*?\]\()(.+?\.(?:` + exts + `)(?:\?[^)]*)?)(\))`)
+	updatedContent := mdRe.ReplaceAllStringFunc(content, func(match string) string {
 		parts := mdRe.FindStringSubmatch(match)
 		if len(parts) < 4 {
 			return match
 		}
 
-		// 构建新的图片路径
-		oldPath := parts[2] + parts[3]
-		newPath := "./" + mdName + ".assets/" + filepath.Base(oldPath)
-
-		// 返回更新后的图片标记
-		return parts[1] + newPath + parts[4]
+		newPath, ok := replace(unescapeMarkdownPath(strings.TrimSpace(parts[2])))
+		if !ok {
+			return match
+		}
+		return parts[1] + newPath + parts[3]
 	})
 
 	// 2. 更新HTML格式的图片路径
 	// 更宽松的正则表达式，可以匹配各种格式的HTML图片标签
-	htmlPattern := `(<img\s+[^>]*?src=)["']([^"']+?\.(?:` + strings.Join(imagePatterns, "|") + `)(?:[^"'>]*)?)(["'][^>]*?>)`
-	htmlRe := regexp.MustCompile(htmlPattern)
-
+	htmlRe := regexp.MustCompile(`(<img\s+[^>]*?src=)(["'])([^"']+?\.(?:` + exts + `)(?:[^"'>]*)?)(["'][^>]*?>)`)
 	updatedContent = htmlRe.ReplaceAllStringFunc(updatedContent, func(match string) string {
-		// 提取图片路径
 		parts := htmlRe.FindStringSubmatch(match)
-		if len(parts) < 4 {
+		if len(parts) < 5 {
 			return match
 		}
 
-		// 处理路径中可能存在的错误格式
-		path := parts[2]
-		for _, ext := range imagePatterns {
-			// 移除可能的双后缀
-			doubleExt := ext + ext
-			if strings.Contains(path, doubleExt) {
-				path = strings.Replace(path, doubleExt, ext, 1)
+		// 处理路径中可能存在的错误格式（与ExtractImages保持一致）
+		path := strings.TrimSpace(parts[3])
+		for _, ext := range imageExtensions {
+			doubleExt := "." + ext + "." + ext
+			if strings.HasSuffix(path, doubleExt) {
+				path = path[:len(path)-len("."+ext)]
 			}
 		}
 
-		// 构建新的图片路径
-		newPath := "./" + mdName + ".assets/" + filepath.Base(path)
-
-		// 返回更新后的图片标记
-		return parts[1] + "\"" + newPath + "\"" + parts[3]
+		newPath, ok := replace(path)
+		if !ok {
+			return match
+		}
+		return parts[1] + parts[2] + newPath + parts[4]
 	})
 
 	return updatedContent
 }
 
+// imageBaseName 获取图片路径中的文件名（去除URL参数，兼容Windows分隔符）
+func imageBaseName(imgPath string) string {
+	return path.Base(normalizeImagePath(imgPath))
+}
+
+// ImageOptions 图片处理选项
+type ImageOptions struct {
+	RemotePolicy RemoteImagePolicy // 远程图片处理策略
+	Fetcher      ImageFetcher      // 远程图片下载器
+	Cache        *RemoteImageCache // 远程图片下载缓存，为nil时不缓存
+
+	EmbedImages   bool  // 是否将图片以data URI内嵌到Markdown中
+	EmbedMaxBytes int64 // 内嵌图片大小上限，超过时仍复制到.assets目录，0表示不限制
+
+	Optimize *OptimizeOptions      // 图片优化选项，为nil时不处理
+	Rules    *validator.ImageRules // 图片检查规则，为nil时不检查
+	Layout   *AssetLayout          // 图片输出布局，为nil时使用<文件名>.assets
+	Resolver *ImageResolver        // 本地图片路径解析器，为nil时使用兼容策略
+
+	KeepStale bool           // 保留图片目录中不再引用的旧图片
+	CopyCache ImageCopyCache // 图片复制缓存，为nil时每次都重新处理
+
+	Output vfs.WriteFS // 图片写入的文件系统，为nil时使用本地文件系统；读取使用Resolver.FS
+}
+
+// output 返回图片写入的文件系统
+func (o *ImageOptions) output() vfs.WriteFS {
+	if o.Output == nil {
+		return vfs.OSFS{}
+	}
+	return o.Output
+}
+
+// ImageCopyCache 图片复制缓存，命中时跳过优化和写入
+type ImageCopyCache interface {
+	// LookupImage 查找相同输入处理过的图片，dir中的目标文件完好时返回其文件名
+	LookupImage(key, dir string) (string, bool)
+	// StoreImage 记录写入dir的图片
+	StoreImage(key, filename string, data []byte)
+}
+
+// DefaultImageOptions 默认图片处理选项：保留远程图片地址
+func DefaultImageOptions() *ImageOptions {
+	return &ImageOptions{
+		RemotePolicy:  RemotePolicyKeep,
+		Fetcher:       NewHTTPFetcher(DefaultRemoteTimeout, DefaultRemoteMaxBytes),
+		EmbedMaxBytes: DefaultEmbedMaxBytes,
+	}
+}
+
 // CopyImagesFromTemplate 从模板文件复制图片到新目录并更新Markdown内容
 func CopyImagesFromTemplate(templatePath, outputPath string, imagePaths []string, content string) (string, error) {
-	fmt.Printf("开始处理图片复制...\n")
-	fmt.Printf("模板文件路径: %s\n", templatePath)
-	fmt.Printf("输出文件路径: %s\n", outputPath)
-	fmt.Printf("图片路径数量: %d\n", len(imagePaths))
+	return CopyImagesFromTemplateWithOptions(context.Background(), templatePath, outputPath, imagePaths, content, DefaultImageOptions())
+}
+
+// CopyImagesFromTemplateWithOptions 按指定选项复制图片到新目录并更新Markdown内容
+//
+// 每读取或写入一张图片前检查ctx，取消时返回ctx的错误；已写入的图片由调用方通过opts.Output清理
+func CopyImagesFromTemplateWithOptions(ctx context.Context, templatePath, outputPath string, imagePaths []string, content string, opts *ImageOptions) (string, error) {
+	if opts == nil {
+		opts = DefaultImageOptions()
+	}
+
+	fmt.Print(i18n.Msg(constants.MsgCopyImagesStart))
+	fmt.Print(i18n.Sprintf(constants.MsgTemplatePath, templatePath))
+	fmt.Print(i18n.Sprintf(constants.MsgOutputPath, outputPath))
+	fmt.Print(i18n.Sprintf(constants.MsgImageCount, len(imagePaths)))
+
+	// 在写入任何文件之前检查远程图片策略
+	if opts.RemotePolicy == RemotePolicyReject {
+		for _, imgPath := range imagePaths {
+			if IsRemoteImage(imgPath) {
+				return content, fmt.Errorf("模板包含远程图片且策略为reject: %s", imgPath)
+			}
+		}
+	}
 
 	// 获取输出文件名（不含扩展名）
 	outputName := strings.TrimSuffix(filepath.Base(outputPath), filepath.Ext(outputPath))
-	fmt.Printf("输出文件名: %s\n", outputName)
+	fmt.Print(i18n.Sprintf(constants.MsgOutputName, outputName))
 
-	// 创建图片目录
-	imageDir := filepath.Join(filepath.Dir(outputPath), outputName+".assets")
-	fmt.Printf("图片目录: %s\n", imageDir)
-	err := EnsureDir(imageDir)
+	// 图片目录（内嵌模式下可能不需要，首次写入时再创建）
+	imageDir, err := opts.Layout.AssetDir(outputPath)
 	if err != nil {
-		return content, fmt.Errorf("创建图片目录失败: %v", err)
+		return content, err
 	}
+	fmt.Print(i18n.Sprintf(constants.MsgImageDir, imageDir))
+	imageDirReady := false
 
-	// 复制每个图片
+	// 1. 读取所有图片（远程图片按策略下载）
+	var images []loadedImage
+	var resolved []ResolvedImage
 	for i, imgPath := range imagePaths {
-		fmt.Printf("\n处理图片 %d/%d: %s\n", i+1, len(imagePaths), imgPath)
-
-		// 解析图片路径
-		absImgPath, err := resolveImagePath(imgPath, templatePath)
-		if err != nil {
-			fmt.Printf("错误: 无法解析图片路径: %v\n", err)
-			return content, fmt.Errorf("解析图片路径失败 %s: %v", imgPath, err)
+		if err := ctx.Err(); err != nil {
+			return content, err
 		}
+		fmt.Print(i18n.Sprintf(constants.MsgImageProgress, i+1, len(imagePaths), imgPath))
 
-		// 处理长路径
-		if len(absImgPath) > 260 {
-			if !strings.HasPrefix(absImgPath, `\\?\`) {
-				absImgPath = `\\?\` + absImgPath
+		if IsRemoteImage(imgPath) {
+			if opts.RemotePolicy != RemotePolicyDownload {
+				fmt.Print(i18n.Sprintf(constants.MsgRemoteKept, imgPath))
+				continue
 			}
+
+			imgContent, err := fetchRemoteImage(ctx, imgPath, opts)
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return content, ctxErr
+			}
+			if err != nil {
+				fmt.Print(i18n.Sprintf(constants.MsgRemoteFetchFailed, err))
+				return content, fmt.Errorf("下载远程图片失败 %s: %v", imgPath, err)
+			}
+			images = append(images, loadedImage{path: imgPath, filename: remoteImageFilename(imgPath, imgContent), data: imgContent})
+		} else {
+			imgContent, resolvedImage, err := readLocalImage(imgPath, templatePath, opts.Resolver)
+			if err != nil {
+				return content, err
+			}
+			resolved = append(resolved, resolvedImage)
+			images = append(images, loadedImage{path: imgPath, filename: imageBaseName(imgPath), data: imgContent})
 		}
+		fmt.Print(i18n.Sprintf(constants.MsgImageRead, len(images[len(images)-1].data)))
+	}
 
-		// 检查源文件是否存在
-		if _, err := os.Stat(absImgPath); os.IsNotExist(err) {
-			fmt.Printf("错误: 源图片文件不存在: %s\n", absImgPath)
-			return content, fmt.Errorf("读取图片失败 %s: 文件不存在", imgPath)
+	PrintResolveReport(resolved)
+
+	// 2. 在写入任何文件之前检查图片内容
+	if err := checkImages(images, opts.Rules); err != nil {
+		return content, err
+	}
+
+	// 原始路径 -> 新路径
+	newPaths := make(map[string]string)
+	var written []string
+
+	// 3. 优化、内嵌或复制每个图片
+	for _, img := range images {
+		if err := ctx.Err(); err != nil {
+			return content, err
+		}
+		// 源图片和处理选项都未变化且目标文件完好时跳过处理
+		var cacheKey, filename string
+		if opts.CopyCache != nil && !opts.EmbedImages {
+			cacheKey = imageCacheKey(img, opts, imageDir)
+			if cached, ok := opts.CopyCache.LookupImage(cacheKey, imageDir); ok {
+				fmt.Print(i18n.Sprintf(constants.MsgImageUnchanged, img.path))
+				filename = cached
+			}
 		}
 
-		// 读取源图片
-		imgContent, err := os.ReadFile(absImgPath)
-		if err != nil {
-			fmt.Printf("错误: 读取图片文件失败: %v\n", err)
-			return content, fmt.Errorf("读取图片失败 %s: %v", imgPath, err)
+		if filename == "" {
+			// 优化图片（可能改变扩展名）
+			var imgContent []byte
+			filename, imgContent, err = OptimizeImage(img.filename, img.data, opts.Optimize)
+			if err != nil {
+				return content, err
+			}
+
+			// 内嵌为data URI
+			if shouldEmbed(opts, len(imgContent)) {
+				newPaths[img.path] = EncodeDataURI(filename, imgContent)
+				fmt.Print(i18n.Sprintf(constants.MsgImageEmbedded, img.path))
+				continue
+			}
+
+			// 创建图片目录
+			if !imageDirReady {
+				if err := vfs.MkdirAll(opts.output(), imageDir); err != nil {
+					return content, fmt.Errorf("创建图片目录失败: %v", err)
+				}
+				imageDirReady = true
+			}
+
+			// 写入新图片
+			newImgPath := filepath.Join(imageDir, filename)
+			fmt.Print(i18n.Sprintf(constants.MsgImageNewPath, newImgPath))
+			err = vfs.WriteFile(opts.output(), newImgPath, imgContent)
+			if err != nil {
+				fmt.Print(i18n.Sprintf(constants.MsgImageWriteFailed, err))
+				return content, fmt.Errorf("写入图片失败 %s: %v", newImgPath, err)
+			}
+			fmt.Print(i18n.Sprintf(constants.MsgImageWritten, newImgPath))
+
+			if cacheKey != "" {
+				opts.CopyCache.StoreImage(cacheKey, filename, imgContent)
+			}
 		}
-		fmt.Printf("成功读取图片，大小: %d 字节\n", len(imgContent))
+		written = append(written, filename)
 
-		// 写入新图片
-		newImgPath := filepath.Join(imageDir, filepath.Base(imgPath))
-		fmt.Printf("新图片路径: %s\n", newImgPath)
-		err = WriteFile(newImgPath, imgContent)
+		newPaths[img.path], err = opts.Layout.Reference(outputPath, filepath.Join(imageDir, filename))
 		if err != nil {
-			fmt.Printf("错误: 写入图片文件失败: %v\n", err)
-			return content, fmt.Errorf("写入图片失败 %s: %v", newImgPath, err)
+			return content, err
 		}
-		fmt.Printf("成功写入图片: %s\n", newImgPath)
+	}
+
+	// 4. 记录本次写入的图片并清理不再引用的旧图片
+	removed, err := UpdateAssetManifest(opts.output(), imageDir, outputPath, written, opts.KeepStale)
+	if err != nil {
+		return content, err
+	}
+	for _, f := range removed {
+		fmt.Print(i18n.Sprintf(constants.MsgStaleImageRemoved, filepath.Join(imageDir, f)))
 	}
 
 	// 更新Markdown内容中的图片路径
-	fmt.Printf("\n更新Markdown内容中的图片路径...\n")
-	updatedContent := UpdateImagePaths(content, outputPath)
-	fmt.Printf("图片路径更新完成\n")
+	fmt.Print(i18n.Msg(constants.MsgRewriteImagePaths))
+	updatedContent := RewriteImagePaths(content, newPaths)
+	fmt.Print(i18n.Msg(constants.MsgImagePathsRewritten))
 
 	return updatedContent, nil
 }
 
+// loadedImage 已读取的图片
+type loadedImage struct {
+	path     string // 模板中的原始路径
+	filename string // 写入.assets目录时使用的文件名
+	data     []byte
+}
+
+// imageCacheKey 图片复制缓存的键：源图片内容、优化选项和目标目录
+func imageCacheKey(img loadedImage, opts *ImageOptions, imageDir string) string {
+	h := sha256.New()
+	h.Write(img.data)
+	fmt.Fprintf(h, "\x00%s\x00%s\x00", img.filename, imageDir)
+	if opts.Optimize != nil {
+		fmt.Fprintf(h, "%+v", *opts.Optimize)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// checkImages 按规则检查所有图片并输出报告，存在错误级别的问题时返回错误
+func checkImages(images []loadedImage, rules *validator.ImageRules) error {
+	if rules == nil {
+		return nil
+	}
+
+	v := validator.NewValidator()
+	var issues []validator.ImageIssue
+	errorCount := 0
+	for _, img := range images {
+		for _, issue := range v.ValidateImage(img.path, img.data, rules) {
+			issues = append(issues, issue)
+			if issue.Level == validator.LevelError {
+				errorCount++
+			}
+		}
+	}
+
+	if len(issues) == 0 {
+		return nil
+	}
+
+	fmt.Print(i18n.Sprintf(constants.MsgImageIssues, len(issues)))
+	for _, issue := range issues {
+		fmt.Printf("  - %s\n", issue)
+	}
+	if errorCount > 0 {
+		return fmt.Errorf("图片检查未通过: %d 个错误", errorCount)
+	}
+	return nil
+}
+
+// readLocalImage 解析并读取模板引用的本地图片
+func readLocalImage(imgPath, templatePath string, resolver *ImageResolver) ([]byte, ResolvedImage, error) {
+	// 解析图片路径
+	resolved, err := resolver.Resolve(imgPath, templatePath)
+	if err != nil {
+		fmt.Print(i18n.Sprintf(constants.MsgResolveImageFailed, err))
+		return nil, resolved, fmt.Errorf("解析图片路径失败 %s: %v", imgPath, err)
+	}
+
+	// 读取源图片
+	imgContent, err := vfs.ReadFile(resolver.fs(), resolved.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		fmt.Print(i18n.Sprintf(constants.MsgImageNotExist, resolved.Path))
+		return nil, resolved, fmt.Errorf("读取图片失败 %s: 文件不存在", imgPath)
+	}
+	if err != nil {
+		fmt.Print(i18n.Sprintf(constants.MsgImageReadFailed, err))
+		return nil, resolved, fmt.Errorf("读取图片失败 %s: %v", imgPath, err)
+	}
+	return imgContent, resolved, nil
+}
+
 // CopyImages 复制图片到新目录并更新Markdown内容
 func CopyImages(mdPath string, imagePaths []string, content string) (string, error) {
 	// 获取Markdown文件名（不含扩展名）
@@ -432,15 +513,8 @@ func CopyImages(mdPath string, imagePaths []string, content string) (string, err
 			absImgPath = filepath.Join(filepath.Dir(mdPath), imgPath)
 		}
 
-		// 处理长路径
-		if len(absImgPath) > 260 {
-			if !strings.HasPrefix(absImgPath, `\\?\`) {
-				absImgPath = `\\?\` + absImgPath
-			}
-		}
-
-		// 读取源图片
-		imgContent, err := os.ReadFile(absImgPath)
+		// 读取源图片，长路径由vfs.OSFS处理
+		imgContent, err := vfs.ReadFile(vfs.OSFS{}, absImgPath)
 		if err != nil {
 			return content, fmt.Errorf("读取图片失败 %s: %v", imgPath, err)
 		}
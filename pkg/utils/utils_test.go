@@ -1,9 +1,13 @@
 package utils
 
 import (
+	"context"
 	"fmt"
+	"md-manual-tool/pkg/validator"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 )
 
@@ -105,6 +109,46 @@ func TestCopyImagesFromTemplate(t *testing.T) {
 	}
 }
 
+func TestEnsureDir(t *testing.T) {
+	tempDir := t.TempDir()
+	tests := []struct {
+		name   string
+		ensure func(string) error
+		path   string
+		dir    string // 应创建的目录
+		posix  bool   // 只在Linux和macOS上合法
+	}{
+		{"带点的目录", EnsureDir, "output/v3.2", "output/v3.2", false},
+		{"隐藏目录", EnsureDir, ".docs/手册", ".docs/手册", false},
+		{"不带扩展名的文件", EnsureFileDir, "out/README", "out", false},
+		{"带扩展名的文件", EnsureFileDir, "out/v3.2/手册_3.3.0.md", "out/v3.2", false},
+		{"问号和星号", EnsureDir, "out/what?*", "out/what?*", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.posix && runtime.GOOS == "windows" {
+				t.Skip("Windows不允许该路径")
+			}
+			if err := tt.ensure(filepath.Join(tempDir, filepath.FromSlash(tt.path))); err != nil {
+				t.Fatalf("创建目录失败: %v", err)
+			}
+			info, err := os.Stat(filepath.Join(tempDir, filepath.FromSlash(tt.dir)))
+			if err != nil || !info.IsDir() {
+				t.Errorf("目录 %s 未创建: %v", tt.dir, err)
+			}
+		})
+	}
+
+	// 文件路径本身不应被创建为目录
+	if _, err := os.Stat(filepath.Join(tempDir, "out", "README")); !os.IsNotExist(err) {
+		t.Errorf("EnsureFileDir不应创建文件路径本身: %v", err)
+	}
+	if err := EnsureDir(""); err == nil {
+		t.Error("空路径应返回错误")
+	}
+}
+
 func TestResolveImagePath(t *testing.T) {
 	// 创建临时目录
 	tempDir, err := os.MkdirTemp("", "test_resolve")
@@ -248,3 +292,33 @@ func containsSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+func TestCopyImagesFromTemplateCheckBeforeWrite(t *testing.T) {
+	tempDir := t.TempDir()
+	imagesDir := filepath.Join(tempDir, "images")
+	if err := os.MkdirAll(imagesDir, 0755); err != nil {
+		t.Fatalf("创建图片目录失败: %v", err)
+	}
+	// 第一张图片完好，逐张检查并写入时会在发现第二张的问题之前写入第一张
+	if err := os.WriteFile(filepath.Join(imagesDir, "good.png"), encodeTestPNG(t, 4, 4), 0644); err != nil {
+		t.Fatalf("创建测试图片失败: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(imagesDir, "empty.png"), nil, 0644); err != nil {
+		t.Fatalf("创建测试图片失败: %v", err)
+	}
+
+	content := "![a](images/good.png)\n![b](images/empty.png)\n"
+	opts := DefaultImageOptions()
+	opts.Rules = &validator.ImageRules{Level: validator.LevelError}
+
+	_, err := CopyImagesFromTemplateWithOptions(context.Background(), filepath.Join(tempDir, "t.md"), filepath.Join(tempDir, "o.md"), ExtractImages(content), content, opts)
+	if err == nil {
+		t.Fatal("存在错误级别的问题时应返回错误")
+	}
+	if !strings.Contains(err.Error(), "1 个错误") {
+		t.Errorf("只有empty.png应未通过检查: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "o.assets")); !os.IsNotExist(err) {
+		t.Error("检查未通过时不应写入任何图片")
+	}
+}
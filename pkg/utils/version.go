@@ -1,8 +1,10 @@
 package utils
 
 import (
+	"fmt"
 	"md-manual-tool/pkg/constants"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -61,3 +63,33 @@ func (v *VersionUtils) IsValidVersionFormat(version string) bool {
 	re := regexp.MustCompile(`^\d+\.\d+\.\d+$`)
 	return re.MatchString(version)
 }
+
+// Version 结构化的版本号，模板中 {{.versionInfo}} 输出完整版本号，{{.versionInfo.Major}} 等输出各部分
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// ParseVersion 解析 x.y.z 格式的版本号
+func ParseVersion(s string) (Version, error) {
+	var v Version
+	parts := strings.Split(strings.TrimSpace(s), ".")
+	if len(parts) != 3 {
+		return v, fmt.Errorf("版本号格式无效: %s", s)
+	}
+	numbers := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || strings.HasPrefix(part, "+") {
+			return v, fmt.Errorf("版本号格式无效: %s", s)
+		}
+		*numbers[i] = n
+	}
+	return v, nil
+}
+
+// String 返回 x.y.z 格式的版本号
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
@@ -0,0 +1,29 @@
+package utils
+
+import "testing"
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Version
+		valid    bool
+	}{
+		{"3.2.1", Version{3, 2, 1}, true},
+		{" 10.0.12 ", Version{10, 0, 12}, true},
+		{"3.2", Version{}, false},
+		{"3.2.x", Version{}, false},
+		{"3.-2.1", Version{}, false},
+		{"+3.2.1", Version{}, false},
+		{"", Version{}, false},
+	}
+
+	for _, tt := range tests {
+		v, err := ParseVersion(tt.input)
+		if (err == nil) != tt.valid || (tt.valid && v != tt.expected) {
+			t.Errorf("ParseVersion(%q) = %v, %v", tt.input, v, err)
+		}
+		if tt.valid && v.String() != tt.expected.String() {
+			t.Errorf("String() = %q", v.String())
+		}
+	}
+}
@@ -0,0 +1,764 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/draw"
+	"sort"
+)
+
+// 本文件实现一个精简的WebP无损（VP8L）编码器：
+// 使用减绿变换和按16x16分块选择的预测变换，对残差做后向引用（LZ77）压缩，
+// 并尝试不同大小的颜色缓存取最小的结果，足以有效压缩界面截图和渐变，且无需依赖cgo或第三方库。
+
+const (
+	vp8lSignature     = 0x2f
+	vp8lMaxDimension  = 1 << 14
+	vp8lNumLiterals   = 256
+	vp8lNumLengthCode = 24
+	vp8lNumDistCode   = 40
+	vp8lMaxCopyLength = 4096
+	vp8lMinCopyLength = 3
+	vp8lMaxCodeLength = 15
+	vp8lPredictor     = 0
+	vp8lSubtractGreen = 2
+	vp8lPredictorBits = 4 // 预测变换的分块大小为 1<<4 像素
+	vp8lNumPredictors = 14
+	vp8lMaxChainDepth = 32          // 哈希链的最大查找深度
+	vp8lMaxDistance   = 1<<20 - 120 // 后向引用的最大距离
+)
+
+// vp8lCacheBits 尝试的颜色缓存大小（位数），0表示不使用颜色缓存
+var vp8lCacheBits = []int{0, 4, 8, 10}
+
+// vp8lCodeLengthOrder 码长码的写入顺序
+var vp8lCodeLengthOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// vp8lSymbol 待编码的符号：字面像素、颜色缓存引用或后向引用
+type vp8lSymbol struct {
+	argb     uint32
+	length   int  // 大于0时表示后向引用
+	distCode int  // 距离码，见vp8lDistanceCode
+	cached   bool // 为true时表示颜色缓存引用，argb中为缓存索引
+}
+
+// EncodeWebPLossless 将图片编码为WebP无损格式
+func EncodeWebPLossless(img image.Image) ([]byte, error) {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width < 1 || height < 1 || width > vp8lMaxDimension || height > vp8lMaxDimension {
+		return nil, fmt.Errorf("WebP不支持的图片尺寸: %dx%d", width, height)
+	}
+
+	nrgba := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(nrgba, nrgba.Bounds(), img, bounds.Min, draw.Src)
+
+	// 转换为ARGB并应用减绿变换
+	pixels := make([]uint32, width*height)
+	hasAlpha := false
+	for i := range pixels {
+		r, g, b, a := nrgba.Pix[i*4], nrgba.Pix[i*4+1], nrgba.Pix[i*4+2], nrgba.Pix[i*4+3]
+		if a != 0xff {
+			hasAlpha = true
+		}
+		pixels[i] = uint32(a)<<24 | uint32(r-g)<<16 | uint32(g)<<8 | uint32(b-g)
+	}
+
+	// 预测变换：每块选择残差最小的预测方式
+	modes, residuals := vp8lPredict(pixels, width, height)
+	modeWidth := vp8lSubSampleSize(width, vp8lPredictorBits)
+
+	var best []byte
+	for _, cacheBits := range vp8lCacheBits {
+		w := &vp8lBitWriter{}
+		w.writeBits(uint32(width-1), 14)
+		w.writeBits(uint32(height-1), 14)
+		if hasAlpha {
+			w.writeBits(1, 1)
+		} else {
+			w.writeBits(0, 1)
+		}
+		w.writeBits(0, 3) // 版本号
+
+		// 变换按应用的顺序写入：先减绿，再预测
+		w.writeBits(1, 1)
+		w.writeBits(vp8lSubtractGreen, 2)
+		w.writeBits(1, 1)
+		w.writeBits(vp8lPredictor, 2)
+		w.writeBits(vp8lPredictorBits-2, 3)
+		vp8lWriteImage(w, modes, modeWidth, 0, false)
+		w.writeBits(0, 1)
+
+		vp8lWriteImage(w, residuals, width, cacheBits, true)
+
+		payload := append([]byte{vp8lSignature}, w.bytes()...)
+		if best == nil || len(payload) < len(best) {
+			best = payload
+		}
+	}
+	return vp8lWrapRIFF(best), nil
+}
+
+// vp8lWriteImage 写入熵编码的图像数据；主图像（main）需要写入是否使用元前缀码，变换数据等子图像不写
+func vp8lWriteImage(w *vp8lBitWriter, pixels []uint32, width, cacheBits int, main bool) {
+	symbols := vp8lColorCache(vp8lBackwardRefs(pixels, width), pixels, cacheBits)
+
+	// 统计各字母表的符号频率
+	green := make([]int, vp8lNumLiterals+vp8lNumLengthCode+vp8lCacheSize(cacheBits))
+	red := make([]int, vp8lNumLiterals)
+	blue := make([]int, vp8lNumLiterals)
+	alpha := make([]int, vp8lNumLiterals)
+	dist := make([]int, vp8lNumDistCode)
+	for _, s := range symbols {
+		switch {
+		case s.cached:
+			green[vp8lNumLiterals+vp8lNumLengthCode+int(s.argb)]++
+		case s.length > 0:
+			code, _, _ := vp8lPrefixEncode(s.length)
+			green[vp8lNumLiterals+code]++
+			code, _, _ = vp8lPrefixEncode(s.distCode)
+			dist[code]++
+		default:
+			alpha[s.argb>>24]++
+			red[(s.argb>>16)&0xff]++
+			green[(s.argb>>8)&0xff]++
+			blue[s.argb&0xff]++
+		}
+	}
+
+	if cacheBits > 0 {
+		w.writeBits(1, 1)
+		w.writeBits(uint32(cacheBits), 4)
+	} else {
+		w.writeBits(0, 1) // 不使用颜色缓存
+	}
+	if main {
+		w.writeBits(0, 1) // 不使用元前缀码
+	}
+
+	codes := make([]vp8lHuffmanCode, 5)
+	for i, histogram := range [][]int{green, red, blue, alpha, dist} {
+		codes[i] = vp8lBuildHuffmanCode(histogram, vp8lMaxCodeLength)
+		codes[i].write(w)
+	}
+
+	for _, s := range symbols {
+		switch {
+		case s.cached:
+			codes[0].writeSymbol(w, vp8lNumLiterals+vp8lNumLengthCode+int(s.argb))
+		case s.length > 0:
+			code, extraBits, extra := vp8lPrefixEncode(s.length)
+			codes[0].writeSymbol(w, vp8lNumLiterals+code)
+			w.writeBits(extra, extraBits)
+			code, extraBits, extra = vp8lPrefixEncode(s.distCode)
+			codes[4].writeSymbol(w, code)
+			w.writeBits(extra, extraBits)
+		default:
+			codes[0].writeSymbol(w, int((s.argb>>8)&0xff))
+			codes[1].writeSymbol(w, int((s.argb>>16)&0xff))
+			codes[2].writeSymbol(w, int(s.argb&0xff))
+			codes[3].writeSymbol(w, int(s.argb>>24))
+		}
+	}
+}
+
+// vp8lBackwardRefs 贪心地查找重复的像素序列，生成符号序列
+//
+// 优先使用距离码较短的左侧和上方像素，其余距离通过以3个像素为键的哈希链查找
+func vp8lBackwardRefs(pixels []uint32, width int) []vp8lSymbol {
+	symbols := make([]vp8lSymbol, 0, len(pixels)/4)
+	head := make(map[[vp8lMinCopyLength]uint32]int)
+	prev := make([]int, len(pixels))
+	insert := func(i int) {
+		if i+vp8lMinCopyLength > len(pixels) {
+			return
+		}
+		var key [vp8lMinCopyLength]uint32
+		copy(key[:], pixels[i:])
+		if p, ok := head[key]; ok {
+			prev[i] = p
+		} else {
+			prev[i] = -1
+		}
+		head[key] = i
+	}
+
+	for i := 0; i < len(pixels); {
+		bestLength, bestDist := 0, 0
+		if i >= 1 {
+			if n := vp8lMatchLength(pixels, i, 1); n > bestLength {
+				bestLength, bestDist = n, 1
+			}
+		}
+		if i >= width {
+			if n := vp8lMatchLength(pixels, i, width); n > bestLength {
+				bestLength, bestDist = n, width
+			}
+		}
+		if bestLength < vp8lMaxCopyLength && i+vp8lMinCopyLength <= len(pixels) {
+			var key [vp8lMinCopyLength]uint32
+			copy(key[:], pixels[i:])
+			p, ok := head[key]
+			for depth := 0; ok && p >= 0 && depth < vp8lMaxChainDepth && i-p <= vp8lMaxDistance; depth++ {
+				if n := vp8lMatchLength(pixels, i, i-p); n > bestLength+4 {
+					bestLength, bestDist = n, i-p
+				}
+				p = prev[p]
+			}
+		}
+
+		if bestLength >= vp8lMinCopyLength {
+			symbols = append(symbols, vp8lSymbol{length: bestLength, distCode: vp8lDistanceCode(bestDist, width)})
+			for j := i; j < i+bestLength; j++ {
+				insert(j)
+			}
+			i += bestLength
+			continue
+		}
+		symbols = append(symbols, vp8lSymbol{argb: pixels[i]})
+		insert(i)
+		i++
+	}
+	return symbols
+}
+
+// vp8lDistanceCode 将像素距离转换为距离码：上方像素为1，左侧像素为2，其余距离加上120
+func vp8lDistanceCode(dist, width int) int {
+	switch dist {
+	case width:
+		return 1
+	case 1:
+		return 2
+	}
+	return dist + 120
+}
+
+// vp8lColorCache 将颜色缓存中已有的字面像素改为缓存引用；解码器对每个输出像素（含后向引用复制的像素）都更新缓存
+func vp8lColorCache(symbols []vp8lSymbol, pixels []uint32, cacheBits int) []vp8lSymbol {
+	if cacheBits == 0 {
+		return symbols
+	}
+	cache := make([]uint32, vp8lCacheSize(cacheBits))
+	valid := make([]bool, len(cache))
+	insert := func(argb uint32) {
+		key := vp8lCacheKey(argb, cacheBits)
+		cache[key], valid[key] = argb, true
+	}
+
+	pos := 0
+	result := make([]vp8lSymbol, len(symbols))
+	for i, s := range symbols {
+		result[i] = s
+		if s.length > 0 {
+			for _, argb := range pixels[pos : pos+s.length] {
+				insert(argb)
+			}
+			pos += s.length
+			continue
+		}
+		if key := vp8lCacheKey(s.argb, cacheBits); valid[key] && cache[key] == s.argb {
+			result[i] = vp8lSymbol{argb: uint32(key), cached: true}
+		}
+		insert(s.argb)
+		pos++
+	}
+	return result
+}
+
+// vp8lCacheSize 颜色缓存的大小
+func vp8lCacheSize(cacheBits int) int {
+	if cacheBits == 0 {
+		return 0
+	}
+	return 1 << uint(cacheBits)
+}
+
+// vp8lCacheKey 颜色在缓存中的位置
+func vp8lCacheKey(argb uint32, cacheBits int) int {
+	return int((0x1e35a7bd * argb) >> uint(32-cacheBits))
+}
+
+// vp8lSubSampleSize 按分块大小计算变换数据的宽度或高度
+func vp8lSubSampleSize(size, bits int) int {
+	return (size + 1<<uint(bits) - 1) >> uint(bits)
+}
+
+// vp8lPredict 为每个分块选择残差绝对值之和最小的预测方式，返回预测方式图像（存于绿色通道）和残差
+func vp8lPredict(pixels []uint32, width, height int) ([]uint32, []uint32) {
+	blockSize := 1 << vp8lPredictorBits
+	modeWidth := vp8lSubSampleSize(width, vp8lPredictorBits)
+	modeHeight := vp8lSubSampleSize(height, vp8lPredictorBits)
+	modes := make([]uint32, modeWidth*modeHeight)
+	residuals := make([]uint32, len(pixels))
+
+	for by := 0; by < modeHeight; by++ {
+		for bx := 0; bx < modeWidth; bx++ {
+			bestMode, bestCost := 0, -1
+			for mode := 0; mode < vp8lNumPredictors; mode++ {
+				cost := 0
+				for y := by * blockSize; y < minInt((by+1)*blockSize, height); y++ {
+					for x := bx * blockSize; x < minInt((bx+1)*blockSize, width); x++ {
+						cost += vp8lResidualCost(vp8lSub(pixels[y*width+x], vp8lPredictPixel(pixels, width, x, y, mode)))
+					}
+				}
+				if bestCost < 0 || cost < bestCost {
+					bestMode, bestCost = mode, cost
+				}
+			}
+			modes[by*modeWidth+bx] = 0xff000000 | uint32(bestMode)<<8
+			for y := by * blockSize; y < minInt((by+1)*blockSize, height); y++ {
+				for x := bx * blockSize; x < minInt((bx+1)*blockSize, width); x++ {
+					residuals[y*width+x] = vp8lSub(pixels[y*width+x], vp8lPredictPixel(pixels, width, x, y, bestMode))
+				}
+			}
+		}
+	}
+	return modes, residuals
+}
+
+// vp8lPredictPixel 按预测方式计算(x, y)处像素的预测值；首行使用左侧像素，首列使用上方像素
+func vp8lPredictPixel(pixels []uint32, width, x, y, mode int) uint32 {
+	i := y*width + x
+	switch {
+	case x == 0 && y == 0:
+		return 0xff000000
+	case y == 0:
+		return pixels[i-1]
+	case x == 0:
+		return pixels[i-width]
+	}
+
+	// 最右列的右上方像素取当前行的第一个像素
+	left, top, topLeft, topRight := pixels[i-1], pixels[i-width], pixels[i-width-1], pixels[i-width+1]
+	switch mode {
+	case 0:
+		return 0xff000000
+	case 1:
+		return left
+	case 2:
+		return top
+	case 3:
+		return topRight
+	case 4:
+		return topLeft
+	case 5:
+		return vp8lAverage2(vp8lAverage2(left, topRight), top)
+	case 6:
+		return vp8lAverage2(left, topLeft)
+	case 7:
+		return vp8lAverage2(left, top)
+	case 8:
+		return vp8lAverage2(topLeft, top)
+	case 9:
+		return vp8lAverage2(top, topRight)
+	case 10:
+		return vp8lAverage2(vp8lAverage2(left, topLeft), vp8lAverage2(top, topRight))
+	case 11:
+		return vp8lSelect(left, top, topLeft)
+	case 12:
+		return vp8lChannels(func(c int) int { return vp8lChannel(left, c) + vp8lChannel(top, c) - vp8lChannel(topLeft, c) })
+	default:
+		avg := vp8lAverage2(left, top)
+		return vp8lChannels(func(c int) int {
+			a := vp8lChannel(avg, c)
+			return a + (a-vp8lChannel(topLeft, c))/2
+		})
+	}
+}
+
+// vp8lChannel 取像素的一个通道（0~3依次为蓝、绿、红、透明度）
+func vp8lChannel(argb uint32, c int) int {
+	return int(argb>>uint(8*c)) & 0xff
+}
+
+// vp8lChannels 逐通道计算并截断到0~255后组合为像素
+func vp8lChannels(f func(c int) int) uint32 {
+	var argb uint32
+	for c := 0; c < 4; c++ {
+		v := f(c)
+		if v < 0 {
+			v = 0
+		} else if v > 255 {
+			v = 255
+		}
+		argb |= uint32(v) << uint(8*c)
+	}
+	return argb
+}
+
+// vp8lAverage2 逐通道取平均值
+func vp8lAverage2(a, b uint32) uint32 {
+	return (((a ^ b) & 0xfefefefe) >> 1) + (a & b)
+}
+
+// vp8lSelect 选择左侧或上方像素中更接近 left+top-topLeft 的一个
+func vp8lSelect(left, top, topLeft uint32) uint32 {
+	distLeft, distTop := 0, 0
+	for c := 0; c < 4; c++ {
+		p := vp8lChannel(left, c) + vp8lChannel(top, c) - vp8lChannel(topLeft, c)
+		distLeft += vp8lAbs(p - vp8lChannel(left, c))
+		distTop += vp8lAbs(p - vp8lChannel(top, c))
+	}
+	if distLeft < distTop {
+		return left
+	}
+	return top
+}
+
+// vp8lSub 逐通道相减（模256）
+func vp8lSub(a, b uint32) uint32 {
+	var argb uint32
+	for c := 0; c < 4; c++ {
+		argb |= uint32(uint8(vp8lChannel(a, c)-vp8lChannel(b, c))) << uint(8*c)
+	}
+	return argb
+}
+
+// vp8lResidualCost 残差的代价：各通道按有符号值取绝对值之和
+func vp8lResidualCost(residual uint32) int {
+	cost := 0
+	for c := 0; c < 4; c++ {
+		cost += vp8lAbs(int(int8(vp8lChannel(residual, c))))
+	}
+	return cost
+}
+
+// vp8lAbs 绝对值
+func vp8lAbs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// vp8lMatchLength 计算从位置i开始与距离dist处像素相同的长度
+func vp8lMatchLength(pixels []uint32, i, dist int) int {
+	n := 0
+	for i+n < len(pixels) && n < vp8lMaxCopyLength && pixels[i+n] == pixels[i+n-dist] {
+		n++
+	}
+	return n
+}
+
+// vp8lPrefixEncode 将长度或距离值编码为前缀码和附加位
+func vp8lPrefixEncode(value int) (code int, extraBits uint, extra uint32) {
+	d := value - 1
+	if d < 4 {
+		return d, 0, 0
+	}
+	highest := 0
+	for (d >> uint(highest+1)) != 0 {
+		highest++
+	}
+	second := (d >> uint(highest-1)) & 1
+	extraBits = uint(highest - 1)
+	return 2*highest + second, extraBits, uint32(d) & (1<<extraBits - 1)
+}
+
+// vp8lWrapRIFF 将VP8L数据封装为RIFF/WebP文件
+func vp8lWrapRIFF(payload []byte) []byte {
+	chunkSize := len(payload)
+	padded := chunkSize + chunkSize&1
+
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(4+8+padded))
+	buf.WriteString("WEBPVP8L")
+	binary.Write(&buf, binary.LittleEndian, uint32(chunkSize))
+	buf.Write(payload)
+	if chunkSize&1 == 1 {
+		buf.WriteByte(0)
+	}
+	return buf.Bytes()
+}
+
+// vp8lBitWriter 低位优先的位写入器
+type vp8lBitWriter struct {
+	buf   []byte
+	acc   uint64
+	nbits uint
+}
+
+// writeBits 写入value的低n位
+func (w *vp8lBitWriter) writeBits(value uint32, n uint) {
+	if n == 0 {
+		return
+	}
+	w.acc |= uint64(value&(1<<n-1)) << w.nbits
+	w.nbits += n
+	for w.nbits >= 8 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc >>= 8
+		w.nbits -= 8
+	}
+}
+
+// bytes 返回补齐到字节边界的数据
+func (w *vp8lBitWriter) bytes() []byte {
+	if w.nbits > 0 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc, w.nbits = 0, 0
+	}
+	return w.buf
+}
+
+// vp8lHuffmanCode 规范Huffman编码
+type vp8lHuffmanCode struct {
+	lengths []int
+	codes   []uint32 // 已按位反转，可直接低位优先写入
+	simple  []int    // 非空时使用简单编码（1~2个小于256的符号）
+}
+
+// vp8lBuildHuffmanCode 根据频率构建长度受限的Huffman编码
+func vp8lBuildHuffmanCode(histogram []int, maxLength int) vp8lHuffmanCode {
+	var used []int
+	for symbol, count := range histogram {
+		if count > 0 {
+			used = append(used, symbol)
+		}
+	}
+
+	// 未使用的字母表：写入只含符号0的简单编码
+	if len(used) == 0 {
+		return vp8lHuffmanCode{simple: []int{0}}
+	}
+	if len(used) <= 2 && used[len(used)-1] < 256 {
+		code := vp8lHuffmanCode{simple: used, lengths: make([]int, len(histogram)), codes: make([]uint32, len(histogram))}
+		if len(used) == 2 {
+			code.lengths[used[0]], code.lengths[used[1]] = 1, 1
+			code.codes[used[1]] = 1
+		}
+		return code
+	}
+
+	lengths := vp8lHuffmanLengths(histogram, maxLength)
+	return vp8lHuffmanCode{lengths: lengths, codes: vp8lCanonicalCodes(lengths)}
+}
+
+// vp8lHuffmanLengths 计算码长，超过上限时压缩频率后重新计算
+func vp8lHuffmanLengths(histogram []int, maxLength int) []int {
+	freqs := make([]int, len(histogram))
+	copy(freqs, histogram)
+
+	// 至少保证两个符号，避免单符号编码的歧义
+	nonZero := 0
+	for _, f := range freqs {
+		if f > 0 {
+			nonZero++
+		}
+	}
+	if nonZero == 1 {
+		for i := range freqs {
+			if freqs[i] == 0 {
+				freqs[i] = 1
+				break
+			}
+		}
+	}
+
+	for {
+		lengths := vp8lHuffmanTreeLengths(freqs)
+		maxUsed := 0
+		for _, l := range lengths {
+			if l > maxUsed {
+				maxUsed = l
+			}
+		}
+		if maxUsed <= maxLength {
+			return lengths
+		}
+		for i, f := range freqs {
+			if f > 0 {
+				freqs[i] = (f + 1) / 2
+			}
+		}
+	}
+}
+
+// vp8lHuffmanTreeLengths 构建Huffman树并返回每个符号的码长
+func vp8lHuffmanTreeLengths(freqs []int) []int {
+	type node struct {
+		weight      int
+		symbol      int
+		left, right *node
+	}
+
+	var nodes []*node
+	for symbol, f := range freqs {
+		if f > 0 {
+			nodes = append(nodes, &node{weight: f, symbol: symbol})
+		}
+	}
+
+	for len(nodes) > 1 {
+		sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].weight < nodes[j].weight })
+		merged := &node{weight: nodes[0].weight + nodes[1].weight, symbol: -1, left: nodes[0], right: nodes[1]}
+		nodes = append([]*node{merged}, nodes[2:]...)
+	}
+
+	lengths := make([]int, len(freqs))
+	var walk func(n *node, depth int)
+	walk = func(n *node, depth int) {
+		if n.symbol >= 0 {
+			lengths[n.symbol] = depth
+			return
+		}
+		walk(n.left, depth+1)
+		walk(n.right, depth+1)
+	}
+	walk(nodes[0], 0)
+	return lengths
+}
+
+// vp8lCanonicalCodes 根据码长生成规范Huffman码（按位反转）
+func vp8lCanonicalCodes(lengths []int) []uint32 {
+	var blCount [vp8lMaxCodeLength + 1]int
+	for _, l := range lengths {
+		if l > 0 {
+			blCount[l]++
+		}
+	}
+
+	var nextCode [vp8lMaxCodeLength + 2]uint32
+	code := uint32(0)
+	for bits := 1; bits <= vp8lMaxCodeLength; bits++ {
+		code = (code + uint32(blCount[bits-1])) << 1
+		nextCode[bits] = code
+	}
+
+	codes := make([]uint32, len(lengths))
+	for symbol, l := range lengths {
+		if l == 0 {
+			continue
+		}
+		codes[symbol] = vp8lReverseBits(nextCode[l], l)
+		nextCode[l]++
+	}
+	return codes
+}
+
+// vp8lReverseBits 反转code的低n位
+func vp8lReverseBits(code uint32, n int) uint32 {
+	var reversed uint32
+	for i := 0; i < n; i++ {
+		reversed = reversed<<1 | (code>>uint(i))&1
+	}
+	return reversed
+}
+
+// writeSymbol 写入一个符号
+func (c *vp8lHuffmanCode) writeSymbol(w *vp8lBitWriter, symbol int) {
+	if len(c.simple) == 1 {
+		return // 单符号简单编码不占用位
+	}
+	w.writeBits(c.codes[symbol], uint(c.lengths[symbol]))
+}
+
+// write 写入Huffman编码的定义
+func (c *vp8lHuffmanCode) write(w *vp8lBitWriter) {
+	if len(c.simple) > 0 {
+		c.writeSimple(w)
+		return
+	}
+
+	// 码长序列的行程编码
+	type token struct {
+		symbol    int
+		extra     uint32
+		extraBits uint
+	}
+	var tokens []token
+	prev := 8
+	for i := 0; i < len(c.lengths); {
+		value := c.lengths[i]
+		run := 1
+		for i+run < len(c.lengths) && c.lengths[i+run] == value {
+			run++
+		}
+		i += run
+
+		if value == 0 {
+			for run > 0 {
+				switch {
+				case run >= 11:
+					n := minInt(run, 138)
+					tokens = append(tokens, token{18, uint32(n - 11), 7})
+					run -= n
+				case run >= 3:
+					tokens = append(tokens, token{17, uint32(run - 3), 3})
+					run = 0
+				default:
+					tokens = append(tokens, token{symbol: 0})
+					run--
+				}
+			}
+			continue
+		}
+
+		if value != prev {
+			tokens = append(tokens, token{symbol: value})
+			run--
+			prev = value
+		}
+		for run > 0 {
+			if run >= 3 {
+				n := minInt(run, 6)
+				tokens = append(tokens, token{16, uint32(n - 3), 2})
+				run -= n
+			} else {
+				tokens = append(tokens, token{symbol: value})
+				run--
+			}
+		}
+	}
+
+	histogram := make([]int, 19)
+	for _, t := range tokens {
+		histogram[t.symbol]++
+	}
+	lengthCode := vp8lHuffmanCode{lengths: vp8lHuffmanLengths(histogram, 7)}
+	lengthCode.codes = vp8lCanonicalCodes(lengthCode.lengths)
+
+	numCodes := 19
+	for numCodes > 4 && lengthCode.lengths[vp8lCodeLengthOrder[numCodes-1]] == 0 {
+		numCodes--
+	}
+
+	w.writeBits(0, 1) // 普通编码
+	w.writeBits(uint32(numCodes-4), 4)
+	for i := 0; i < numCodes; i++ {
+		w.writeBits(uint32(lengthCode.lengths[vp8lCodeLengthOrder[i]]), 3)
+	}
+	w.writeBits(0, 1) // 使用完整的字母表长度
+
+	for _, t := range tokens {
+		lengthCode.writeSymbol(w, t.symbol)
+		w.writeBits(t.extra, t.extraBits)
+	}
+}
+
+// writeSimple 写入简单编码（1~2个符号）
+func (c *vp8lHuffmanCode) writeSimple(w *vp8lBitWriter) {
+	w.writeBits(1, 1)
+	w.writeBits(uint32(len(c.simple)-1), 1)
+	if c.simple[0] < 2 {
+		w.writeBits(0, 1)
+		w.writeBits(uint32(c.simple[0]), 1)
+	} else {
+		w.writeBits(1, 1)
+		w.writeBits(uint32(c.simple[0]), 8)
+	}
+	if len(c.simple) == 2 {
+		w.writeBits(uint32(c.simple[1]), 8)
+	}
+}
+
+// minInt 返回较小值
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
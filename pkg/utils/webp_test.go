@@ -0,0 +1,393 @@
+package utils
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// vp8lBitReader 低位优先的位读取器
+type vp8lBitReader struct {
+	data []byte
+	pos  uint
+}
+
+func (r *vp8lBitReader) readBits(n uint) (uint32, error) {
+	var value uint32
+	for i := uint(0); i < n; i++ {
+		if r.pos/8 >= uint(len(r.data)) {
+			return 0, fmt.Errorf("数据不完整")
+		}
+		value |= uint32(r.data[r.pos/8]>>(r.pos%8)&1) << i
+		r.pos++
+	}
+	return value, nil
+}
+
+// vp8lDecodeCode 解码用的规范Huffman编码
+type vp8lDecodeCode struct {
+	single  int // 只有一个符号时不占用位
+	symbols map[[2]uint32]int
+}
+
+func newVP8LDecodeCode(lengths []int) (*vp8lDecodeCode, error) {
+	var used []int
+	for symbol, l := range lengths {
+		if l > 0 {
+			used = append(used, symbol)
+		}
+	}
+	switch len(used) {
+	case 0:
+		return &vp8lDecodeCode{single: 0}, nil
+	case 1:
+		return &vp8lDecodeCode{single: used[0]}, nil
+	}
+	codes := vp8lCanonicalCodes(lengths)
+	c := &vp8lDecodeCode{single: -1, symbols: make(map[[2]uint32]int)}
+	for _, symbol := range used {
+		// 规范码按高位优先从码流中读出
+		c.symbols[[2]uint32{uint32(lengths[symbol]), vp8lReverseBits(codes[symbol], lengths[symbol])}] = symbol
+	}
+	return c, nil
+}
+
+func (c *vp8lDecodeCode) read(r *vp8lBitReader) (int, error) {
+	if c.single >= 0 {
+		return c.single, nil
+	}
+	var code uint32
+	for length := uint32(1); length <= vp8lMaxCodeLength; length++ {
+		bit, err := r.readBits(1)
+		if err != nil {
+			return 0, err
+		}
+		code = code<<1 | bit
+		if symbol, ok := c.symbols[[2]uint32{length, code}]; ok {
+			return symbol, nil
+		}
+	}
+	return 0, fmt.Errorf("无效的Huffman码")
+}
+
+func readVP8LCode(r *vp8lBitReader, alphabetSize int) (*vp8lDecodeCode, error) {
+	lengths := make([]int, alphabetSize)
+	simple, err := r.readBits(1)
+	if err != nil {
+		return nil, err
+	}
+	if simple == 1 {
+		n, _ := r.readBits(1)
+		firstBits, _ := r.readBits(1)
+		first, _ := r.readBits(1 + 7*uint(firstBits))
+		if n == 0 {
+			return &vp8lDecodeCode{single: int(first)}, nil
+		}
+		second, err := r.readBits(8)
+		if err != nil {
+			return nil, err
+		}
+		lengths[first], lengths[second] = 1, 1
+		return newVP8LDecodeCode(lengths)
+	}
+
+	numCodes, _ := r.readBits(4)
+	codeLengths := make([]int, 19)
+	for i := 0; i < int(numCodes)+4; i++ {
+		l, err := r.readBits(3)
+		if err != nil {
+			return nil, err
+		}
+		codeLengths[vp8lCodeLengthOrder[i]] = int(l)
+	}
+	lengthCode, err := newVP8LDecodeCode(codeLengths)
+	if err != nil {
+		return nil, err
+	}
+	maxSymbol := alphabetSize
+	if limited, _ := r.readBits(1); limited == 1 {
+		n, _ := r.readBits(3)
+		m, _ := r.readBits(2 + 2*uint(n))
+		maxSymbol = int(m) + 2
+	}
+
+	prev := 8
+	for symbol := 0; symbol < alphabetSize && maxSymbol > 0; maxSymbol-- {
+		code, err := lengthCode.read(r)
+		if err != nil {
+			return nil, err
+		}
+		if code < 16 {
+			lengths[symbol] = code
+			symbol++
+			if code != 0 {
+				prev = code
+			}
+			continue
+		}
+		value, extraBits, base := 0, uint(7), 11
+		switch code {
+		case 16:
+			value, extraBits, base = prev, 2, 3
+		case 17:
+			extraBits, base = 3, 3
+		}
+		extra, err := r.readBits(extraBits)
+		if err != nil {
+			return nil, err
+		}
+		for n := base + int(extra); n > 0; n-- {
+			if symbol >= alphabetSize {
+				return nil, fmt.Errorf("码长超出字母表")
+			}
+			lengths[symbol] = value
+			symbol++
+		}
+	}
+	return newVP8LDecodeCode(lengths)
+}
+
+// readVP8LPrefix 读取长度或距离的前缀码和附加位
+func readVP8LPrefix(r *vp8lBitReader, code int) (int, error) {
+	if code < 4 {
+		return code + 1, nil
+	}
+	extraBits := uint(code-2) >> 1
+	extra, err := r.readBits(extraBits)
+	if err != nil {
+		return 0, err
+	}
+	return (2+code&1)<<extraBits + int(extra) + 1, nil
+}
+
+// readVP8LImage 读取熵编码的图像数据
+func readVP8LImage(r *vp8lBitReader, width, height int, main bool) ([]uint32, error) {
+	cacheBits := 0
+	if useCache, _ := r.readBits(1); useCache == 1 {
+		bits, _ := r.readBits(4)
+		cacheBits = int(bits)
+	}
+	if main {
+		if meta, _ := r.readBits(1); meta == 1 {
+			return nil, fmt.Errorf("测试解码器不支持元前缀码")
+		}
+	}
+
+	var codes [5]*vp8lDecodeCode
+	for i, size := range []int{vp8lNumLiterals + vp8lNumLengthCode + vp8lCacheSize(cacheBits), 256, 256, 256, vp8lNumDistCode} {
+		code, err := readVP8LCode(r, size)
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+	}
+
+	cache := make([]uint32, vp8lCacheSize(cacheBits))
+	pixels := make([]uint32, 0, width*height)
+	for len(pixels) < width*height {
+		start := len(pixels)
+		green, err := codes[0].read(r)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case green < vp8lNumLiterals:
+			red, _ := codes[1].read(r)
+			blue, _ := codes[2].read(r)
+			alpha, err := codes[3].read(r)
+			if err != nil {
+				return nil, err
+			}
+			pixels = append(pixels, uint32(alpha)<<24|uint32(red)<<16|uint32(green)<<8|uint32(blue))
+		case green < vp8lNumLiterals+vp8lNumLengthCode:
+			length, err := readVP8LPrefix(r, green-vp8lNumLiterals)
+			if err != nil {
+				return nil, err
+			}
+			distSymbol, err := codes[4].read(r)
+			if err != nil {
+				return nil, err
+			}
+			distCode, err := readVP8LPrefix(r, distSymbol)
+			if err != nil {
+				return nil, err
+			}
+			var dist int
+			switch {
+			case distCode == 1:
+				dist = width
+			case distCode == 2:
+				dist = 1
+			case distCode > 120:
+				dist = distCode - 120
+			default:
+				return nil, fmt.Errorf("测试解码器不支持距离码 %d", distCode)
+			}
+			if dist > len(pixels) || len(pixels)+length > width*height {
+				return nil, fmt.Errorf("无效的后向引用")
+			}
+			for n := 0; n < length; n++ {
+				pixels = append(pixels, pixels[len(pixels)-dist])
+			}
+		default:
+			pixels = append(pixels, cache[green-vp8lNumLiterals-vp8lNumLengthCode])
+		}
+		if cacheBits > 0 {
+			for _, argb := range pixels[start:] {
+				cache[vp8lCacheKey(argb, cacheBits)] = argb
+			}
+		}
+	}
+	return pixels, nil
+}
+
+// decodeTestWebP 解码本文件的编码器生成的WebP无损图片（支持减绿变换、预测变换和颜色缓存）
+func decodeTestWebP(t *testing.T, data []byte) *image.NRGBA {
+	t.Helper()
+	if len(data) < 21 || string(data[0:4]) != "RIFF" || string(data[8:16]) != "WEBPVP8L" || data[20] != vp8lSignature {
+		t.Fatalf("WebP文件头不正确")
+	}
+	if size := binary.LittleEndian.Uint32(data[4:8]); int(size)+8 != len(data) {
+		t.Fatalf("RIFF大小 %d 与文件大小 %d 不符", size, len(data))
+	}
+	r := &vp8lBitReader{data: data[21:]}
+	w, _ := r.readBits(14)
+	h, _ := r.readBits(14)
+	r.readBits(1 + 3)
+	width, height := int(w)+1, int(h)+1
+
+	var transforms []int
+	var modes []uint32
+	var modeBits int
+	for {
+		present, err := r.readBits(1)
+		if err != nil {
+			t.Fatalf("读取变换失败: %v", err)
+		}
+		if present == 0 {
+			break
+		}
+		kind, _ := r.readBits(2)
+		switch kind {
+		case vp8lSubtractGreen:
+		case vp8lPredictor:
+			bits, _ := r.readBits(3)
+			modeBits = int(bits) + 2
+			if modes, err = readVP8LImage(r, vp8lSubSampleSize(width, modeBits), vp8lSubSampleSize(height, modeBits), false); err != nil {
+				t.Fatalf("读取预测方式失败: %v", err)
+			}
+		default:
+			t.Fatalf("测试解码器不支持变换 %d", kind)
+		}
+		transforms = append(transforms, int(kind))
+	}
+
+	pixels, err := readVP8LImage(r, width, height, true)
+	if err != nil {
+		t.Fatalf("解码像素失败: %v", err)
+	}
+
+	// 按与写入相反的顺序还原变换
+	for i := len(transforms) - 1; i >= 0; i-- {
+		switch transforms[i] {
+		case vp8lPredictor:
+			modeWidth := vp8lSubSampleSize(width, modeBits)
+			for y := 0; y < height; y++ {
+				for x := 0; x < width; x++ {
+					mode := int(modes[(y>>uint(modeBits))*modeWidth+x>>uint(modeBits)]>>8) & 0xff
+					pixels[y*width+x] = vp8lAdd(pixels[y*width+x], vp8lPredictPixel(pixels, width, x, y, mode))
+				}
+			}
+		case vp8lSubtractGreen:
+			for i, argb := range pixels {
+				green := argb >> 8 & 0xff
+				pixels[i] = argb&0xff00ff00 | (argb>>16+green)&0xff<<16 | (argb+green)&0xff
+			}
+		}
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for i, argb := range pixels {
+		img.Pix[i*4], img.Pix[i*4+1], img.Pix[i*4+2], img.Pix[i*4+3] = uint8(argb>>16), uint8(argb>>8), uint8(argb), uint8(argb>>24)
+	}
+	return img
+}
+
+// vp8lAdd 逐通道相加（模256）
+func vp8lAdd(a, b uint32) uint32 {
+	var argb uint32
+	for c := 0; c < 4; c++ {
+		argb |= uint32(uint8(vp8lChannel(a, c)+vp8lChannel(b, c))) << uint(8*c)
+	}
+	return argb
+}
+
+// testScreenshot 生成类似界面截图的测试图片：标题栏、侧边栏和重复出现的“文字”
+func testScreenshot(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.NRGBA{255, 255, 255, 255}
+			switch {
+			case y < 40:
+				c = color.NRGBA{40, 90, 200, 255}
+			case x < 160:
+				c = color.NRGBA{235, 235, 235, 255}
+			case y%20 < 8 && (x*7+y*3)%11 < 6:
+				c = color.NRGBA{30, 30, 30, 255}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// assertSameImage 比较两张图片的像素
+func assertSameImage(t *testing.T, expected image.Image, actual *image.NRGBA) {
+	t.Helper()
+	b := expected.Bounds()
+	if actual.Bounds().Dx() != b.Dx() || actual.Bounds().Dy() != b.Dy() {
+		t.Fatalf("尺寸不符: 期望 %dx%d, 实际 %dx%d", b.Dx(), b.Dy(), actual.Bounds().Dx(), actual.Bounds().Dy())
+	}
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			want := color.NRGBAModel.Convert(expected.At(b.Min.X+x, b.Min.Y+y)).(color.NRGBA)
+			if got := actual.NRGBAAt(x, y); got != want {
+				t.Fatalf("(%d, %d) 像素不符: 期望 %v, 实际 %v", x, y, want, got)
+			}
+		}
+	}
+}
+
+func TestEncodeWebPLossless(t *testing.T) {
+	gradient := image.NewNRGBA(image.Rect(0, 0, 67, 45))
+	noise := image.NewNRGBA(image.Rect(0, 0, 33, 17))
+	for y := 0; y < 45; y++ {
+		for x := 0; x < 67; x++ {
+			gradient.SetNRGBA(x, y, color.NRGBA{uint8(x * 3), uint8(y * 5), uint8(x + y), uint8(255 - x)})
+		}
+	}
+	seed := uint32(1)
+	for i := range noise.Pix {
+		seed = seed*1103515245 + 12345
+		noise.Pix[i] = uint8(seed >> 16)
+	}
+
+	tests := map[string]image.Image{
+		"单像素":  image.NewNRGBA(image.Rect(0, 0, 1, 1)),
+		"渐变透明": gradient,
+		"噪声":   noise,
+		"截图":   testScreenshot(300, 200),
+	}
+	for name, img := range tests {
+		t.Run(name, func(t *testing.T) {
+			data, err := EncodeWebPLossless(img)
+			if err != nil {
+				t.Fatalf("编码失败: %v", err)
+			}
+			assertSameImage(t, img, decodeTestWebP(t, data))
+		})
+	}
+}
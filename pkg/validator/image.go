@@ -0,0 +1,219 @@
+package validator
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"md-manual-tool/pkg/constants"
+	"md-manual-tool/pkg/i18n"
+	"path"
+	"strings"
+
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// IssueLevel 图片检查问题的级别
+type IssueLevel string
+
+const (
+	// LevelWarning 警告，仅提示不中断处理
+	LevelWarning IssueLevel = "warn"
+	// LevelError 错误，在写入任何文件前中断处理
+	LevelError IssueLevel = "error"
+)
+
+// ParseIssueLevel 解析问题级别，空字符串视为warn
+func ParseIssueLevel(value string) (IssueLevel, error) {
+	switch IssueLevel(strings.ToLower(strings.TrimSpace(value))) {
+	case "", LevelWarning:
+		return LevelWarning, nil
+	case LevelError:
+		return LevelError, nil
+	}
+	return "", fmt.Errorf("无效的图片检查级别: %s（可选值: warn、error）", value)
+}
+
+// ImageRules 图片检查规则
+type ImageRules struct {
+	MaxBytes         int64      // 文件大小上限，0表示不限制
+	MaxWidth         int        // 宽度上限，0表示不限制
+	MaxHeight        int        // 高度上限，0表示不限制
+	RequireExtension bool       // 文件内容格式必须与扩展名一致
+	Level            IssueLevel // 违反规则时的问题级别
+}
+
+// ImageIssue 图片检查发现的问题
+type ImageIssue struct {
+	Path    string
+	Level   IssueLevel
+	Message string
+}
+
+// String 格式化问题描述
+func (i ImageIssue) String() string {
+	return fmt.Sprintf("[%s] %s: %s", i.Level, i.Path, i.Message)
+}
+
+// ImageInfo 图片元数据
+type ImageInfo struct {
+	Format string // 根据内容识别的格式，无法识别时为空
+	Width  int    // 宽度，无法获取时为0
+	Height int    // 高度，无法获取时为0
+	Broken bool   // 格式可识别但文件头无法解码
+}
+
+// ValidateImage 按规则检查图片，返回发现的问题
+func (v *Validator) ValidateImage(imgPath string, data []byte, rules *ImageRules) []ImageIssue {
+	if rules == nil {
+		return nil
+	}
+
+	level := rules.Level
+	if level == "" {
+		level = LevelWarning
+	}
+
+	var issues []ImageIssue
+	add := func(key string, args ...interface{}) {
+		issues = append(issues, ImageIssue{Path: imgPath, Level: level, Message: i18n.Sprintf(key, args...)})
+	}
+
+	if len(data) == 0 {
+		add(constants.MsgImageEmpty)
+		return issues
+	}
+
+	if rules.MaxBytes > 0 && int64(len(data)) > rules.MaxBytes {
+		add(constants.MsgImageTooLarge, len(data), rules.MaxBytes)
+	}
+
+	info := InspectImage(data)
+	switch {
+	case info.Format == "":
+		add(constants.MsgImageUnknown)
+	case info.Broken:
+		add(constants.MsgImageBroken, info.Format)
+	}
+
+	if expected := formatFromExtension(imgPath); rules.RequireExtension && info.Format != "" && expected != "" && expected != info.Format {
+		add(constants.MsgImageExtMismatch, path.Ext(imgPath), info.Format)
+	}
+
+	if rules.MaxWidth > 0 && info.Width > rules.MaxWidth {
+		add(constants.MsgImageTooWide, info.Width, rules.MaxWidth)
+	}
+	if rules.MaxHeight > 0 && info.Height > rules.MaxHeight {
+		add(constants.MsgImageTooTall, info.Height, rules.MaxHeight)
+	}
+
+	return issues
+}
+
+// InspectImage 根据文件内容识别图片格式并读取尺寸
+func InspectImage(data []byte) ImageInfo {
+	info := ImageInfo{Format: SniffImageFormat(data)}
+
+	switch info.Format {
+	case "png", "jpeg", "gif":
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			info.Broken = true
+			return info
+		}
+		info.Width, info.Height = cfg.Width, cfg.Height
+	case "bmp":
+		if len(data) < 26 {
+			info.Broken = true
+			return info
+		}
+		info.Width = int(int32(binary.LittleEndian.Uint32(data[18:22])))
+		info.Height = int(int32(binary.LittleEndian.Uint32(data[22:26])))
+		if info.Height < 0 {
+			info.Height = -info.Height // 自上而下存储的BMP高度为负数
+		}
+	case "webp":
+		info.Width, info.Height, info.Broken = webpDimensions(data)
+	}
+
+	return info
+}
+
+// SniffImageFormat 根据文件头识别图片格式
+func SniffImageFormat(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return "png"
+	case bytes.HasPrefix(data, []byte{0xff, 0xd8, 0xff}):
+		return "jpeg"
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		return "gif"
+	case bytes.HasPrefix(data, []byte("BM")):
+		return "bmp"
+	case len(data) >= 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return "webp"
+	case bytes.HasPrefix(data, []byte("II*\x00")), bytes.HasPrefix(data, []byte("MM\x00*")):
+		return "tiff"
+	case bytes.HasPrefix(data, []byte{0, 0, 1, 0}):
+		return "ico"
+	}
+
+	// SVG为文本格式，在文件开头查找<svg标签
+	head := data
+	if len(head) > 1024 {
+		head = head[:1024]
+	}
+	if bytes.Contains(bytes.ToLower(head), []byte("<svg")) {
+		return "svg"
+	}
+	return ""
+}
+
+// formatFromExtension 根据扩展名推断图片格式
+func formatFromExtension(imgPath string) string {
+	ext := strings.ToLower(path.Ext(strings.ReplaceAll(imgPath, "\\", "/")))
+	if idx := strings.Index(ext, "?"); idx != -1 {
+		ext = ext[:idx]
+	}
+	switch ext {
+	case ".png", ".gif", ".bmp", ".webp", ".svg", ".ico":
+		return ext[1:]
+	case ".jpg", ".jpeg":
+		return "jpeg"
+	case ".tif", ".tiff":
+		return "tiff"
+	}
+	return ""
+}
+
+// webpDimensions 解析WebP文件头中的尺寸（支持VP8、VP8L和VP8X）
+func webpDimensions(data []byte) (width, height int, broken bool) {
+	if len(data) < 30 {
+		return 0, 0, true
+	}
+
+	switch string(data[12:16]) {
+	case "VP8 ":
+		// 帧标签3字节 + 起始码 9d 01 2a
+		if data[23] != 0x9d || data[24] != 0x01 || data[25] != 0x2a {
+			return 0, 0, true
+		}
+		width = int(binary.LittleEndian.Uint16(data[26:28]) & 0x3fff)
+		height = int(binary.LittleEndian.Uint16(data[28:30]) & 0x3fff)
+	case "VP8L":
+		if data[20] != 0x2f {
+			return 0, 0, true
+		}
+		bits := binary.LittleEndian.Uint32(data[21:25])
+		width = int(bits&0x3fff) + 1
+		height = int((bits>>14)&0x3fff) + 1
+	case "VP8X":
+		width = int(uint32(data[24])|uint32(data[25])<<8|uint32(data[26])<<16) + 1
+		height = int(uint32(data[27])|uint32(data[28])<<8|uint32(data[29])<<16) + 1
+	default:
+		return 0, 0, true
+	}
+	return width, height, false
+}
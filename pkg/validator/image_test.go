@@ -0,0 +1,81 @@
+package validator
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// encodeTestPNG 生成指定尺寸的测试PNG
+func encodeTestPNG(t *testing.T, width, height int) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatalf("编码测试PNG失败: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestInspectImage(t *testing.T) {
+	info := InspectImage(encodeTestPNG(t, 40, 30))
+	if info.Format != "png" || info.Width != 40 || info.Height != 30 || info.Broken {
+		t.Errorf("PNG元数据错误: %+v", info)
+	}
+
+	// 只有文件头的PNG视为损坏
+	info = InspectImage([]byte("\x89PNG\r\n\x1a\n"))
+	if info.Format != "png" || !info.Broken {
+		t.Errorf("截断的PNG应标记为损坏: %+v", info)
+	}
+
+	// VP8L头：宽高各减1后按14位存储
+	webp := []byte("RIFF\x00\x00\x00\x00WEBPVP8L\x00\x00\x00\x00\x2f")
+	webp = append(webp, 0x3f, 0x40, 0x06, 0x00, 0, 0, 0, 0, 0)
+	info = InspectImage(webp)
+	if info.Format != "webp" || info.Width != 64 || info.Height != 26 {
+		t.Errorf("WebP元数据错误: %+v", info)
+	}
+
+	if format := SniffImageFormat([]byte(`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg"/>`)); format != "svg" {
+		t.Errorf("SVG识别错误: %s", format)
+	}
+}
+
+func TestValidateImage(t *testing.T) {
+	v := NewValidator()
+	png := encodeTestPNG(t, 200, 100)
+
+	tests := []struct {
+		name    string
+		path    string
+		data    []byte
+		rules   *ImageRules
+		message string
+	}{
+		{"空文件", "a.png", nil, &ImageRules{}, "文件为空"},
+		{"文本冒充图片", "a.png", []byte("hello"), &ImageRules{}, "无法识别"},
+		{"扩展名不匹配", "a.jpg", png, &ImageRules{RequireExtension: true}, "内容为png"},
+		{"超过大小", "a.png", png, &ImageRules{MaxBytes: 10}, "文件大小"},
+		{"超过宽度", "a.png", png, &ImageRules{MaxWidth: 100}, "宽度 200"},
+		{"超过高度", "a.png", png, &ImageRules{MaxHeight: 50}, "高度 100"},
+		{"符合规则", "a.png?v=1", png, &ImageRules{RequireExtension: true, MaxWidth: 200, MaxHeight: 100}, ""},
+	}
+
+	for _, tt := range tests {
+		issues := v.ValidateImage(tt.path, tt.data, tt.rules)
+		if tt.message == "" {
+			if len(issues) != 0 {
+				t.Errorf("%s: 不应有问题，实际: %v", tt.name, issues)
+			}
+			continue
+		}
+		if len(issues) != 1 || !strings.Contains(issues[0].Message, tt.message) {
+			t.Errorf("%s: 期望问题包含 %q，实际: %v", tt.name, tt.message, issues)
+			continue
+		}
+		if issues[0].Level != LevelWarning {
+			t.Errorf("%s: 默认级别应为warn，实际: %s", tt.name, issues[0].Level)
+		}
+	}
+}
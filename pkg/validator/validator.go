@@ -1,8 +1,8 @@
 package validator
 
 import (
-	"fmt"
 	"md-manual-tool/pkg/constants"
+	"md-manual-tool/pkg/i18n"
 	"os"
 	"regexp"
 )
@@ -51,7 +51,7 @@ func (v *Validator) ValidateInputs(templatePath, configPath string) *ValidationR
 // validateFile 验证文件是否存在
 func (v *Validator) validateFile(filePath, fileType string) error {
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return fmt.Errorf(constants.ErrFileNotExist, fileType, filePath, v.getCurrentDir())
+		return i18n.Errorf(constants.ErrFileNotExist, i18n.Msg(fileType), filePath, v.getCurrentDir())
 	}
 	return nil
 }
@@ -60,7 +60,7 @@ func (v *Validator) validateFile(filePath, fileType string) error {
 func (v *Validator) getCurrentDir() string {
 	dir, err := os.Getwd()
 	if err != nil {
-		return "无法获取当前目录"
+		return i18n.Msg(constants.ErrCurrentDir)
 	}
 	return dir
 }
@@ -74,7 +74,7 @@ func (v *Validator) ValidateVersionFormat(version string) error {
 	// 验证版本号格式：x.y.z
 	re := regexp.MustCompile(`^\d+\.\d+\.\d+$`)
 	if !re.MatchString(version) {
-		return fmt.Errorf("版本号格式无效，请使用 x.y.z 格式（如 1.0.1）")
+		return i18n.Errorf(constants.ErrInvalidVersionFormat)
 	}
 
 	return nil
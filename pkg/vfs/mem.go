@@ -0,0 +1,295 @@
+package vfs
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemFS 内存文件系统，可被多个goroutine同时使用；主要用于测试
+//
+// 与其他io/fs实现一样只接受有效的io/fs路径，操作系统格式的路径通过本包的ReadFile、WriteFile等函数访问
+type MemFS struct {
+	mu    sync.RWMutex
+	files map[string]*memEntry // 文件和目录，键为io/fs格式的路径
+}
+
+// memEntry 内存中的文件或目录
+type memEntry struct {
+	data    []byte
+	dir     bool
+	modTime time.Time
+}
+
+// NewMemFS 创建内存文件系统，files为初始文件（路径 -> 内容），所在目录自动创建
+func NewMemFS(files map[string]string) *MemFS {
+	m := &MemFS{files: map[string]*memEntry{".": {dir: true}}}
+	for name, content := range files {
+		if err := WriteFile(m, name, []byte(content)); err != nil {
+			panic(err)
+		}
+	}
+	return m
+}
+
+// Open 打开文件或目录
+func (m *MemFS) Open(name string) (fs.File, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	key, entry, err := m.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+	info := &memInfo{name: path.Base(key), entry: entry}
+	if !entry.dir {
+		return &memFile{info: info, Reader: bytes.NewReader(entry.data)}, nil
+	}
+	return &memDir{info: info, entries: m.children(key)}, nil
+}
+
+// Stat 读取文件信息
+func (m *MemFS) Stat(name string) (fs.FileInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	key, entry, err := m.lookup("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return &memInfo{name: path.Base(key), entry: entry}, nil
+}
+
+// ReadFile 读取文件内容的副本
+func (m *MemFS) ReadFile(name string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, entry, err := m.lookup("read", name)
+	if err != nil {
+		return nil, err
+	}
+	if entry.dir {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: errIsDir}
+	}
+	return append([]byte(nil), entry.data...), nil
+}
+
+// ReadDir 按名称顺序列出目录内容
+func (m *MemFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	key, entry, err := m.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if !entry.dir {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errNotDir}
+	}
+	return m.children(key), nil
+}
+
+// MkdirAll 创建目录及其所有上级目录
+func (m *MemFS) MkdirAll(dir string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key, err := memKey("mkdir", dir)
+	if err != nil {
+		return err
+	}
+	for p := key; ; p = path.Dir(p) {
+		if entry, ok := m.files[p]; ok {
+			if !entry.dir {
+				return &fs.PathError{Op: "mkdir", Path: dir, Err: errNotDir}
+			}
+		} else {
+			m.files[p] = &memEntry{dir: true, modTime: time.Now()}
+		}
+		if p == "." {
+			return nil
+		}
+	}
+}
+
+// WriteFile 写入文件，所在目录必须已存在
+func (m *MemFS) WriteFile(name string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key, err := memKey("write", name)
+	if err != nil {
+		return err
+	}
+	if parent, ok := m.files[path.Dir(key)]; !ok || !parent.dir {
+		return &fs.PathError{Op: "write", Path: name, Err: fs.ErrNotExist}
+	}
+	if entry, ok := m.files[key]; ok && entry.dir {
+		return &fs.PathError{Op: "write", Path: name, Err: errIsDir}
+	}
+	m.files[key] = &memEntry{data: append([]byte(nil), data...), modTime: time.Now()}
+	return nil
+}
+
+// Remove 删除文件或空目录
+func (m *MemFS) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key, entry, err := m.lookup("remove", name)
+	if err != nil {
+		return err
+	}
+	if key == "." || entry.dir && len(m.children(key)) > 0 {
+		return &fs.PathError{Op: "remove", Path: name, Err: errNotEmpty}
+	}
+	delete(m.files, key)
+	return nil
+}
+
+// Rename 重命名文件，目标文件已存在时覆盖；不支持重命名目录
+func (m *MemFS) Rename(oldname, newname string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	oldKey, entry, err := m.lookup("rename", oldname)
+	if err != nil {
+		return err
+	}
+	if entry.dir {
+		return &fs.PathError{Op: "rename", Path: oldname, Err: errIsDir}
+	}
+	newKey, err := memKey("rename", newname)
+	if err != nil {
+		return err
+	}
+	if parent, ok := m.files[path.Dir(newKey)]; !ok || !parent.dir {
+		return &fs.PathError{Op: "rename", Path: newname, Err: fs.ErrNotExist}
+	}
+	if target, ok := m.files[newKey]; ok && target.dir {
+		return &fs.PathError{Op: "rename", Path: newname, Err: errIsDir}
+	}
+	delete(m.files, oldKey)
+	m.files[newKey] = entry
+	return nil
+}
+
+// Files 按路径顺序返回所有文件（不含目录）
+func (m *MemFS) Files() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var names []string
+	for key, entry := range m.files {
+		if !entry.dir {
+			names = append(names, key)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lookup 查找文件或目录，需持有读锁
+func (m *MemFS) lookup(op, name string) (string, *memEntry, error) {
+	key, err := memKey(op, name)
+	if err != nil {
+		return "", nil, err
+	}
+	entry, ok := m.files[key]
+	if !ok {
+		return "", nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return key, entry, nil
+}
+
+// children 按名称顺序返回目录的直接子项，需持有读锁
+func (m *MemFS) children(dir string) []fs.DirEntry {
+	prefix := dir + "/"
+	if dir == "." {
+		prefix = ""
+	}
+	var entries []fs.DirEntry
+	for key, entry := range m.files {
+		if key == "." || !strings.HasPrefix(key, prefix) || strings.Contains(key[len(prefix):], "/") {
+			continue
+		}
+		entries = append(entries, fs.FileInfoToDirEntry(&memInfo{name: key[len(prefix):], entry: entry}))
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries
+}
+
+// memKey 检查路径是否为有效的io/fs路径
+func memKey(op, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	return name, nil
+}
+
+var (
+	errIsDir    = fsError("是目录")
+	errNotDir   = fsError("不是目录")
+	errNotEmpty = fsError("目录不为空")
+)
+
+// fsError 内存文件系统的错误
+type fsError string
+
+func (e fsError) Error() string { return string(e) }
+
+// memInfo 文件信息，同时用于目录项
+type memInfo struct {
+	name  string
+	entry *memEntry
+}
+
+func (i *memInfo) Name() string       { return i.name }
+func (i *memInfo) Size() int64        { return int64(len(i.entry.data)) }
+func (i *memInfo) ModTime() time.Time { return i.entry.modTime }
+func (i *memInfo) IsDir() bool        { return i.entry.dir }
+func (i *memInfo) Sys() interface{}   { return nil }
+
+func (i *memInfo) Mode() fs.FileMode {
+	if i.entry.dir {
+		return fs.ModeDir | 0755
+	}
+	return 0644
+}
+
+// memFile 打开的文件
+type memFile struct {
+	info *memInfo
+	*bytes.Reader
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *memFile) Close() error               { return nil }
+
+// memDir 打开的目录
+type memDir struct {
+	info    *memInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *memDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *memDir) Close() error               { return nil }
+
+func (d *memDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: errIsDir}
+}
+
+// ReadDir 读取目录项，n <= 0 时返回剩余的所有目录项
+func (d *memDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(rest) {
+		n = len(rest)
+	}
+	d.offset += n
+	return rest[:n], nil
+}
@@ -0,0 +1,60 @@
+package vfs
+
+import (
+	"io/fs"
+	"os"
+)
+
+// OSFS 本地文件系统，路径使用操作系统格式；Windows上超长路径自动加上长路径前缀
+type OSFS struct{}
+
+// Open 打开文件
+func (OSFS) Open(name string) (fs.File, error) {
+	return os.Open(longPath(name))
+}
+
+// Stat 读取文件信息
+func (OSFS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(longPath(name))
+}
+
+// ReadFile 读取文件内容
+func (OSFS) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(longPath(name))
+}
+
+// ReadDir 读取目录
+func (OSFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(longPath(name))
+}
+
+// MkdirAll 创建目录及其所有上级目录，先按当前操作系统的规则检查路径
+func (OSFS) MkdirAll(dir string) error {
+	if err := ValidatePath(dir); err != nil {
+		return err
+	}
+	return os.MkdirAll(longPath(dir), 0755)
+}
+
+// WriteFile 写入文件，先按当前操作系统的规则检查路径
+func (OSFS) WriteFile(name string, data []byte) error {
+	if err := ValidatePath(name); err != nil {
+		return err
+	}
+	return os.WriteFile(longPath(name), data, 0644)
+}
+
+// Remove 删除文件或空目录
+func (OSFS) Remove(name string) error {
+	return os.Remove(longPath(name))
+}
+
+// Rename 重命名文件，先按当前操作系统的规则检查目标路径
+func (OSFS) Rename(oldname, newname string) error {
+	if err := ValidatePath(newname); err != nil {
+		return err
+	}
+	return os.Rename(longPath(oldname), longPath(newname))
+}
+
+func (OSFS) native() bool { return true }
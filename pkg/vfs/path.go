@@ -0,0 +1,127 @@
+package vfs
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// windowsMaxPath 超过该长度的Windows路径需要长路径前缀（创建目录的上限为MAX_PATH-12）
+const windowsMaxPath = 248
+
+// windowsInvalidChars Windows文件名中不允许的字符（另有0-31的控制字符）
+const windowsInvalidChars = `<>"|?*`
+
+// windowsReservedNames Windows保留的设备名，带扩展名时同样不可用（如 CON.txt）
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// ValidatePath 按当前操作系统的规则检查路径能否用于创建文件或目录
+//
+// Linux和macOS只禁止空路径和NUL字符，? * : 等都是合法的文件名字符；
+// Windows还检查保留字符、盘符以外的冒号、保留设备名以及以空格或点结尾的文件名
+func ValidatePath(name string) error {
+	return validatePath(runtime.GOOS, name)
+}
+
+// validatePath 按指定操作系统的规则检查路径，只做字符串判断
+func validatePath(goos, name string) error {
+	if name == "" {
+		return fmt.Errorf("路径为空")
+	}
+	if strings.IndexByte(name, 0) >= 0 {
+		return fmt.Errorf("路径包含NUL字符: %q", name)
+	}
+	if goos == "windows" {
+		return validateWindowsPath(name)
+	}
+	return nil
+}
+
+// validateWindowsPath 检查Windows路径：支持盘符路径、UNC路径（\\server\share）和长路径前缀（\\?\）
+func validateWindowsPath(name string) error {
+	rest := name
+	switch {
+	case hasPrefixFold(rest, `\\?\UNC\`):
+		rest = rest[len(`\\?\UNC\`):]
+	case strings.HasPrefix(rest, `\\?\`):
+		rest = rest[len(`\\?\`):]
+	case strings.HasPrefix(rest, `\\`) || strings.HasPrefix(rest, `//`):
+		rest = rest[2:]
+	}
+	if len(rest) >= 2 && rest[1] == ':' && isASCIILetter(rest[0]) {
+		rest = rest[2:]
+	}
+
+	for _, part := range strings.FieldsFunc(rest, func(r rune) bool { return r == '\\' || r == '/' }) {
+		if part == "." || part == ".." {
+			continue
+		}
+		for _, r := range part {
+			if r < 32 {
+				return fmt.Errorf("路径包含控制字符 %#x: %q", r, name)
+			}
+			if r == ':' {
+				return fmt.Errorf("冒号只能用于盘符: %s", name)
+			}
+			if strings.ContainsRune(windowsInvalidChars, r) {
+				return fmt.Errorf("路径包含Windows不允许的字符 '%c': %s", r, name)
+			}
+		}
+		if strings.HasSuffix(part, " ") || strings.HasSuffix(part, ".") {
+			return fmt.Errorf("Windows文件名不能以空格或点结尾: %q", part)
+		}
+		base := strings.ToUpper(strings.TrimRight(strings.SplitN(part, ".", 2)[0], " "))
+		if windowsReservedNames[base] {
+			return fmt.Errorf("Windows保留的设备名不能作为文件名: %s", part)
+		}
+	}
+	return nil
+}
+
+// longPath 在Windows上为超长路径加上长路径前缀，其他系统原样返回
+func longPath(name string) string {
+	if runtime.GOOS != "windows" {
+		return name
+	}
+	return absLongPath(name, filepath.Abs)
+}
+
+// absLongPath 先用abs转换为绝对路径再判断是否超长：相对路径本身不长，加上工作目录后仍可能超长
+func absLongPath(name string, abs func(string) (string, error)) string {
+	if full, err := abs(name); err == nil {
+		name = full
+	}
+	return windowsLongPath(name)
+}
+
+// windowsLongPath 为超长的Windows绝对路径加上长路径前缀：盘符路径为 \\?\C:\...，UNC路径为 \\?\UNC\server\share\...
+//
+// 带前缀的路径不再经过系统规范化，因此分隔符统一为反斜杠；相对路径和已带前缀的路径原样返回
+func windowsLongPath(name string) string {
+	if len(name) < windowsMaxPath || strings.HasPrefix(name, `\\?\`) {
+		return name
+	}
+	name = strings.ReplaceAll(name, "/", `\`)
+	switch {
+	case strings.HasPrefix(name, `\\`):
+		return `\\?\UNC\` + name[2:]
+	case len(name) >= 3 && isASCIILetter(name[0]) && name[1] == ':' && name[2] == '\\':
+		return `\\?\` + name
+	}
+	return name
+}
+
+// hasPrefixFold 不区分大小写的前缀判断
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+// isASCIILetter 判断是否为ASCII字母（盘符）
+func isASCIILetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,96 @@
+package vfs
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidatePath(t *testing.T) {
+	long := `C:\` + strings.Repeat(`目录\`, 100) + "a.md"
+	tests := []struct {
+		name string
+		goos string
+		path string
+		err  string // 为空表示合法
+	}{
+		{"Linux普通路径", "linux", "output/手册_3.3.0.md", ""},
+		{"Linux带点的目录", "linux", "/home/me/.docs/v3.2", ""},
+		{"Linux问号和星号", "linux", "out/what?*.md", ""},
+		{"Linux冒号和尖括号", "linux", "out/a:b<c>.md", ""},
+		{"Linux反斜杠", "linux", `out/a\b.md`, ""},
+		{"macOS同Linux", "darwin", "out/CON.md", ""},
+		{"空路径", "linux", "", "路径为空"},
+		{"NUL字符", "linux", "out/a\x00.md", "NUL"},
+
+		{"Windows盘符路径", "windows", `C:\out\手册_3.3.0.md`, ""},
+		{"Windows正斜杠", "windows", `C:/out/v3.2/a.md`, ""},
+		{"Windows相对路径", "windows", `..\out\.docs\a.md`, ""},
+		{"Windows UNC路径", "windows", `\\server\share$\out\a.md`, ""},
+		{"Windows长路径前缀", "windows", `\\?\C:\out\a.md`, ""},
+		{"Windows UNC长路径前缀", "windows", `\\?\UNC\server\share\a.md`, ""},
+		{"Windows超长路径", "windows", long, ""},
+		{"Windows问号", "windows", `C:\out\what?.md`, "'?'"},
+		{"Windows星号", "windows", `out\*.md`, "'*'"},
+		{"Windows尖括号", "windows", `out\<a>.md`, "'<'"},
+		{"Windows竖线", "windows", `out\a|b.md`, "'|'"},
+		{"Windows引号", "windows", `out\"a".md`, `'"'`},
+		{"Windows控制字符", "windows", "out\\a\tb.md", "控制字符"},
+		{"Windows盘符以外的冒号", "windows", `C:\out\a:b.md`, "冒号"},
+		{"Windows相对路径中的冒号", "windows", `out:a\b.md`, "冒号"},
+		{"Windows保留设备名", "windows", `C:\out\CON`, "保留"},
+		{"Windows带扩展名的保留设备名", "windows", `out\com1.txt`, "保留"},
+		{"Windows保留名为前缀的普通名称", "windows", `out\CONSOLE.md`, ""},
+		{"Windows以点结尾", "windows", `out\v3.2.\a.md`, "空格或点结尾"},
+		{"Windows以空格结尾", "windows", `out\docs \a.md`, "空格或点结尾"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePath(tt.goos, tt.path)
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("validatePath(%q) 应合法: %v", tt.path, err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("validatePath(%q) = %v, 期望包含 %q", tt.path, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestWindowsLongPath(t *testing.T) {
+	dirs := strings.Repeat(`目录\`, 100)
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{"短路径不变", `C:\out\a.md`, `C:\out\a.md`},
+		{"盘符路径", `C:\` + dirs + "a.md", `\\?\C:\` + dirs + "a.md"},
+		{"正斜杠统一为反斜杠", `D:/` + strings.ReplaceAll(dirs, `\`, "/") + "a.md", `\\?\D:\` + dirs + "a.md"},
+		{"UNC路径", `\\server\share\` + dirs + "a.md", `\\?\UNC\server\share\` + dirs + "a.md"},
+		{"已带前缀", `\\?\C:\` + dirs + "a.md", `\\?\C:\` + dirs + "a.md"},
+		{"相对路径不加前缀", dirs + "a.md", dirs + "a.md"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := windowsLongPath(tt.path); got != tt.expected {
+				t.Errorf("windowsLongPath(%q)\n= %q\n期望 %q", tt.path, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAbsLongPath(t *testing.T) {
+	// 工作目录约100个字符，相对路径约200个字符：单独都不超长，合在一起超长
+	wd := `C:\` + strings.Repeat("w", 96) + `\`
+	abs := func(name string) (string, error) { return wd + name, nil }
+	name := strings.Repeat(`d\`, 100) + "a.md"
+	if got, expected := absLongPath(name, abs), `\\?\`+wd+name; got != expected {
+		t.Errorf("absLongPath(%q)\n= %q\n期望 %q", name, got, expected)
+	}
+	if got := absLongPath("out\\a.md", abs); got != wd+`out\a.md` {
+		t.Errorf("短路径不应加前缀: %q", got)
+	}
+}
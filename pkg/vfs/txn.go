@@ -0,0 +1,258 @@
+package vfs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+)
+
+// Txn 暂存对WriteFS的写入，用于一次生成的所有输出
+//
+// WriteFile先写入同目录下的临时文件，Commit时依次重命名为目标文件并执行暂存的删除；
+// Rollback删除临时文件和本次新建的空目录。生成被中断时不会留下写了一半的文档或图片目录。
+// 通过Txn读取时可以看到暂存的文件，但列出目录（ReadDir）只反映底层文件系统。
+type Txn struct {
+	fs WriteFS
+
+	mu      sync.Mutex
+	pending map[string]string // 目标路径 -> 临时文件
+	order   []string          // 目标路径的写入顺序
+	removed []string          // Commit时删除的路径
+	dirs    []string          // 本次新建的目录，按创建顺序
+	seq     int
+	done    bool
+}
+
+// NewTxn 在fsys上开始暂存写入
+func NewTxn(fsys WriteFS) *Txn {
+	return &Txn{fs: fsys, pending: make(map[string]string)}
+}
+
+// Open 打开文件，暂存的文件打开其临时文件，等待删除的文件视为不存在
+func (t *Txn) Open(name string) (fs.File, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.isRemoved(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	if tmp, ok := t.pending[name]; ok {
+		return t.fs.Open(tmp)
+	}
+	return t.fs.Open(name)
+}
+
+// Stat 读取文件信息
+func (t *Txn) Stat(name string) (fs.FileInfo, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.isRemoved(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	if tmp, ok := t.pending[name]; ok {
+		info, err := t.fs.Stat(tmp)
+		if err != nil {
+			return nil, err
+		}
+		return &renamedInfo{FileInfo: info, name: t.base(name)}, nil
+	}
+	return t.fs.Stat(name)
+}
+
+// MkdirAll 创建目录并记录其中新建的部分，Rollback时删除仍为空的新目录
+func (t *Txn) MkdirAll(dir string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var created []string
+	for p := dir; ; {
+		if _, err := t.fs.Stat(p); !errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		created = append(created, p)
+		parent := t.dir(p)
+		if parent == p {
+			break
+		}
+		p = parent
+	}
+	if err := t.fs.MkdirAll(dir); err != nil {
+		return err
+	}
+	for i := len(created) - 1; i >= 0; i-- {
+		t.dirs = append(t.dirs, created[i])
+	}
+	return nil
+}
+
+// WriteFile 将内容写入目标文件旁的临时文件，Commit时生效
+func (t *Txn) WriteFile(name string, data []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.done {
+		return fmt.Errorf("暂存写入已结束: %s", name)
+	}
+	t.seq++
+	tmp := t.join(t.dir(name), fmt.Sprintf(".%s.%d-%d.tmp", t.base(name), os.Getpid(), t.seq))
+	if err := t.fs.WriteFile(tmp, data); err != nil {
+		return err
+	}
+	if old, ok := t.pending[name]; ok {
+		t.fs.Remove(old)
+	} else {
+		t.order = append(t.order, name)
+	}
+	t.pending[name] = tmp
+	t.unremove(name)
+	return nil
+}
+
+// Remove 删除暂存的文件；底层文件系统中的文件或空目录在Commit时删除
+func (t *Txn) Remove(name string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if tmp, ok := t.pending[name]; ok {
+		delete(t.pending, name)
+		if err := t.fs.Remove(tmp); err != nil {
+			return err
+		}
+		// 只存在于暂存中的文件到此已删除；覆盖了底层文件时，底层文件在Commit时删除
+		if _, err := t.fs.Stat(name); err == nil {
+			t.removed = append(t.removed, name)
+		}
+		return nil
+	}
+	if _, err := t.fs.Stat(name); err != nil {
+		return err
+	}
+	t.removed = append(t.removed, name)
+	return nil
+}
+
+// Rename 重命名文件；暂存的文件改为以新名称提交，已提交的文件直接重命名
+func (t *Txn) Rename(oldname, newname string) error {
+	data, err := fs.ReadFile(t, oldname)
+	if err != nil {
+		return err
+	}
+	if err := t.WriteFile(newname, data); err != nil {
+		return err
+	}
+	return t.Remove(oldname)
+}
+
+// Commit 将临时文件重命名为目标文件并执行暂存的删除
+//
+// 重命名失败时返回错误，尚未提交的临时文件仍可由Rollback清理；删除失败（如目录不为空）被忽略
+func (t *Txn) Commit() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for len(t.order) > 0 {
+		name := t.order[0]
+		if tmp, ok := t.pending[name]; ok {
+			if err := t.fs.Rename(tmp, name); err != nil {
+				return fmt.Errorf("提交 %s 失败: %v", name, err)
+			}
+			delete(t.pending, name)
+		}
+		t.order = t.order[1:]
+	}
+	for _, name := range t.removed {
+		t.fs.Remove(name)
+	}
+	t.removed = nil
+	t.done = true
+	return nil
+}
+
+// Rollback 删除尚未提交的临时文件和本次新建的空目录；Commit成功后调用不做任何事
+func (t *Txn) Rollback() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.done {
+		return nil
+	}
+	t.done = true
+	var firstErr error
+	for _, name := range t.order {
+		if tmp, ok := t.pending[name]; ok {
+			if err := t.fs.Remove(tmp); err != nil && !errors.Is(err, fs.ErrNotExist) && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	for i := len(t.dirs) - 1; i >= 0; i-- {
+		// 目录中还有其他文件时删除失败，保留目录
+		t.fs.Remove(t.dirs[i])
+	}
+	t.pending, t.order, t.removed, t.dirs = map[string]string{}, nil, nil, nil
+	return firstErr
+}
+
+// Pending 按写入顺序返回尚未提交的目标路径
+func (t *Txn) Pending() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var names []string
+	for _, name := range t.order {
+		if _, ok := t.pending[name]; ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (t *Txn) native() bool { return Native(t.fs) }
+
+// isRemoved 判断路径是否等待删除，需持有锁
+func (t *Txn) isRemoved(name string) bool {
+	for _, removed := range t.removed {
+		if removed == name {
+			return true
+		}
+	}
+	return false
+}
+
+// unremove 取消等待中的删除，需持有锁
+func (t *Txn) unremove(name string) {
+	kept := t.removed[:0]
+	for _, removed := range t.removed {
+		if removed != name {
+			kept = append(kept, removed)
+		}
+	}
+	t.removed = kept
+}
+
+// dir、base、join 按底层文件系统的路径格式处理路径
+func (t *Txn) dir(name string) string {
+	if Native(t.fs) {
+		return filepath.Dir(name)
+	}
+	return path.Dir(name)
+}
+
+func (t *Txn) base(name string) string {
+	if Native(t.fs) {
+		return filepath.Base(name)
+	}
+	return path.Base(name)
+}
+
+func (t *Txn) join(dir, name string) string {
+	if Native(t.fs) {
+		return filepath.Join(dir, name)
+	}
+	return path.Join(dir, name)
+}
+
+// renamedInfo 以目标文件名返回临时文件的信息
+type renamedInfo struct {
+	fs.FileInfo
+	name string
+}
+
+func (i *renamedInfo) Name() string { return i.name }
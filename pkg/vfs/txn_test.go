@@ -0,0 +1,130 @@
+package vfs
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTxnCommit(t *testing.T) {
+	m := NewMemFS(map[string]string{
+		"out/手册.md":           "旧内容",
+		"out/手册.assets/a.png": "旧图片",
+	})
+	txn := NewTxn(m)
+	if err := WriteFile(txn, "out/手册.md", []byte("新内容")); err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+	if err := WriteFile(txn, "out/手册.assets/b.png", []byte("新图片")); err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+	if err := Remove(txn, "out/手册.assets/a.png"); err != nil {
+		t.Fatalf("删除失败: %v", err)
+	}
+
+	// 提交前底层文件保持不变，通过Txn读取可以看到暂存的内容
+	if data, _ := ReadFile(m, "out/手册.md"); string(data) != "旧内容" {
+		t.Errorf("提交前底层文件被修改: %q", data)
+	}
+	if data, err := ReadFile(txn, "out/手册.md"); err != nil || string(data) != "新内容" {
+		t.Errorf("读取暂存文件失败: %q %v", data, err)
+	}
+	if info, err := Stat(txn, "out/手册.assets/b.png"); err != nil || info.Name() != "b.png" {
+		t.Errorf("暂存文件信息: %v %v", info, err)
+	}
+	if _, err := Stat(txn, "out/手册.assets/a.png"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("等待删除的文件应视为不存在: %v", err)
+	}
+
+	if err := txn.Commit(); err != nil {
+		t.Fatalf("提交失败: %v", err)
+	}
+	expected := []string{"out/手册.assets/b.png", "out/手册.md"}
+	if files := m.Files(); !reflect.DeepEqual(files, expected) {
+		t.Errorf("提交后的文件: %q, 期望 %q", files, expected)
+	}
+	if data, _ := ReadFile(m, "out/手册.md"); string(data) != "新内容" {
+		t.Errorf("提交后内容: %q", data)
+	}
+	if err := txn.Rollback(); err != nil {
+		t.Errorf("提交后回滚应不做任何事: %v", err)
+	}
+}
+
+func TestTxnPendingRemoveRename(t *testing.T) {
+	m := NewMemFS(map[string]string{"out/旧.md": "旧内容"})
+	txn := NewTxn(m)
+
+	// 删除只存在于暂存中的文件
+	if err := WriteFile(txn, "out/临时.md", []byte("x")); err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+	if err := Remove(txn, "out/临时.md"); err != nil {
+		t.Errorf("删除暂存文件失败: %v", err)
+	}
+	if _, err := Stat(txn, "out/临时.md"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("删除后的暂存文件应不存在: %v", err)
+	}
+	if err := Remove(txn, "out/临时.md"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("再次删除应返回ErrNotExist: %v", err)
+	}
+
+	// 重命名只存在于暂存中的文件
+	if err := WriteFile(txn, "out/a.md", []byte("a")); err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+	if err := Rename(txn, "out/a.md", "out/b.md"); err != nil {
+		t.Errorf("重命名暂存文件失败: %v", err)
+	}
+
+	// 覆盖后删除底层文件
+	if err := WriteFile(txn, "out/旧.md", []byte("新内容")); err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+	if err := Remove(txn, "out/旧.md"); err != nil {
+		t.Errorf("删除覆盖的文件失败: %v", err)
+	}
+
+	if err := txn.Commit(); err != nil {
+		t.Fatalf("提交失败: %v", err)
+	}
+	if files := m.Files(); !reflect.DeepEqual(files, []string{"out/b.md"}) {
+		t.Errorf("提交后的文件: %q", files)
+	}
+}
+
+func TestTxnRollback(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "out", "手册.md")
+	if err := WriteFile(OSFS{}, existing, []byte("旧内容")); err != nil {
+		t.Fatal(err)
+	}
+
+	txn := NewTxn(OSFS{})
+	for _, name := range []string{existing, filepath.Join(dir, "out", "手册.assets", "img", "a.png")} {
+		if err := WriteFile(txn, name, []byte("新内容")); err != nil {
+			t.Fatalf("写入失败: %v", err)
+		}
+	}
+	if len(txn.Pending()) != 2 {
+		t.Errorf("暂存文件: %q", txn.Pending())
+	}
+	if err := txn.Rollback(); err != nil {
+		t.Fatalf("回滚失败: %v", err)
+	}
+
+	// 只剩原有文件：临时文件和新建的目录都被删除
+	entries, err := os.ReadDir(filepath.Join(dir, "out"))
+	if err != nil || len(entries) != 1 || entries[0].Name() != "手册.md" {
+		t.Errorf("回滚后的目录内容: %v %v", entries, err)
+	}
+	if data, _ := os.ReadFile(existing); string(data) != "旧内容" {
+		t.Errorf("回滚后原有文件被修改: %q", data)
+	}
+	if err := WriteFile(txn, existing, nil); err == nil {
+		t.Error("回滚后不能继续写入")
+	}
+}
@@ -0,0 +1,91 @@
+// Package vfs 文件系统抽象：读取使用io/fs接口，写入使用WriteFS
+//
+// 模板和图片可以来自本地目录、ZIP包（zip.Reader）、嵌入的模板集（embed.FS）或内存，
+// 输出写入本地目录或内存（测试）。
+//
+// 路径约定：OSFS直接使用操作系统格式的路径（含绝对路径），其他文件系统只接受io/fs格式的路径
+// （以/分隔、不以/开头）。本包的ReadFile、Stat、WriteFile等函数按文件系统类型转换路径，
+// 因此处理流程可以统一使用filepath拼接路径。
+package vfs
+
+import (
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// WriteFS 可写文件系统
+type WriteFS interface {
+	fs.StatFS
+	// MkdirAll 创建目录及其所有上级目录，目录已存在时不返回错误
+	MkdirAll(dir string) error
+	// WriteFile 写入文件，所在目录必须已存在；文件已存在时覆盖
+	WriteFile(name string, data []byte) error
+	// Remove 删除文件或空目录
+	Remove(name string) error
+	// Rename 重命名文件，目标文件已存在时覆盖，所在目录必须已存在
+	Rename(oldname, newname string) error
+}
+
+// nativeFS 使用操作系统格式路径的文件系统，如OSFS和包装OSFS的Txn
+type nativeFS interface {
+	native() bool
+}
+
+// Native 判断文件系统是否使用操作系统格式的路径
+func Native(fsys fs.FS) bool {
+	n, ok := fsys.(nativeFS)
+	return ok && n.native()
+}
+
+// Clean 将路径转换为io/fs格式：统一分隔符为/、去掉开头的/和多余的 . 与 ..
+func Clean(name string) string {
+	p := path.Clean(filepath.ToSlash(name))
+	p = strings.TrimLeft(p, "/")
+	if p == "" {
+		return "."
+	}
+	return p
+}
+
+// Name 返回路径在文件系统中使用的名称：OSFS保持操作系统格式，其他文件系统使用Clean后的路径
+func Name(fsys fs.FS, name string) string {
+	if Native(fsys) {
+		return name
+	}
+	return Clean(name)
+}
+
+// ReadFile 读取文件
+func ReadFile(fsys fs.FS, name string) ([]byte, error) {
+	return fs.ReadFile(fsys, Name(fsys, name))
+}
+
+// Stat 读取文件信息
+func Stat(fsys fs.FS, name string) (fs.FileInfo, error) {
+	return fs.Stat(fsys, Name(fsys, name))
+}
+
+// MkdirAll 创建目录及其所有上级目录
+func MkdirAll(fsys WriteFS, dir string) error {
+	return fsys.MkdirAll(Name(fsys, dir))
+}
+
+// WriteFile 写入文件，自动创建所在目录
+func WriteFile(fsys WriteFS, name string, data []byte) error {
+	if err := MkdirAll(fsys, filepath.Dir(name)); err != nil {
+		return err
+	}
+	return fsys.WriteFile(Name(fsys, name), data)
+}
+
+// Remove 删除文件或空目录
+func Remove(fsys WriteFS, name string) error {
+	return fsys.Remove(Name(fsys, name))
+}
+
+// Rename 重命名文件
+func Rename(fsys WriteFS, oldname, newname string) error {
+	return fsys.Rename(Name(fsys, oldname), Name(fsys, newname))
+}
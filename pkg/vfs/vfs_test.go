@@ -0,0 +1,101 @@
+package vfs
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestClean(t *testing.T) {
+	tests := map[string]string{
+		"a/b.md":      "a/b.md",
+		"./a//b/../c": "a/c",
+		"/abs/a.md":   "abs/a.md",
+		"":            ".",
+		"/":           ".",
+		"../a":        "../a",
+		`a\b\c.png`:   filepath.ToSlash(`a\b\c.png`),
+	}
+	for input, expected := range tests {
+		if got := Clean(input); got != expected {
+			t.Errorf("Clean(%q) = %q, 期望 %q", input, got, expected)
+		}
+	}
+}
+
+func TestMemFS(t *testing.T) {
+	m := NewMemFS(map[string]string{
+		"docs/手册_1.0.0.md":      "# 手册",
+		"docs/images/a.png":     "png",
+		"docs/images/sub/b.png": "png",
+	})
+	if err := fstest.TestFS(m, "docs/手册_1.0.0.md", "docs/images/a.png", "docs/images/sub/b.png"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 写入需要所在目录已存在
+	if err := m.WriteFile("out/a.md", []byte("x")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("目录不存在时写入应失败: %v", err)
+	}
+	if err := WriteFile(m, "out/a.md", []byte("x")); err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+	// 操作系统格式的路径与io/fs格式指向同一文件
+	if data, err := ReadFile(m, "./out/../out/a.md"); err != nil || string(data) != "x" {
+		t.Errorf("读取失败: %q %v", data, err)
+	}
+	if err := MkdirAll(m, "out/a.md/x"); err == nil {
+		t.Error("文件下不能创建目录")
+	}
+	if _, err := Stat(m, "../a.md"); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("超出根目录的路径应无效: %v", err)
+	}
+
+	// 删除
+	if err := Remove(m, "docs/images"); err == nil {
+		t.Error("非空目录不能删除")
+	}
+	for _, name := range []string{"docs/images/sub/b.png", "docs/images/sub"} {
+		if err := Remove(m, name); err != nil {
+			t.Errorf("删除 %s 失败: %v", name, err)
+		}
+	}
+	if err := Remove(m, "none"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("删除不存在的文件应返回ErrNotExist: %v", err)
+	}
+
+	// 重命名
+	if err := Rename(m, "out/a.md", "out/b.md"); err != nil {
+		t.Errorf("重命名失败: %v", err)
+	}
+	if err := Rename(m, "out/b.md", "none/b.md"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("目标目录不存在时重命名应失败: %v", err)
+	}
+
+	expected := []string{"docs/images/a.png", "docs/手册_1.0.0.md", "out/b.md"}
+	if files := m.Files(); !reflect.DeepEqual(files, expected) {
+		t.Errorf("文件列表: %q, 期望 %q", files, expected)
+	}
+}
+
+func TestOSFS(t *testing.T) {
+	dir := t.TempDir()
+	var fsys WriteFS = OSFS{}
+	name := filepath.Join(dir, "a", "b.assets", "c.png")
+	if err := WriteFile(fsys, name, []byte("png")); err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+	if data, err := ReadFile(fsys, name); err != nil || string(data) != "png" {
+		t.Errorf("读取失败: %q %v", data, err)
+	}
+	if err := Remove(fsys, name); err != nil {
+		t.Errorf("删除失败: %v", err)
+	}
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Errorf("文件未删除: %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+//go:build linux
+
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// inotifyMask 关注的事件：写入完成、修改、创建、删除和重命名（编辑器常以重命名方式保存）
+const inotifyMask = syscall.IN_CLOSE_WRITE | syscall.IN_MODIFY | syscall.IN_CREATE |
+	syscall.IN_DELETE | syscall.IN_MOVED_TO | syscall.IN_MOVED_FROM | syscall.IN_ATTRIB
+
+// inotifySource 基于Linux inotify的事件来源，监视文件所在目录
+type inotifySource struct {
+	file *os.File
+	dirs map[string]int32 // 目录 -> watch描述符
+	wds  map[int32]string // watch描述符 -> 目录
+	buf  []byte
+}
+
+// newInotifySource 创建inotify事件来源
+func newInotifySource() (source, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("初始化inotify失败: %v", err)
+	}
+	return &inotifySource{
+		file: os.NewFile(uintptr(fd), "inotify"),
+		dirs: make(map[string]int32),
+		wds:  make(map[int32]string),
+		buf:  make([]byte, 64*1024),
+	}, nil
+}
+
+// next 等待监视文件所在目录中与这些文件相关的事件
+func (s *inotifySource) next(ctx context.Context, files []string) ([]string, error) {
+	watched := make(map[string]bool)
+	for _, f := range files {
+		watched[f] = true
+		dir := filepath.Dir(f)
+		if _, ok := s.dirs[dir]; ok {
+			continue
+		}
+		wd, err := syscall.InotifyAddWatch(int(s.file.Fd()), dir, inotifyMask)
+		if err != nil {
+			// 目录暂不存在时忽略，下一轮重新尝试
+			continue
+		}
+		s.dirs[dir] = int32(wd)
+		s.wds[int32(wd)] = dir
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		// 设置较短的读取超时以便及时响应ctx
+		s.file.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+		n, err := s.file.Read(s.buf)
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("读取inotify事件失败: %v", err)
+		}
+
+		changed := s.parse(s.buf[:n], watched)
+		if len(changed) > 0 {
+			return changed, nil
+		}
+	}
+}
+
+// parse 解析事件，返回被监视的文件
+func (s *inotifySource) parse(buf []byte, watched map[string]bool) []string {
+	seen := make(map[string]bool)
+	var changed []string
+	for offset := 0; offset+syscall.SizeofInotifyEvent <= len(buf); {
+		event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+		nameStart := offset + syscall.SizeofInotifyEvent
+		nameEnd := nameStart + int(event.Len)
+		if nameEnd > len(buf) {
+			break
+		}
+
+		name := string(buf[nameStart:nameEnd])
+		for len(name) > 0 && name[len(name)-1] == 0 {
+			name = name[:len(name)-1]
+		}
+		if dir, ok := s.wds[event.Wd]; ok && name != "" {
+			path := filepath.Join(dir, name)
+			if watched[path] && !seen[path] {
+				seen[path] = true
+				changed = append(changed, path)
+			}
+		}
+		offset = nameEnd
+	}
+	return changed
+}
+
+// close 关闭inotify
+func (s *inotifySource) close() error {
+	return s.file.Close()
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package watch
+
+import "errors"
+
+// newInotifySource 非Linux平台不支持inotify，使用轮询
+func newInotifySource() (source, error) {
+	return nil, errors.New("当前平台不支持inotify")
+}
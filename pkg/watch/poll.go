@@ -0,0 +1,73 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// fileState 轮询时记录的文件状态
+type fileState struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+// pollSource 基于定时比较文件状态的事件来源，适用于所有平台
+type pollSource struct {
+	interval time.Duration
+	states   map[string]fileState
+}
+
+// newPollSource 创建轮询事件来源
+func newPollSource(interval time.Duration) *pollSource {
+	return &pollSource{
+		interval: interval,
+		states:   make(map[string]fileState),
+	}
+}
+
+// next 定时比较文件状态，首次出现的文件只记录状态不视为变化
+func (s *pollSource) next(ctx context.Context, files []string) ([]string, error) {
+	for _, f := range files {
+		if _, ok := s.states[f]; !ok {
+			s.states[f] = statFile(f)
+		}
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+
+		var changed []string
+		for _, f := range files {
+			state := statFile(f)
+			if state != s.states[f] {
+				s.states[f] = state
+				changed = append(changed, f)
+			}
+		}
+		if len(changed) > 0 {
+			return changed, nil
+		}
+	}
+}
+
+// close 轮询来源无需释放资源
+func (s *pollSource) close() error {
+	return nil
+}
+
+// statFile 读取文件状态
+func statFile(path string) fileState {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileState{}
+	}
+	return fileState{exists: true, size: info.Size(), modTime: info.ModTime()}
+}
@@ -0,0 +1,130 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"md-manual-tool/pkg/constants"
+	"md-manual-tool/pkg/i18n"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// 默认参数
+const (
+	DefaultInterval = 500 * time.Millisecond
+	DefaultDebounce = 300 * time.Millisecond
+)
+
+// source 文件变化事件来源
+type source interface {
+	// next 阻塞直到files中有文件发生变化，返回变化的文件；ctx结束时返回ctx.Err()
+	next(ctx context.Context, files []string) ([]string, error)
+	close() error
+}
+
+// Watcher 文件监视器，合并短时间内的多次变化后触发回调
+type Watcher struct {
+	Interval time.Duration // 轮询间隔
+	Debounce time.Duration // 最后一次变化后等待的静默时间
+	Polling  bool          // 强制使用轮询（不使用inotify）
+}
+
+// NewWatcher 创建使用默认参数的监视器
+func NewWatcher() *Watcher {
+	return &Watcher{
+		Interval: DefaultInterval,
+		Debounce: DefaultDebounce,
+	}
+}
+
+// Run 监视文件直到ctx结束
+// files在每次回调后重新调用，以便跟踪模板中新增或删除的图片；onChange收到本轮变化的文件
+func (w *Watcher) Run(ctx context.Context, files func() []string, onChange func(changed []string)) error {
+	src, err := w.newSource()
+	if err != nil {
+		return err
+	}
+	defer src.close()
+
+	for {
+		watched := absPaths(files())
+		changed, err := src.next(ctx, watched)
+		if err != nil {
+			return ignoreCanceled(err)
+		}
+
+		// 防抖：持续收集变化，直到静默Debounce时间
+		pending := make(map[string]bool)
+		for _, f := range changed {
+			pending[f] = true
+		}
+		for {
+			quietCtx, cancel := context.WithTimeout(ctx, w.Debounce)
+			more, err := src.next(quietCtx, watched)
+			cancel()
+			if errors.Is(err, context.DeadlineExceeded) {
+				break
+			}
+			if err != nil {
+				return ignoreCanceled(err)
+			}
+			for _, f := range more {
+				pending[f] = true
+			}
+		}
+
+		onChange(sortedKeys(pending))
+	}
+}
+
+// newSource 选择事件来源：优先inotify，不可用时退回轮询
+func (w *Watcher) newSource() (source, error) {
+	interval := w.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	if !w.Polling {
+		if src, err := newInotifySource(); err == nil {
+			return src, nil
+		} else {
+			fmt.Print(i18n.Sprintf(constants.MsgInotifyFallback, err))
+		}
+	}
+	return newPollSource(interval), nil
+}
+
+// absPaths 转换为去重后的绝对路径
+func absPaths(files []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, f := range files {
+		if abs, err := filepath.Abs(f); err == nil {
+			f = abs
+		}
+		if !seen[f] {
+			seen[f] = true
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// sortedKeys 返回排序后的键
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ignoreCanceled ctx被取消属于正常退出
+func ignoreCanceled(err error) error {
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,56 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatcherDebounce(t *testing.T) {
+	for _, polling := range []bool{true, false} {
+		dir := t.TempDir()
+		file := filepath.Join(dir, "template.md")
+		other := filepath.Join(dir, "other.md")
+		os.WriteFile(file, []byte("v1"), 0644)
+
+		w := &Watcher{Interval: 20 * time.Millisecond, Debounce: 150 * time.Millisecond, Polling: polling}
+		ctx, cancel := context.WithCancel(context.Background())
+		calls := make(chan []string, 10)
+		done := make(chan error, 1)
+		go func() {
+			done <- w.Run(ctx, func() []string { return []string{file} }, func(changed []string) {
+				calls <- changed
+			})
+		}()
+
+		// 等待监视器就绪后连续修改多次，应只触发一次回调
+		time.Sleep(100 * time.Millisecond)
+		os.WriteFile(other, []byte("ignored"), 0644)
+		for i := 0; i < 3; i++ {
+			os.WriteFile(file, []byte("v2"+string(rune('a'+i))), 0644)
+			time.Sleep(30 * time.Millisecond)
+		}
+
+		select {
+		case changed := <-calls:
+			if len(changed) != 1 || changed[0] != file {
+				t.Errorf("polling=%v: 变化的文件错误: %v", polling, changed)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("polling=%v: 未收到变化通知", polling)
+		}
+
+		select {
+		case changed := <-calls:
+			t.Errorf("polling=%v: 防抖后不应再次触发: %v", polling, changed)
+		case <-time.After(300 * time.Millisecond):
+		}
+
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("polling=%v: 取消后应正常退出: %v", polling, err)
+		}
+	}
+}